@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 
@@ -15,11 +17,23 @@ var m = plugin.Manifest{
 	Description:   "Manage Datum Cloud DNS zones (reference plugin example)",
 	APIVersion:    1,
 	MinAPIVersion: 1,
+	// Tools are offered to the datumctl AI agent as dns_<name>.
+	Tools: []plugin.Tool{{
+		Name:        "list_zones",
+		Description: "List the DNS zones in the current project",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+	}},
 }
 
 func main() {
 	// ServeManifest handles --plugin-manifest and exits before cobra runs.
 	plugin.ServeManifest(m)
+	// ServeTools handles --plugin-tool calls from the AI agent and exits.
+	plugin.ServeTools(map[string]plugin.ToolHandler{
+		"list_zones": func(ctx context.Context, _ map[string]any) (string, error) {
+			return listZones(ctx)
+		},
+	})
 
 	root := plugin.NewRootCmd("dns", "Manage Datum Cloud DNS resources")
 
@@ -32,42 +46,11 @@ func main() {
 		Use:   "list",
 		Short: "List DNS zones",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := plugin.Context()
-
-			if ctx.CredentialsHelper == "" {
-				return fmt.Errorf("DATUM_CREDENTIALS_HELPER is not set; run this plugin via 'datumctl dns zones list'")
-			}
-
-			// Demonstrate token acquisition — the core of the end-to-end demo.
-			token, err := plugin.Token()
-			if err != nil {
-				return fmt.Errorf("failed to get credentials: %w", err)
-			}
-
-			// Make a real API call using the injected context and fresh token.
-			// In a production plugin this would call the Datum Cloud DNS API.
-			apiURL := fmt.Sprintf("https://%s/v1/organizations/%s/projects/%s/dnszones",
-				ctx.APIHost, ctx.Org, ctx.Project)
-
-			req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet, apiURL, nil)
+			body, err := listZones(cmd.Context())
 			if err != nil {
 				return err
 			}
-			req.Header.Set("Authorization", "Bearer "+token)
-
-			resp, err := http.DefaultClient.Do(req)
-			if err != nil {
-				return fmt.Errorf("API request failed: %w", err)
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusOK {
-				return fmt.Errorf("API returned %d", resp.StatusCode)
-			}
-
-			fmt.Fprintf(cmd.OutOrStdout(),
-				"DNS zones for org=%s project=%s (status %d)\n",
-				ctx.Org, ctx.Project, resp.StatusCode)
+			fmt.Fprintln(cmd.OutOrStdout(), body)
 			return nil
 		},
 	}
@@ -79,3 +62,47 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// listZones lists the DNS zones in the current project and returns the API
+// response body. It backs both the "zones list" command and the list_zones
+// AI tool.
+func listZones(ctx context.Context) (string, error) {
+	pctx := plugin.Context()
+
+	if pctx.CredentialsHelper == "" {
+		return "", fmt.Errorf("DATUM_CREDENTIALS_HELPER is not set; run this plugin via 'datumctl dns zones list'")
+	}
+
+	// Demonstrate token acquisition — the core of the end-to-end demo.
+	token, err := plugin.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	// Make a real API call using the injected context and fresh token.
+	// In a production plugin this would call the Datum Cloud DNS API.
+	apiURL := fmt.Sprintf("https://%s/v1/organizations/%s/projects/%s/dnszones",
+		pctx.APIHost, pctx.Org, pctx.Project)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read API response: %w", err)
+	}
+	return string(body), nil
+}
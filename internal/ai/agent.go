@@ -11,14 +11,6 @@ import (
 	"go.datum.net/datumctl/internal/ai/llm"
 )
 
-const (
-	// historyWindowSize is the maximum number of messages sent to the LLM in a
-	// single Chat call. Older messages are dropped from the window (not from
-	// the full history slice) to avoid hitting provider token limits in long
-	// interactive sessions.
-	historyWindowSize = 40
-)
-
 // AgentOptions configures an Agent.
 type AgentOptions struct {
 	LLM           llm.LLMClient
@@ -26,6 +18,14 @@ type AgentOptions struct {
 	SystemPrompt  string
 	MaxIterations int
 
+	// ContextTokens overrides the model's context window, in tokens, used to
+	// decide when to compact the history. Zero uses llm.ContextWindow.
+	ContextTokens int
+
+	// MaxSessionTokens stops the session once its model calls have used this
+	// many tokens, cached ones included. Zero means no limit.
+	MaxSessionTokens int
+
 	// In/Out/ErrOut allow callers to substitute streams for testing.
 	// When nil, os.Stdin/os.Stdout/os.Stderr are used.
 	In     io.Reader
@@ -42,13 +42,37 @@ type AgentOptions struct {
 	// If nil, StdinGate{In, ErrOut} is used when IsTerminal is true,
 	// and AutoDeclineGate is used otherwise.
 	Gate ConfirmGate
+
+	// Policy approves or denies destructive tool calls before Gate is
+	// consulted. Nil leaves every call to Gate.
+	Policy *Policy
+
+	// AfterTurn, when set, is called by Run after every turn, successful or
+	// not, with the full transcript, so the conversation can be saved.
+	AfterTurn func(transcript []llm.Message)
+
+	// Project reports the project the session is in, for Policy. It is a
+	// function because change_context can move the session. Nil means no
+	// project.
+	Project func() string
 }
 
 // Agent runs the agentic loop.
 type Agent struct {
 	opts        AgentOptions
-	history     []llm.Message
+	history     []llm.Message // what the model sees; compacted by fitHistory
+	transcript  []llm.Message // everything said, for saving
 	toolEventCh chan<- string // nil between turns; set via SetToolEventCh
+	events      chan<- Event  // set during RunTurnEvents
+
+	// The current turn's LLM calls and tool calls, reported in TurnResult.
+	iterations int
+	toolCalls  []ToolCallRecord
+
+	// Token usage reported by the provider, for the current turn and for
+	// every turn of the session.
+	turnUsage    llm.Usage
+	sessionUsage llm.Usage
 }
 
 // NewAgent creates an Agent from the given options.
@@ -77,26 +101,38 @@ func NewAgent(opts AgentOptions) *Agent {
 
 // Run executes the agentic loop starting with initialQuery. In interactive
 // mode it loops, reading subsequent queries from stdin after each response.
+// An empty initialQuery in interactive mode starts by reading one, as when a
+// saved conversation is resumed.
 func (a *Agent) Run(ctx context.Context, initialQuery string) error {
-	a.history = append(a.history, llm.Message{Role: llm.RoleUser, Content: initialQuery})
-
+	sc := bufio.NewScanner(a.opts.In)
+	query := initialQuery
 	for {
-		if err := a.runOnce(ctx); err != nil {
-			return err
+		if query == "" && a.opts.Interactive {
+			fmt.Fprintf(a.opts.Out, "\n> ")
+			if !sc.Scan() {
+				return nil // EOF
+			}
+			query = sc.Text()
+			if query == "" || query == "exit" || query == "quit" {
+				return nil
+			}
 		}
-		if !a.opts.Interactive {
-			return nil
+		a.record(llm.Message{Role: llm.RoleUser, Content: query})
+
+		err := a.runOnce(ctx)
+		if summary := a.UsageSummary(); summary != "" {
+			fmt.Fprintf(a.opts.ErrOut, "[ai] %s\n", summary)
 		}
-		fmt.Fprintf(a.opts.Out, "\n> ")
-		sc := bufio.NewScanner(a.opts.In)
-		if !sc.Scan() {
-			return nil // EOF
+		if a.opts.AfterTurn != nil {
+			a.opts.AfterTurn(a.Transcript())
 		}
-		line := sc.Text()
-		if line == "" || line == "exit" || line == "quit" {
+		if err != nil {
+			return err
+		}
+		if !a.opts.Interactive {
 			return nil
 		}
-		a.history = append(a.history, llm.Message{Role: llm.RoleUser, Content: line})
+		query = ""
 	}
 }
 
@@ -104,13 +140,56 @@ func (a *Agent) Run(ctx context.Context, initialQuery string) error {
 type TurnResult struct {
 	Response string
 	Err      error
+
+	// ToolCalls lists the turn's tool calls in the order they were made.
+	ToolCalls []ToolCallRecord
+	// Iterations is how many times the model was called.
+	Iterations int
+	// Usage is the tokens the turn used, and SessionUsage those used by the
+	// session so far, as far as the provider reports them.
+	Usage        llm.Usage
+	SessionUsage llm.Usage
+}
+
+// ToolCallRecord is one tool call and its outcome. Exactly one of Result,
+// Error, and Skipped is set once the call has finished.
+type ToolCallRecord struct {
+	ID        string         `json:"id"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+	Result    string         `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	// Skipped says why a change was not made: the user declined it, nobody
+	// could be asked, or the policy denied it.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// EventKind identifies what an Event reports.
+type EventKind string
+
+const (
+	// EventText carries a chunk of the model's answer.
+	EventText EventKind = "text"
+	// EventToolCall reports a tool call the model made, before it runs.
+	EventToolCall EventKind = "tool_call"
+	// EventToolResult reports how a tool call ended.
+	EventToolResult EventKind = "tool_result"
+)
+
+// Event is one step of a turn, sent by RunTurnEvents as it happens.
+type Event struct {
+	Kind EventKind
+	// Iteration is the model call, from 1, the event belongs to.
+	Iteration int
+	Text      string
+	Call      *ToolCallRecord
 }
 
 // RunTurn executes one full user→LLM→tools→response cycle without looping for
 // more user input. Safe to call from a goroutine (e.g. a Bubbletea tea.Cmd).
 // The user message is appended to the shared history before the LLM call.
 func (a *Agent) RunTurn(ctx context.Context, userMessage string) TurnResult {
-	a.history = append(a.history, llm.Message{Role: llm.RoleUser, Content: userMessage})
+	a.record(llm.Message{Role: llm.RoleUser, Content: userMessage})
 
 	// Collect the response into a strings.Builder instead of streaming to Out,
 	// so the TUI pane can append it all at once.
@@ -120,18 +199,89 @@ func (a *Agent) RunTurn(ctx context.Context, userMessage string) TurnResult {
 	err := a.runOnce(ctx)
 	a.opts.Out = savedOut
 
-	return TurnResult{Response: buf.String(), Err: err}
+	return a.turnResult(buf.String(), err)
+}
+
+// RunTurnEvents executes one turn like RunTurn and sends each answer chunk,
+// tool call, and tool result to events as it happens, in order. Sends block
+// until received or ctx is done, so the caller must drain events while the
+// turn runs; RunTurnEvents does not close it.
+func (a *Agent) RunTurnEvents(ctx context.Context, userMessage string, events chan<- Event) TurnResult {
+	a.record(llm.Message{Role: llm.RoleUser, Content: userMessage})
+
+	a.events = events
+	var buf strings.Builder
+	savedOut := a.opts.Out
+	a.opts.Out = &eventWriter{buf: &buf, emit: func(text string) { a.emit(ctx, Event{Kind: EventText, Text: text}) }}
+	err := a.runOnce(ctx)
+	a.opts.Out = savedOut
+	a.events = nil
+
+	return a.turnResult(buf.String(), err)
+}
+
+func (a *Agent) turnResult(response string, err error) TurnResult {
+	return TurnResult{
+		Response:     response,
+		Err:          err,
+		ToolCalls:    a.toolCalls,
+		Iterations:   a.iterations,
+		Usage:        a.turnUsage,
+		SessionUsage: a.sessionUsage,
+	}
+}
+
+// emit sends ev to the RunTurnEvents channel, if there is one.
+func (a *Agent) emit(ctx context.Context, ev Event) {
+	if a.events == nil {
+		return
+	}
+	ev.Iteration = a.iterations
+	select {
+	case a.events <- ev:
+	case <-ctx.Done():
+	}
+}
+
+// eventWriter collects the answer and emits each chunk as an EventText.
+type eventWriter struct {
+	buf  *strings.Builder
+	emit func(string)
 }
 
-// ClearHistory resets the conversation history. Used by the TUI's ctrl+l keybind.
+func (w *eventWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p) //nolint:errcheck
+	w.emit(string(p))
+	return len(p), nil
+}
+
+// ClearHistory resets the conversation history, and with it the session's
+// token usage and budget. Used by the TUI's ctrl+l keybind.
 func (a *Agent) ClearHistory() {
-	a.history = nil
+	a.SetHistory(nil)
 }
 
-// SetHistory replaces the agent's conversation history. Used by the TUI to
-// restore a saved conversation when the chat pane is re-opened.
+// SetHistory replaces the agent's conversation history and transcript. Used
+// to resume a saved conversation, which starts a new session: the token
+// usage and budget count from zero, since earlier sessions' usage is not
+// saved.
 func (a *Agent) SetHistory(msgs []llm.Message) {
-	a.history = msgs
+	a.history = append([]llm.Message(nil), msgs...)
+	a.transcript = append([]llm.Message(nil), msgs...)
+	a.turnUsage = llm.Usage{}
+	a.sessionUsage = llm.Usage{}
+}
+
+// Transcript returns every message of the conversation, including tool calls
+// and results. Unlike the history sent to the model, it is never compacted.
+func (a *Agent) Transcript() []llm.Message {
+	return append([]llm.Message(nil), a.transcript...)
+}
+
+// record appends m to both the history and the transcript.
+func (a *Agent) record(m llm.Message) {
+	a.history = append(a.history, m)
+	a.transcript = append(a.transcript, m)
 }
 
 // SetGate replaces the confirmation gate. Called before each turn so the TUI
@@ -149,7 +299,7 @@ func (a *Agent) SetToolEventCh(ch chan<- string) {
 // RunTurnStream executes one turn and streams LLM token chunks to chunkCh as
 // they arrive. Returns TurnResult when the turn is complete.
 func (a *Agent) RunTurnStream(ctx context.Context, userMessage string, chunkCh chan<- string) TurnResult {
-	a.history = append(a.history, llm.Message{Role: llm.RoleUser, Content: userMessage})
+	a.record(llm.Message{Role: llm.RoleUser, Content: userMessage})
 
 	var buf strings.Builder
 	savedOut := a.opts.Out
@@ -157,7 +307,7 @@ func (a *Agent) RunTurnStream(ctx context.Context, userMessage string, chunkCh c
 	err := a.runOnce(ctx)
 	a.opts.Out = savedOut
 
-	return TurnResult{Response: buf.String(), Err: err}
+	return a.turnResult(buf.String(), err)
 }
 
 // teeWriter writes to both a buffer and a string channel (for streaming).
@@ -180,12 +330,18 @@ func (w *teeWriter) Write(p []byte) (int, error) {
 // runOnce executes one question→tool-calls→answer cycle, up to MaxIterations.
 func (a *Agent) runOnce(ctx context.Context) error {
 	toolDefs := a.opts.Registry.Defs()
+	a.iterations, a.toolCalls = 0, nil
+	a.turnUsage = llm.Usage{}
 
 	for iter := 0; iter < a.opts.MaxIterations; iter++ {
+		if err := a.checkBudget(); err != nil {
+			return err
+		}
+		a.iterations++
 		spinner := NewSpinner(a.opts.ErrOut, a.opts.IsTerminal)
 		spinner.Run()
 
-		window := a.historyWindow()
+		window := a.fitHistory(ctx, toolDefs)
 
 		resp, err := a.opts.LLM.StreamChat(ctx, a.opts.SystemPrompt, window, toolDefs, &spinnerClearWriter{
 			spinner: spinner,
@@ -195,7 +351,8 @@ func (a *Agent) runOnce(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("LLM error: %w", err)
 		}
-		a.history = append(a.history, resp)
+		a.addUsage(resp.Usage)
+		a.record(resp)
 
 		if len(resp.ToolCalls) == 0 {
 			fmt.Fprintln(a.opts.Out)
@@ -204,7 +361,7 @@ func (a *Agent) runOnce(ctx context.Context) error {
 
 		for _, tc := range resp.ToolCalls {
 			result, isErr := a.executeToolCall(ctx, tc)
-			a.history = append(a.history, llm.Message{
+			a.record(llm.Message{
 				Role: llm.RoleToolResult,
 				ToolResult: &llm.ToolResult{
 					CallID:  tc.ID,
@@ -234,7 +391,8 @@ func (w *spinnerClearWriter) Write(p []byte) (int, error) {
 	return w.out.Write(p)
 }
 
-// executeToolCall finds the tool, handles confirmation, and runs it.
+// executeToolCall runs one tool call, records it for the TurnResult, and
+// reports it to the event channels. It returns the content for the model.
 func (a *Agent) executeToolCall(ctx context.Context, tc llm.ToolCall) (string, bool) {
 	if a.toolEventCh != nil {
 		select {
@@ -242,33 +400,74 @@ func (a *Agent) executeToolCall(ctx context.Context, tc llm.ToolCall) (string, b
 		default:
 		}
 	}
+	rec := ToolCallRecord{ID: tc.ID, Tool: tc.ToolName, Arguments: tc.Arguments}
+	started := rec
+	a.emit(ctx, Event{Kind: EventToolCall, Call: &started})
+
+	content, isErr, skipped := a.callTool(ctx, tc)
+	switch {
+	case skipped != "":
+		rec.Skipped = skipped
+	case isErr:
+		rec.Error = content
+	default:
+		rec.Result = content
+	}
+	a.toolCalls = append(a.toolCalls, rec)
+	a.emit(ctx, Event{Kind: EventToolResult, Call: &rec})
+	return content, isErr
+}
+
+// callTool finds the tool, handles confirmation, and runs it. skipped is set
+// when a change was not made, and says why.
+func (a *Agent) callTool(ctx context.Context, tc llm.ToolCall) (content string, isErr bool, skipped string) {
 	tool, ok := a.opts.Registry.Find(tc.ToolName)
 	if !ok {
-		return fmt.Sprintf("unknown tool %q", tc.ToolName), true
+		return fmt.Sprintf("unknown tool %q", tc.ToolName), true, ""
 	}
 
 	if tool.RequiresConfirm {
-		if !a.opts.Gate.Confirm(tc) {
-			return `{"skipped":true,"reason":"user declined"}`, false
+		project := ""
+		if a.opts.Project != nil {
+			project = a.opts.Project()
+		}
+		switch decision := a.opts.Policy.Evaluate(tc, project); decision.Verdict {
+		case VerdictDeny:
+			fmt.Fprintf(a.opts.ErrOut, "[ai] %s denied by policy: %s\n", tc.ToolName, decision.Reason)
+			return DeniedResult(decision.Reason), false, "denied by policy: " + decision.Reason
+		case VerdictApprove:
+			fmt.Fprintf(a.opts.ErrOut, "[ai] %s approved by policy: %s\n", tc.ToolName, decision.Reason)
+		default:
+			// A gate that cannot ask declines regardless; spare the server the
+			// dry run, or for a delete the search for dependents.
+			preview := ""
+			if _, declines := a.opts.Gate.(AutoDeclineGate); !declines {
+				preview = a.preview(ctx, tool, tc)
+			}
+			if !a.opts.Gate.Confirm(tc, preview) {
+				return `{"skipped":true,"reason":"user declined"}`, false, "not confirmed"
+			}
 		}
 	}
 
 	result, err := tool.Execute(ctx, tc.Arguments)
 	if err != nil {
 		fmt.Fprintf(a.opts.ErrOut, "[ai] tool %s error: %v\n", tc.ToolName, err)
-		return err.Error(), true
+		return err.Error(), true, ""
 	}
-	return result, false
+	return result, false, ""
 }
 
-// historyWindow returns the slice of history to send to Chat, capped at
-// historyWindowSize messages.
-func (a *Agent) historyWindow() []llm.Message {
-	if len(a.history) <= historyWindowSize {
-		return a.history
+// preview asks the tool to describe what the call would change. A tool
+// without a preview, or one that fails, falls back to the raw arguments.
+func (a *Agent) preview(ctx context.Context, tool *Tool, tc llm.ToolCall) string {
+	if tool.Preview == nil {
+		return ""
+	}
+	preview, err := tool.Preview(ctx, tc.Arguments)
+	if err != nil {
+		fmt.Fprintf(a.opts.ErrOut, "[ai] could not preview %s: %v\n", tc.ToolName, err)
+		return ""
 	}
-	fmt.Fprintf(a.opts.ErrOut,
-		"[ai] warning: conversation history (%d messages) exceeds window (%d); oldest messages dropped\n",
-		len(a.history), historyWindowSize)
-	return a.history[len(a.history)-historyWindowSize:]
+	return preview
 }
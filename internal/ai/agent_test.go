@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+func TestRunTurnEventsReportsToolCalls(t *testing.T) {
+	agent := NewAgent(AgentOptions{
+		LLM: &scriptedLLM{call: deleteCall("DNSZone")},
+		Registry: NewRegistryOf(Tool{
+			Def:             llm.ToolDef{Name: "delete_resource"},
+			RequiresConfirm: true,
+			Execute: func(ctx context.Context, args map[string]any) (string, error) {
+				t.Error("unconfirmed tool ran")
+				return "", nil
+			},
+		}),
+		Out:    io.Discard,
+		ErrOut: io.Discard,
+	})
+
+	events := make(chan Event)
+	var got []Event
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range events {
+			got = append(got, ev)
+		}
+	}()
+	result := agent.RunTurnEvents(context.Background(), "delete the zone", events)
+	close(events)
+	<-done
+
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if result.Response != "done\n" || result.Iterations != 2 {
+		t.Errorf("result = %q after %d iterations, want %q after 2", result.Response, result.Iterations, "done\n")
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].Tool != "delete_resource" || result.ToolCalls[0].Skipped != "not confirmed" {
+		t.Fatalf("tool calls = %+v, want one skipped delete_resource", result.ToolCalls)
+	}
+
+	want := []struct {
+		kind      EventKind
+		iteration int
+	}{
+		{EventToolCall, 1}, {EventToolResult, 1}, {EventText, 2}, {EventText, 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Kind != w.kind || got[i].Iteration != w.iteration {
+			t.Errorf("event %d = %s in iteration %d, want %s in iteration %d", i, got[i].Kind, got[i].Iteration, w.kind, w.iteration)
+		}
+	}
+	if got[0].Call.Skipped != "" || got[1].Call.Skipped != "not confirmed" {
+		t.Errorf("tool_call should report the call before it ran, tool_result how it ended: %+v, %+v", got[0].Call, got[1].Call)
+	}
+}
+
+func TestRunResumesAndReportsTranscript(t *testing.T) {
+	var saved []llm.Message
+	agent := NewAgent(AgentOptions{
+		LLM: &scriptedLLM{call: deleteCall("DNSZone")},
+		Registry: NewRegistryOf(Tool{
+			Def:             llm.ToolDef{Name: "delete_resource"},
+			RequiresConfirm: true,
+		}),
+		In:          strings.NewReader("delete the zone\nexit\n"),
+		Out:         io.Discard,
+		ErrOut:      io.Discard,
+		Interactive: true,
+		AfterTurn:   func(transcript []llm.Message) { saved = transcript },
+	})
+	agent.SetHistory([]llm.Message{
+		{Role: llm.RoleUser, Content: "list zones"},
+		{Role: llm.RoleAssistant, Content: "one zone: example"},
+	})
+
+	// An empty initial query reads the first question from In.
+	if err := agent.Run(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(saved) != 6 {
+		t.Fatalf("saved %d messages, want 6: %+v", len(saved), saved)
+	}
+	if saved[0].Content != "list zones" || saved[2].Content != "delete the zone" {
+		t.Errorf("transcript should keep the resumed history, then the new question: %+v", saved[:3])
+	}
+	if len(saved[3].ToolCalls) != 1 || saved[4].ToolResult == nil || saved[5].Content != "done" {
+		t.Errorf("transcript should record the tool call, its result, and the answer: %+v", saved[3:])
+	}
+}
@@ -6,13 +6,16 @@ import (
 	"path/filepath"
 
 	syaml "sigs.k8s.io/yaml"
+
+	"go.datum.net/datumctl/internal/ai/llm"
 )
 
 // Config holds user-level AI preferences loaded from the config file.
 // All fields are optional; zero values fall back to provider/env defaults.
 // Flag values always take precedence over config file values.
 type Config struct {
-	// LLM provider: "anthropic", "openai", or "gemini". Auto-detected from API keys if empty.
+	// LLM provider: "anthropic", "openai", "gemini", or "openai-compatible".
+	// Auto-detected from API keys if empty, or "openai-compatible" when BaseURL is set.
 	Provider string `json:"provider,omitempty" yaml:"provider"`
 
 	// Model overrides the provider default (e.g. "claude-sonnet-4-6").
@@ -21,6 +24,15 @@ type Config struct {
 	// MaxIterations caps the agentic loop. Defaults to 20.
 	MaxIterations int `json:"max_iterations,omitempty" yaml:"max_iterations"`
 
+	// ContextTokens overrides the model's context window, in tokens. Older
+	// turns are summarized as the conversation approaches it. Defaults to the
+	// provider's window for the model, or 32768 for OpenAI-compatible servers.
+	ContextTokens int `json:"context_tokens,omitempty" yaml:"context_tokens"`
+
+	// MaxTokensPerSession stops a session once its model calls have used
+	// this many tokens, cached ones included. Zero means no limit.
+	MaxTokensPerSession int `json:"max_tokens_per_session,omitempty" yaml:"max_tokens_per_session"`
+
 	// Stream is reserved for v2. Always false in v1.
 	Stream bool `json:"stream,omitempty" yaml:"stream"`
 
@@ -35,6 +47,21 @@ type Config struct {
 	AnthropicAPIKey string `json:"anthropic_api_key,omitempty" yaml:"anthropic_api_key"`
 	OpenAIAPIKey    string `json:"openai_api_key,omitempty" yaml:"openai_api_key"`
 	GeminiAPIKey    string `json:"gemini_api_key,omitempty" yaml:"gemini_api_key"`
+
+	// OpenAI-compatible endpoint (Ollama, vLLM, LiteLLM, Azure OpenAI). BaseURL
+	// is the API root, e.g. http://localhost:11434/v1. Deployment and
+	// APIVersion address an Azure OpenAI deployment. Headers are sent with
+	// every request. The API key is optional and overridden by the
+	// OPENAI_COMPATIBLE_API_KEY env var.
+	BaseURL                string            `json:"base_url,omitempty" yaml:"base_url"`
+	Deployment             string            `json:"deployment,omitempty" yaml:"deployment"`
+	APIVersion             string            `json:"api_version,omitempty" yaml:"api_version"`
+	Headers                map[string]string `json:"headers,omitempty" yaml:"headers"`
+	OpenAICompatibleAPIKey string            `json:"openai_compatible_api_key,omitempty" yaml:"openai_compatible_api_key"`
+
+	// Policy decides confirmations before the user is asked. Edited in the
+	// file directly; see Policy.
+	Policy *Policy `json:"policy,omitempty" yaml:"policy"`
 }
 
 // ConfigFilePath returns the platform-appropriate path to the AI config file.
@@ -66,6 +93,9 @@ func LoadConfig() (Config, error) {
 	if err := syaml.Unmarshal(data, &cfg); err != nil {
 		return Config{}, fmt.Errorf("parse AI config %s: %w", path, err)
 	}
+	if err := cfg.Policy.Validate(); err != nil {
+		return Config{}, fmt.Errorf("parse AI config %s: %w", path, err)
+	}
 	return cfg, nil
 }
 
@@ -100,4 +130,23 @@ func (c *Config) ApplyEnvOverrides() {
 	if v := os.Getenv("GEMINI_API_KEY"); v != "" {
 		c.GeminiAPIKey = v
 	}
+	if v := os.Getenv("OPENAI_COMPATIBLE_API_KEY"); v != "" {
+		c.OpenAICompatibleAPIKey = v
+	}
+}
+
+// LLMConfig returns the provider settings for llm.NewClient.
+func (c Config) LLMConfig() llm.Config {
+	return llm.Config{
+		Provider:         c.Provider,
+		Model:            c.Model,
+		AnthropicAPIKey:  c.AnthropicAPIKey,
+		OpenAIAPIKey:     c.OpenAIAPIKey,
+		GeminiAPIKey:     c.GeminiAPIKey,
+		BaseURL:          c.BaseURL,
+		Deployment:       c.Deployment,
+		APIVersion:       c.APIVersion,
+		Headers:          c.Headers,
+		CompatibleAPIKey: c.OpenAICompatibleAPIKey,
+	}
 }
@@ -9,22 +9,29 @@ import (
 	"strings"
 
 	"go.datum.net/datumctl/internal/ai/llm"
+	"go.datum.net/datumctl/internal/console/styles"
 )
 
 // ConfirmGate decides whether a destructive tool call should proceed.
 // The implementation is responsible for I/O (stdin prompt or TUI dialog).
 type ConfirmGate interface {
 	// Confirm displays the proposed action and returns true if approved.
-	Confirm(call llm.ToolCall) bool
+	// preview is the tool's description of the change (usually a dry-run
+	// diff), or empty when the tool has none; the arguments are shown then.
+	Confirm(call llm.ToolCall, preview string) bool
 }
 
 // PrintPreview writes a human-readable description of a proposed mutating
 // action to w. Called before prompting for confirmation.
-func PrintPreview(w io.Writer, call llm.ToolCall) {
+func PrintPreview(w io.Writer, call llm.ToolCall, preview string) {
 	fmt.Fprintf(w, "\n--- Proposed action ---\n")
 	fmt.Fprintf(w, "Tool:    %s\n", call.ToolName)
-	b, _ := json.MarshalIndent(call.Arguments, "", "  ")
-	fmt.Fprintf(w, "Details:\n%s\n", string(b))
+	if preview != "" {
+		fmt.Fprintf(w, "%s\n", strings.TrimRight(preview, "\n"))
+	} else {
+		b, _ := json.MarshalIndent(call.Arguments, "", "  ")
+		fmt.Fprintf(w, "Details:\n%s\n", string(b))
+	}
 	fmt.Fprintf(w, "-----------------------\n")
 }
 
@@ -33,10 +40,15 @@ func PrintPreview(w io.Writer, call llm.ToolCall) {
 type StdinGate struct {
 	In  io.Reader
 	Out io.Writer
+	// Color renders the preview diff in color; set it when Out is a terminal.
+	Color bool
 }
 
-func (g StdinGate) Confirm(call llm.ToolCall) bool {
-	PrintPreview(g.Out, call)
+func (g StdinGate) Confirm(call llm.ToolCall, preview string) bool {
+	if g.Color && preview != "" {
+		preview = styles.ColorizeDiff(preview)
+	}
+	PrintPreview(g.Out, call, preview)
 	fmt.Fprint(g.Out, "Apply changes? [y/N]: ")
 	sc := bufio.NewScanner(g.In)
 	if !sc.Scan() {
@@ -55,16 +67,18 @@ type TUIGate struct {
 	Ctx context.Context
 }
 
-// ConfirmRequest carries a tool call and a channel to send the decision back on.
+// ConfirmRequest carries a tool call, its preview, and a channel to send the
+// decision back on.
 type ConfirmRequest struct {
 	Call    llm.ToolCall
+	Preview string
 	ReplyCh chan bool
 }
 
-func (g TUIGate) Confirm(call llm.ToolCall) bool {
+func (g TUIGate) Confirm(call llm.ToolCall, preview string) bool {
 	replyCh := make(chan bool, 1)
 	select {
-	case g.RequestCh <- ConfirmRequest{Call: call, ReplyCh: replyCh}:
+	case g.RequestCh <- ConfirmRequest{Call: call, Preview: preview, ReplyCh: replyCh}:
 		select {
 		case reply := <-replyCh:
 			return reply
@@ -81,7 +95,7 @@ type AutoDeclineGate struct {
 	ErrOut io.Writer
 }
 
-func (g AutoDeclineGate) Confirm(call llm.ToolCall) bool {
+func (g AutoDeclineGate) Confirm(call llm.ToolCall, preview string) bool {
 	fmt.Fprintf(g.ErrOut,
 		"[ai] mutation skipped: %s requires interactive mode (not a terminal)\n", call.ToolName)
 	return false
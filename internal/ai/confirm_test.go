@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+func TestPrintPreview(t *testing.T) {
+	call := deleteCall("DNSZone")
+
+	var withDiff bytes.Buffer
+	PrintPreview(&withDiff, call, "--- live\n+++ (deleted)\n-x\n")
+	if !strings.Contains(withDiff.String(), "+++ (deleted)\n-x\n") || strings.Contains(withDiff.String(), "Details:") {
+		t.Errorf("preview not shown in place of the arguments:\n%s", withDiff.String())
+	}
+
+	var raw bytes.Buffer
+	PrintPreview(&raw, call, "")
+	if !strings.Contains(raw.String(), "Details:") || !strings.Contains(raw.String(), `"kind": "DNSZone"`) {
+		t.Errorf("arguments not shown without a preview:\n%s", raw.String())
+	}
+}
+
+// recordingGate declines every call and records the preview it was shown.
+type recordingGate struct{ previews []string }
+
+func (g *recordingGate) Confirm(call llm.ToolCall, preview string) bool {
+	g.previews = append(g.previews, preview)
+	return false
+}
+
+func TestAutoDeclineSkipsPreview(t *testing.T) {
+	var errOut bytes.Buffer
+	agent := NewAgent(AgentOptions{
+		LLM: &scriptedLLM{call: deleteCall("DNSZone")},
+		Registry: NewRegistryOf(Tool{
+			Def:             llm.ToolDef{Name: "delete_resource"},
+			RequiresConfirm: true,
+			Preview: func(ctx context.Context, args map[string]any) (string, error) {
+				t.Error("previewed a call that is declined without asking")
+				return "", nil
+			},
+			Execute: func(ctx context.Context, args map[string]any) (string, error) {
+				t.Error("declined tool ran")
+				return "", nil
+			},
+		}),
+		Gate:   AutoDeclineGate{ErrOut: &errOut},
+		Out:    io.Discard,
+		ErrOut: &errOut,
+	})
+	if err := agent.Run(context.Background(), "go"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(errOut.String(), "mutation skipped: delete_resource") {
+		t.Errorf("stderr lacks the skipped mutation:\n%s", errOut.String())
+	}
+}
+
+func TestAgentShowsToolPreview(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		preview func(ctx context.Context, args map[string]any) (string, error)
+		want    string
+		wantLog string
+	}{
+		{"diff", func(ctx context.Context, args map[string]any) (string, error) { return "-x\n", nil }, "-x\n", ""},
+		{"failed", func(ctx context.Context, args map[string]any) (string, error) { return "", errors.New("forbidden") }, "", "could not preview delete_resource: forbidden"},
+		{"none", nil, "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gate := &recordingGate{}
+			var errOut bytes.Buffer
+			agent := NewAgent(AgentOptions{
+				LLM: &scriptedLLM{call: deleteCall("DNSZone")},
+				Registry: NewRegistryOf(Tool{
+					Def:             llm.ToolDef{Name: "delete_resource"},
+					RequiresConfirm: true,
+					Preview:         tc.preview,
+					Execute: func(ctx context.Context, args map[string]any) (string, error) {
+						t.Error("declined tool ran")
+						return "", nil
+					},
+				}),
+				Gate:   gate,
+				Out:    io.Discard,
+				ErrOut: &errOut,
+			})
+			if err := agent.Run(context.Background(), "go"); err != nil {
+				t.Fatal(err)
+			}
+			if len(gate.previews) != 1 || gate.previews[0] != tc.want {
+				t.Errorf("gate saw %q, want [%q]", gate.previews, tc.want)
+			}
+			if !strings.Contains(errOut.String(), tc.wantLog) {
+				t.Errorf("stderr lacks %q:\n%s", tc.wantLog, errOut.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+const (
+	// charsPerToken approximates how much text one token covers. Tokenizers
+	// differ by provider, but all average close to this for English and JSON,
+	// and the budget leaves headroom for the difference.
+	charsPerToken = 4
+
+	// messageOverheadTokens covers each message's framing: role, IDs, and
+	// tool-call structure.
+	messageOverheadTokens = 8
+
+	// minHistoryBudget keeps a usable budget for models with tiny windows
+	// or very long system prompts.
+	minHistoryBudget = 4_000
+
+	// summaryToolResultChars caps each tool result in the transcript handed
+	// to the summarizer.
+	summaryToolResultChars = 1_000
+
+	// minTrimmedToolResultChars is the least a trimmed tool result keeps.
+	minTrimmedToolResultChars = 500
+)
+
+// summaryPrefix starts the user message that stands in for summarized turns.
+const summaryPrefix = "Summary of the conversation so far (earlier messages were condensed to fit the context window):\n\n"
+
+const summarizePrompt = `You condense conversations between a user and Patch, the Datum Cloud assistant, so the conversation can continue within a limited context window.
+
+Write a concise summary that keeps:
+- the user's original request and any later goals, quoted verbatim when short;
+- the organization, project, and namespace the conversation is working in;
+- every resource inspected, created, changed, or deleted, by kind, name, and namespace;
+- changes the user confirmed or declined, and decisions made along the way;
+- open questions and remaining steps.
+
+Leave out raw tool output except values that are still needed. Reply with the summary only.`
+
+// estimateTokens approximates the tokens a message costs.
+func estimateTokens(m llm.Message) int {
+	n := len(m.Content)
+	for _, tc := range m.ToolCalls {
+		b, _ := json.Marshal(tc.Arguments)
+		n += len(tc.ToolName) + len(b)
+	}
+	if m.ToolResult != nil {
+		n += len(m.ToolResult.Content)
+	}
+	return n/charsPerToken + messageOverheadTokens
+}
+
+func estimateHistory(msgs []llm.Message) int {
+	total := 0
+	for _, m := range msgs {
+		total += estimateTokens(m)
+	}
+	return total
+}
+
+// historyBudget returns how many tokens the history may use in one call: a
+// share of the model's context window, less the system prompt and tool
+// definitions, leaving the rest for the reply.
+func (a *Agent) historyBudget(toolDefs []llm.ToolDef) int {
+	window := a.opts.ContextTokens
+	if window <= 0 {
+		window = llm.ContextWindow(a.opts.LLM.Provider(), a.opts.LLM.Model())
+	}
+	b, _ := json.Marshal(toolDefs)
+	budget := window*3/4 - (len(a.opts.SystemPrompt)+len(b))/charsPerToken
+	return max(budget, minHistoryBudget)
+}
+
+// fitHistory returns the messages to send on the next call, compacting the
+// history first if it has outgrown the budget: older turns are summarized by
+// the model (or, if that fails, dropped), and oversized tool results in what
+// remains are trimmed. History is only ever cut at the start of a user turn,
+// so a tool call is never separated from its result.
+func (a *Agent) fitHistory(ctx context.Context, toolDefs []llm.ToolDef) []llm.Message {
+	budget := a.historyBudget(toolDefs)
+	if estimateHistory(a.history) <= budget {
+		return a.history
+	}
+
+	if cut := compactionCut(a.history, budget/2); cut > 0 {
+		older, recent := a.history[:cut], a.history[cut:]
+		summary, err := a.summarize(ctx, older, budget)
+		if err != nil {
+			fmt.Fprintf(a.opts.ErrOut,
+				"[ai] warning: could not summarize %d earlier messages (%v); dropping them to fit the context window\n", len(older), err)
+			a.history = append([]llm.Message(nil), recent...)
+		} else {
+			fmt.Fprintf(a.opts.ErrOut, "[ai] summarized %d earlier messages to fit the context window\n", len(older))
+			a.history = append([]llm.Message{
+				{Role: llm.RoleUser, Content: summaryPrefix + summary},
+				{Role: llm.RoleAssistant, Content: "Understood. I'll continue from there."},
+			}, recent...)
+		}
+	}
+	return trimToolResults(a.history, budget)
+}
+
+// compactionCut returns where to split msgs so the recent part fits in keep
+// tokens: the earliest user turn from which the rest fits, or failing that
+// the start of the last turn. It returns 0 when there is nothing before the
+// current turn to compact.
+func compactionCut(msgs []llm.Message, keep int) int {
+	last := 0
+	for i := len(msgs) - 1; i > 0; i-- {
+		if msgs[i].Role == llm.RoleUser {
+			last = i
+			break
+		}
+	}
+	if last == 0 {
+		return 0
+	}
+	for i := 1; i < last; i++ {
+		if msgs[i].Role == llm.RoleUser && estimateHistory(msgs[i:]) <= keep {
+			return i
+		}
+	}
+	return last
+}
+
+// summarize asks the model to condense msgs. The transcript it is given is
+// capped to the budget so the request itself fits.
+func (a *Agent) summarize(ctx context.Context, msgs []llm.Message, budget int) (string, error) {
+	transcript := renderTranscript(msgs)
+	if limit := budget * charsPerToken; len(transcript) > limit {
+		// Keep the start, where the original request is, and the most recent part.
+		head := limit / 4
+		transcript = transcript[:head] + "\n[... earlier turns omitted ...]\n" + transcript[len(transcript)-(limit-head):]
+	}
+	resp, err := a.opts.LLM.Chat(ctx, summarizePrompt, []llm.Message{{Role: llm.RoleUser, Content: transcript}}, nil)
+	if err != nil {
+		return "", err
+	}
+	a.addUsage(resp.Usage)
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return "", fmt.Errorf("the model returned an empty summary")
+	}
+	return summary, nil
+}
+
+// renderTranscript writes msgs as plain text for the summarizer.
+func renderTranscript(msgs []llm.Message) string {
+	var b strings.Builder
+	for _, m := range msgs {
+		switch m.Role {
+		case llm.RoleUser:
+			fmt.Fprintf(&b, "User: %s\n\n", m.Content)
+		case llm.RoleAssistant:
+			if m.Content != "" {
+				fmt.Fprintf(&b, "Assistant: %s\n\n", m.Content)
+			}
+			for _, tc := range m.ToolCalls {
+				args, _ := json.Marshal(tc.Arguments)
+				fmt.Fprintf(&b, "Assistant called %s %s\n\n", tc.ToolName, args)
+			}
+		case llm.RoleToolResult:
+			if m.ToolResult == nil {
+				continue
+			}
+			label := "Tool result"
+			if m.ToolResult.IsError {
+				label = "Tool error"
+			}
+			fmt.Fprintf(&b, "%s: %s\n\n", label, truncateText(m.ToolResult.Content, summaryToolResultChars))
+		}
+	}
+	return b.String()
+}
+
+// trimToolResults returns msgs with the largest tool results cut down, one
+// at a time, until the total fits budget or nothing is left to trim. msgs is
+// not modified.
+func trimToolResults(msgs []llm.Message, budget int) []llm.Message {
+	total := estimateHistory(msgs)
+	if total <= budget {
+		return msgs
+	}
+	out := append([]llm.Message(nil), msgs...)
+	for total > budget {
+		largest := -1
+		for i, m := range out {
+			if m.ToolResult == nil || len(m.ToolResult.Content) <= minTrimmedToolResultChars*2 {
+				continue
+			}
+			if largest < 0 || len(m.ToolResult.Content) > len(out[largest].ToolResult.Content) {
+				largest = i
+			}
+		}
+		if largest < 0 {
+			break
+		}
+		result := *out[largest].ToolResult
+		before := estimateTokens(out[largest])
+		// Cut at least half, so repeated trims (which add a note) always shrink.
+		keep := max(min(len(result.Content)-(total-budget)*charsPerToken, len(result.Content)/2), minTrimmedToolResultChars)
+		result.Content = truncateText(result.Content, keep)
+		out[largest].ToolResult = &result
+		total += estimateTokens(out[largest]) - before
+	}
+	return out
+}
+
+// truncateText cuts s to about limit characters, saying how much was cut.
+func truncateText(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return fmt.Sprintf("%s\n[... %d more characters trimmed to fit the context window; narrow the query to see them]", s[:limit], len(s)-limit)
+}
+
+// Tool results are shaped at the source, too, so one call cannot fill the
+// window: lists are paged and objects lose fields the model never needs.
+const (
+	// defaultListLimit is list_resources' page size when the model sets none.
+	defaultListLimit = 50
+
+	// maxListStatusChars is how large an item's status may render in a list
+	// before only its conditions are kept.
+	maxListStatusChars = 1_000
+
+	// maxConditionMessageChars caps each condition message in a trimmed status.
+	maxConditionMessageChars = 300
+)
+
+// trimForModel removes managedFields and the last-applied annotation from a
+// resource object. With trimStatus, a status too long for a list is cut
+// down to its conditions; get_resource shows the full status.
+func trimForModel(obj map[string]any, trimStatus bool) {
+	if meta, ok := obj["metadata"].(map[string]any); ok {
+		delete(meta, "managedFields")
+		if annotations, ok := meta["annotations"].(map[string]any); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			if len(annotations) == 0 {
+				delete(meta, "annotations")
+			}
+		}
+	}
+	status, ok := obj["status"].(map[string]any)
+	if !trimStatus || !ok {
+		return
+	}
+	if b, _ := json.Marshal(status); len(b) <= maxListStatusChars {
+		return
+	}
+	trimmed := map[string]any{"note": "status trimmed to conditions; use get_resource for the full status"}
+	if conditions, ok := status["conditions"].([]any); ok {
+		for _, c := range conditions {
+			if cond, ok := c.(map[string]any); ok {
+				if msg, ok := cond["message"].(string); ok {
+					cond["message"] = truncateText(msg, maxConditionMessageChars)
+				}
+			}
+		}
+		trimmed["conditions"] = conditions
+	}
+	obj["status"] = trimmed
+}
+
+// paginationHint tells the model how to fetch the rest of a partial list.
+func paginationHint(lst *unstructured.UnstructuredList) string {
+	token := lst.GetContinue()
+	if token == "" {
+		return ""
+	}
+	more := "More results are available"
+	if remaining := lst.GetRemainingItemCount(); remaining != nil && *remaining > 0 {
+		more = fmt.Sprintf("%d more results are available", *remaining)
+	}
+	return fmt.Sprintf("\n# %s: call list_resources again with continue: %q, or narrow the query with labelSelector.\n", more, token)
+}
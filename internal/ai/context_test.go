@@ -0,0 +1,175 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+// turn returns one user turn in which the assistant calls a tool whose
+// result is resultChars long, then answers.
+func turn(n int, resultChars int) []llm.Message {
+	id := string(rune('a' + n))
+	return []llm.Message{
+		{Role: llm.RoleUser, Content: "question " + id},
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{ID: id, ToolName: "list_resources"}}},
+		{Role: llm.RoleToolResult, ToolResult: &llm.ToolResult{CallID: id, Content: strings.Repeat("x", resultChars)}},
+		{Role: llm.RoleAssistant, Content: "answer " + id},
+	}
+}
+
+func history(turns ...[]llm.Message) []llm.Message {
+	var out []llm.Message
+	for _, t := range turns {
+		out = append(out, t...)
+	}
+	return out
+}
+
+// compactingLLM answers summary requests (Chat) with a fixed summary, or
+// fails them, and records what each StreamChat call was sent.
+type compactingLLM struct {
+	summaryErr error
+	sent       [][]llm.Message
+}
+
+func (c *compactingLLM) Chat(ctx context.Context, systemPrompt string, messages []llm.Message, tools []llm.ToolDef) (llm.Message, error) {
+	if c.summaryErr != nil {
+		return llm.Message{}, c.summaryErr
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: "the user wants a DNS zone"}, nil
+}
+
+func (c *compactingLLM) StreamChat(ctx context.Context, systemPrompt string, messages []llm.Message, tools []llm.ToolDef, textOut io.Writer) (llm.Message, error) {
+	c.sent = append(c.sent, messages)
+	return llm.Message{Role: llm.RoleAssistant, Content: "ok"}, nil
+}
+
+func (c *compactingLLM) Provider() string { return "openai-compatible" }
+func (c *compactingLLM) Model() string    { return "test" }
+
+func TestCompactionCutKeepsToolPairs(t *testing.T) {
+	msgs := history(turn(0, 8000), turn(1, 8000), turn(2, 400))
+	cut := compactionCut(msgs, estimateHistory(turn(2, 400)))
+	if cut != 8 {
+		t.Fatalf("cut = %d, want 8 (the start of the last turn)", cut)
+	}
+	if cut = compactionCut(msgs, estimateHistory(msgs[4:])); cut != 4 {
+		t.Errorf("cut = %d, want 4 (the earliest turn that fits)", cut)
+	}
+	if cut = compactionCut(turn(0, 100000), 10); cut != 0 {
+		t.Errorf("cut = %d, want 0 for a single turn", cut)
+	}
+}
+
+func TestFitHistorySummarizesOlderTurns(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		summaryErr error
+		wantFirst  string
+		wantLog    string
+	}{
+		{"summarized", nil, summaryPrefix + "the user wants a DNS zone", "summarized 8 earlier messages"},
+		{"summary failed", errors.New("rate limited"), "question c", "dropping them"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &compactingLLM{summaryErr: tc.summaryErr}
+			var errOut bytes.Buffer
+			agent := NewAgent(AgentOptions{
+				LLM:           fake,
+				Registry:      NewEmptyRegistry(),
+				ContextTokens: 8000, // a 6000-token history budget
+				Out:           io.Discard,
+				ErrOut:        &errOut,
+			})
+			agent.SetHistory(history(turn(0, 12000), turn(1, 12000)))
+			if err := agent.Run(context.Background(), "question c"); err != nil {
+				t.Fatal(err)
+			}
+
+			sent := fake.sent[0]
+			if sent[0].Content != tc.wantFirst {
+				t.Errorf("first message = %q, want %q", sent[0].Content, tc.wantFirst)
+			}
+			if sent[len(sent)-1].Content != "question c" {
+				t.Errorf("the current question was not kept: %+v", sent[len(sent)-1])
+			}
+			if estimateHistory(sent) > 6000 {
+				t.Errorf("sent %d tokens, over the budget", estimateHistory(sent))
+			}
+			if !strings.Contains(errOut.String(), tc.wantLog) {
+				t.Errorf("stderr lacks %q:\n%s", tc.wantLog, errOut.String())
+			}
+		})
+	}
+}
+
+func TestTrimToolResults(t *testing.T) {
+	msgs := history(turn(0, 40000), turn(1, 4000))
+	trimmed := trimToolResults(msgs, 2000)
+	if estimateHistory(trimmed) > 2000 {
+		t.Errorf("trimmed history is %d tokens, want at most 2000", estimateHistory(trimmed))
+	}
+	if !strings.Contains(trimmed[2].ToolResult.Content, "characters trimmed to fit the context window") {
+		t.Error("trimmed result does not say it was trimmed")
+	}
+	if len(msgs[2].ToolResult.Content) != 40000 {
+		t.Error("trimToolResults modified the history it was given")
+	}
+
+	// Results too small to trim are sent as they are.
+	small := history(turn(0, 900), turn(1, 900))
+	if got := trimToolResults(small, 10); len(got[2].ToolResult.Content) != 900 {
+		t.Errorf("trimmed a small result to %d characters", len(got[2].ToolResult.Content))
+	}
+}
+
+func TestTrimForModel(t *testing.T) {
+	obj := map[string]any{
+		"metadata": map[string]any{
+			"name":          "zone",
+			"managedFields": []any{map[string]any{"manager": "datumctl-ai"}},
+			"annotations":   map[string]any{"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+		},
+		"status": map[string]any{
+			"records":    strings.Repeat("r", 2000),
+			"conditions": []any{map[string]any{"type": "Ready", "message": strings.Repeat("m", 1000)}},
+		},
+	}
+	trimForModel(obj, true)
+	meta := obj["metadata"].(map[string]any)
+	if _, ok := meta["managedFields"]; ok {
+		t.Error("managedFields kept")
+	}
+	if _, ok := meta["annotations"]; ok {
+		t.Error("empty annotations kept")
+	}
+	status := obj["status"].(map[string]any)
+	if _, ok := status["records"]; ok {
+		t.Error("long status kept in a list")
+	}
+	cond := status["conditions"].([]any)[0].(map[string]any)
+	if len(cond["message"].(string)) > maxConditionMessageChars+200 {
+		t.Errorf("condition message not truncated: %d characters", len(cond["message"].(string)))
+	}
+}
+
+func TestPaginationHint(t *testing.T) {
+	lst := &unstructured.UnstructuredList{}
+	if hint := paginationHint(lst); hint != "" {
+		t.Errorf("complete list hint = %q", hint)
+	}
+	lst.SetContinue("tok")
+	remaining := int64(12)
+	lst.SetRemainingItemCount(&remaining)
+	if hint := paginationHint(lst); !strings.Contains(hint, `continue: "tok"`) || !strings.Contains(hint, "12 more") {
+		t.Errorf("hint = %q", hint)
+	}
+}
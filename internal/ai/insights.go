@@ -0,0 +1,355 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+	"go.datum.net/datumctl/internal/client"
+	"go.datum.net/datumctl/internal/console/data"
+)
+
+// addInsightTools registers the read-only troubleshooting tools: audit
+// activity, change history, events, and quota usage. They reuse the console's
+// data clients, created per call so a change_context never serves results
+// cached for the previous project.
+func addInsightTools(r *Registry, factory *client.DatumCloudFactory) {
+	resourceProps := func() map[string]any {
+		return map[string]any{
+			"kind": map[string]any{
+				"type":        "string",
+				"description": "Resource kind, e.g. DNSZone",
+			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Resource name",
+			},
+			"apiVersion": map[string]any{
+				"type":        "string",
+				"description": "API version (optional)",
+			},
+			"namespace": map[string]any{
+				"type":        "string",
+				"description": "Namespace (optional; defaults to the session namespace for namespaced kinds)",
+			},
+		}
+	}
+
+	activityProps := resourceProps()
+	activityProps["sinceHours"] = map[string]any{
+		"type":        "integer",
+		"description": "Without kind and name: how far back to look, in hours (default 24)",
+	}
+	activityProps["limit"] = map[string]any{
+		"type":        "integer",
+		"description": "Without kind and name: maximum number of entries (default 50)",
+	}
+	r.add(Tool{
+		Def: llm.ToolDef{
+			Name: "query_activity",
+			Description: "Query the audit activity timeline: who changed what and when. With kind and name, returns the last 30 days of activity " +
+				"for that resource; without them, returns recent changes made by people across the current project.",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": activityProps,
+			},
+		},
+		Execute: func(ctx context.Context, args map[string]any) (string, error) {
+			kind := stringArg(args, "kind")
+			name := stringArg(args, "name")
+			projectWide, err := activityScope(kind, name)
+			if err != nil {
+				return "", err
+			}
+			ac := data.NewActivityClient(factory)
+
+			var rows []data.ActivityRow
+			if projectWide {
+				since := time.Duration(intArg(args, "sinceHours", 24)) * time.Hour
+				rows, err = ac.ListRecentProjectActivity(ctx, since, int(intArg(args, "limit", 50)))
+			} else {
+				rt, namespace, rerr := resolveResource(factory, kind, stringArg(args, "apiVersion"), stringArg(args, "namespace"))
+				if rerr != nil {
+					return "", rerr
+				}
+				rows, _, err = ac.ListActivity(ctx, rt.Group, rt.Kind, name, namespace, "")
+			}
+			if err != nil {
+				return "", activityError(err)
+			}
+			b, _ := json.Marshal(map[string]any{"items": activityItems(rows)})
+			return string(b), nil
+		},
+	})
+
+	historyProps := resourceProps()
+	historyProps["revision"] = map[string]any{
+		"type":        "integer",
+		"description": "Revision number from a previous call; returns the unified diff between it and the revision before",
+	}
+	r.add(Tool{
+		Def: llm.ToolDef{
+			Name: "get_resource_history",
+			Description: "Get the change history of a resource from the audit log (last 30 days): one revision per write, with who made it and " +
+				"which fields changed. Pass revision to see the full diff of one change.",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": historyProps,
+				"required":   []string{"kind", "name"},
+			},
+		},
+		Execute: func(ctx context.Context, args map[string]any) (string, error) {
+			name := stringArg(args, "name")
+			rt, namespace, err := resolveResource(factory, stringArg(args, "kind"), stringArg(args, "apiVersion"), stringArg(args, "namespace"))
+			if err != nil {
+				return "", err
+			}
+			hc := data.NewHistoryClient(factory)
+			rows, manifests, truncated, err := hc.LoadHistory(ctx, rt, name, namespace)
+			if err != nil {
+				return "", err
+			}
+
+			if rev := intArg(args, "revision", 0); rev != 0 {
+				if err := checkRevision(rev, len(manifests), rt.Kind, name); err != nil {
+					return "", err
+				}
+				body, isCreation, predMissing, err := hc.ComputeDiff(manifests, int(rev)-1)
+				if err != nil {
+					return "", err
+				}
+				b, _ := json.Marshal(map[string]any{
+					"revision":            rev,
+					"creation":            isCreation,
+					"previousUnavailable": predMissing,
+					"diff":                body,
+				})
+				return string(b), nil
+			}
+
+			b, _ := json.Marshal(map[string]any{"revisions": revisionItems(rows), "truncated": truncated})
+			return string(b), nil
+		},
+	})
+
+	r.add(Tool{
+		Def: llm.ToolDef{
+			Name: "list_events",
+			Description: "List the events recorded for a resource, newest first — warnings here usually explain why a resource is not ready " +
+				"or not progressing.",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": resourceProps(),
+				"required":   []string{"kind", "name"},
+			},
+		},
+		Execute: func(ctx context.Context, args map[string]any) (string, error) {
+			name := stringArg(args, "name")
+			rt, namespace, err := resolveResource(factory, stringArg(args, "kind"), stringArg(args, "apiVersion"), stringArg(args, "namespace"))
+			if err != nil {
+				return "", err
+			}
+			events, err := data.NewKubeResourceClient(factory).ListEvents(ctx, rt.Kind, name, namespace)
+			if err != nil {
+				return "", err
+			}
+
+			b, _ := json.Marshal(map[string]any{"items": eventItems(events)})
+			return string(b), nil
+		},
+	})
+
+	r.add(Tool{
+		Def: llm.ToolDef{
+			Name: "get_quota_usage",
+			Description: "Get quota usage in the current context: for each allowance bucket, the resource type it limits, the limit, " +
+				"how much is allocated, and how much is still available.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"resourceType": map[string]any{
+						"type":        "string",
+						"description": "Only show buckets whose resource type contains this text (case-insensitive, optional)",
+					},
+				},
+			},
+		},
+		Execute: func(ctx context.Context, args map[string]any) (string, error) {
+			buckets, err := data.NewKubeResourceClient(factory).ListAllowanceBuckets(ctx)
+			if err != nil {
+				return "", err
+			}
+			b, _ := json.Marshal(map[string]any{"items": quotaItems(buckets, stringArg(args, "resourceType"))})
+			return string(b), nil
+		},
+	})
+}
+
+// activityScope checks query_activity's target: a resource, given by kind
+// and name, or the whole project, given by neither.
+func activityScope(kind, name string) (projectWide bool, err error) {
+	switch {
+	case kind == "" && name == "":
+		return true, nil
+	case kind == "" || name == "":
+		return false, fmt.Errorf("pass both kind and name, or neither for project-wide activity")
+	}
+	return false, nil
+}
+
+// activityError explains that activity is unavailable when the control plane
+// lacks the activity API, rather than reporting a bare lookup failure.
+func activityError(err error) error {
+	if errors.Is(err, data.ErrActivityCRDAbsent) || errors.Is(err, data.ErrActivityCRDPartial) {
+		return fmt.Errorf("activity is not available in this context: %w", err)
+	}
+	return err
+}
+
+type activityItem struct {
+	Time     time.Time `json:"time"`
+	Origin   string    `json:"origin"`
+	Actor    string    `json:"actor,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	Summary  string    `json:"summary"`
+	Resource string    `json:"resource,omitempty"`
+}
+
+func activityItems(rows []data.ActivityRow) []activityItem {
+	items := make([]activityItem, 0, len(rows))
+	for _, row := range rows {
+		it := activityItem{Time: row.Timestamp, Origin: row.Origin, Actor: row.ActorDisplay, Source: row.ChangeSource, Summary: row.Summary}
+		if ref := row.ResourceRef; ref != nil {
+			it.Resource = ref.Kind + " " + qualifiedName(ref.Namespace, ref.Name)
+		}
+		items = append(items, it)
+	}
+	return items
+}
+
+// checkRevision checks a get_resource_history revision against the n
+// revisions the resource has, numbered from 1.
+func checkRevision(rev int64, n int, kind, name string) error {
+	if rev < 1 || rev > int64(n) {
+		return fmt.Errorf("revision %d out of range: %s %s has %d revisions", rev, kind, name, n)
+	}
+	return nil
+}
+
+type revisionItem struct {
+	Revision int       `json:"revision"`
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Source   string    `json:"source"`
+	Verb     string    `json:"verb"`
+	Status   int32     `json:"status,omitempty"`
+	Summary  string    `json:"summary"`
+}
+
+func revisionItems(rows []data.HistoryRow) []revisionItem {
+	items := make([]revisionItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, revisionItem{
+			Revision: row.Rev, Time: row.Timestamp, User: row.User, Source: row.Source,
+			Verb: row.Verb, Status: row.Status, Summary: row.Summary,
+		})
+	}
+	return items
+}
+
+type eventItem struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+	Count   int32     `json:"count,omitempty"`
+}
+
+// eventItems shapes events newest first, timing each by its last occurrence
+// or, for new-style events, its event time.
+func eventItems(events []data.EventRow) []eventItem {
+	items := make([]eventItem, 0, len(events))
+	for _, ev := range events {
+		ts := ev.LastTimestamp
+		if ts.IsZero() {
+			ts = ev.EventTime
+		}
+		items = append(items, eventItem{Time: ts, Type: ev.Type, Reason: ev.Reason, Message: ev.Message, Count: ev.Count})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Time.After(items[j].Time) })
+	return items
+}
+
+type quotaItem struct {
+	ResourceType string `json:"resourceType"`
+	Consumer     string `json:"consumer,omitempty"`
+	Limit        int64  `json:"limit"`
+	Allocated    int64  `json:"allocated"`
+	Available    int64  `json:"available"`
+	Claims       int    `json:"claims"`
+}
+
+// quotaItems shapes the buckets whose resource type contains filter,
+// case-insensitively, sorted by resource type.
+func quotaItems(buckets []data.AllowanceBucket, filter string) []quotaItem {
+	filter = strings.ToLower(filter)
+	items := []quotaItem{}
+	for _, bucket := range buckets {
+		if filter != "" && !strings.Contains(strings.ToLower(bucket.ResourceType), filter) {
+			continue
+		}
+		it := quotaItem{
+			ResourceType: bucket.ResourceType, Limit: bucket.Limit, Allocated: bucket.Allocated,
+			Available: bucket.Available, Claims: bucket.ClaimCount,
+		}
+		if bucket.ConsumerKind != "" {
+			it.Consumer = bucket.ConsumerKind + " " + bucket.ConsumerName
+		}
+		items = append(items, it)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ResourceType < items[j].ResourceType })
+	return items
+}
+
+// resolveResource resolves a kind to the console's ResourceType and picks the
+// namespace to query: the one given, or the session namespace for namespaced
+// kinds, or none for cluster-scoped ones.
+func resolveResource(factory *client.DatumCloudFactory, kind, apiVersion, namespace string) (data.ResourceType, string, error) {
+	gvr, namespaced, err := resolveGVR(factory, kind, apiVersion)
+	if err != nil {
+		return data.ResourceType{}, "", err
+	}
+	rt := data.ResourceType{Name: gvr.Resource, Kind: kind, Group: gvr.Group, Version: gvr.Version, Namespaced: namespaced}
+	switch {
+	case !namespaced:
+		namespace = ""
+	case namespace == "" && factory.ConfigFlags.Namespace != nil:
+		namespace = *factory.ConfigFlags.Namespace
+	}
+	return rt, namespace, nil
+}
+
+// intArg reads an integer argument, which arrives from JSON as a float64.
+func intArg(args map[string]any, key string, def int64) int64 {
+	switch n := args[key].(type) {
+	case float64:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	}
+	return def
+}
+
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
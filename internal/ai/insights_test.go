@@ -0,0 +1,148 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.datum.net/datumctl/internal/console/data"
+)
+
+func TestInsightToolsAreReadOnly(t *testing.T) {
+	r := NewRegistry(nil, "")
+	for _, name := range []string{"query_activity", "get_resource_history", "list_events", "get_quota_usage"} {
+		tool, ok := r.Find(name)
+		if !ok {
+			t.Errorf("%s is not registered", name)
+			continue
+		}
+		if tool.RequiresConfirm || tool.Preview != nil {
+			t.Errorf("%s should run without confirmation", name)
+		}
+	}
+}
+
+func TestIntArg(t *testing.T) {
+	args := map[string]any{"json": float64(48), "native": 3, "text": "12"}
+	for key, want := range map[string]int64{"json": 48, "native": 3, "text": 7, "missing": 7} {
+		if got := intArg(args, key, 7); got != want {
+			t.Errorf("intArg(%q) = %d, want %d", key, got, want)
+		}
+	}
+}
+
+func TestActivityScope(t *testing.T) {
+	tests := []struct {
+		kind, name  string
+		projectWide bool
+		wantErr     bool
+	}{
+		{"", "", true, false},
+		{"DNSZone", "example", false, false},
+		{"DNSZone", "", false, true},
+		{"", "example", false, true},
+	}
+	for _, tt := range tests {
+		projectWide, err := activityScope(tt.kind, tt.name)
+		if (err != nil) != tt.wantErr || projectWide != tt.projectWide {
+			t.Errorf("activityScope(%q, %q) = %v, %v; want %v, error %v", tt.kind, tt.name, projectWide, err, tt.projectWide, tt.wantErr)
+		}
+	}
+}
+
+func TestActivityError(t *testing.T) {
+	for _, sentinel := range []error{data.ErrActivityCRDAbsent, data.ErrActivityCRDPartial} {
+		err := activityError(fmt.Errorf("list activity: %w", sentinel))
+		if !errors.Is(err, sentinel) || !strings.Contains(err.Error(), "activity is not available in this context") {
+			t.Errorf("activityError(%v) = %v, want it explained as unavailable", sentinel, err)
+		}
+	}
+	other := errors.New("forbidden")
+	if err := activityError(other); err != other {
+		t.Errorf("activityError(%v) = %v, want it unchanged", other, err)
+	}
+}
+
+func TestActivityItems(t *testing.T) {
+	now := time.Now()
+	items := activityItems([]data.ActivityRow{
+		{Timestamp: now, Origin: "audit", ActorDisplay: "ada@example.com", ChangeSource: "human", Summary: "updated",
+			ResourceRef: &data.ResourceRef{Kind: "DNSZone", Name: "example", Namespace: "default"}},
+		{Timestamp: now, Origin: "event", Summary: "ready"},
+	})
+	if len(items) != 2 {
+		t.Fatalf("items = %+v, want two", items)
+	}
+	if items[0].Resource != "DNSZone default/example" || items[0].Actor != "ada@example.com" {
+		t.Errorf("items[0] = %+v", items[0])
+	}
+	if items[1].Resource != "" {
+		t.Errorf("items[1].Resource = %q, want none without a resource ref", items[1].Resource)
+	}
+}
+
+func TestCheckRevision(t *testing.T) {
+	for _, rev := range []int64{1, 3} {
+		if err := checkRevision(rev, 3, "DNSZone", "example"); err != nil {
+			t.Errorf("checkRevision(%d, 3) = %v, want nil", rev, err)
+		}
+	}
+	for _, rev := range []int64{-1, 4} {
+		err := checkRevision(rev, 3, "DNSZone", "example")
+		if err == nil || !strings.Contains(err.Error(), "DNSZone example has 3 revisions") {
+			t.Errorf("checkRevision(%d, 3) = %v, want an out-of-range error", rev, err)
+		}
+	}
+}
+
+func TestEventItemsNewestFirst(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := eventItems([]data.EventRow{
+		{Reason: "Oldest", LastTimestamp: base},
+		{Reason: "NewStyle", EventTime: base.Add(2 * time.Minute)},
+		{Reason: "Middle", LastTimestamp: base.Add(time.Minute)},
+	})
+	var got []string
+	for _, it := range items {
+		got = append(got, it.Reason)
+	}
+	if want := "NewStyle Middle Oldest"; strings.Join(got, " ") != want {
+		t.Errorf("order = %v, want %s", got, want)
+	}
+	if !items[0].Time.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("time = %v, want the event time when there is no last timestamp", items[0].Time)
+	}
+}
+
+func TestQuotaItems(t *testing.T) {
+	buckets := []data.AllowanceBucket{
+		{ResourceType: "networking.datumapis.com/gateways", Limit: 10, Allocated: 2, Available: 8, ClaimCount: 2},
+		{ResourceType: "dns.networking.miloapis.com/DNSZones", ConsumerKind: "Project", ConsumerName: "proj", Limit: 5},
+		{ResourceType: "compute.datumapis.com/instances", Limit: 3},
+	}
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"compute.datumapis.com/instances", "dns.networking.miloapis.com/DNSZones", "networking.datumapis.com/gateways"}},
+		{"dnszone", []string{"dns.networking.miloapis.com/DNSZones"}},
+		{"NETWORKING", []string{"dns.networking.miloapis.com/DNSZones", "networking.datumapis.com/gateways"}},
+		{"storage", nil},
+	}
+	for _, tt := range tests {
+		items := quotaItems(buckets, tt.filter)
+		var got []string
+		for _, it := range items {
+			got = append(got, it.ResourceType)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("quotaItems(%q) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+	items := quotaItems(buckets, "dnszone")
+	if len(items) != 1 || items[0].Consumer != "Project proj" {
+		t.Errorf("items = %+v, want the consumer as kind and name", items)
+	}
+}
@@ -50,7 +50,7 @@ func (c *anthropicClient) Model() string    { return c.model }
 type anthropicRequest struct {
 	Model     string             `json:"model"`
 	MaxTokens int                `json:"max_tokens"`
-	System    string             `json:"system,omitempty"`
+	System    []anthropicContent `json:"system,omitempty"`
 	Messages  []anthropicMessage `json:"messages"`
 	Tools     []anthropicTool    `json:"tools,omitempty"`
 	Stream    bool               `json:"stream,omitempty"`
@@ -70,12 +70,42 @@ type anthropicContent struct {
 	ToolUseID string          `json:"tool_use_id,omitempty"`
 	Content   string          `json:"content,omitempty"`
 	IsError   bool            `json:"is_error,omitempty"`
+
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
 }
 
 type anthropicTool struct {
 	Name        string         `json:"name"`
 	Description string         `json:"description"`
 	InputSchema map[string]any `json:"input_schema"`
+
+	CacheControl *anthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+// anthropicCacheControl marks the end of a prompt prefix to cache. The tools
+// and the system prompt are the same on every call of a session, so each
+// ends in a breakpoint: later calls read them from the cache at a tenth of
+// the input price instead of paying for them again.
+type anthropicCacheControl struct {
+	Type string `json:"type"` // "ephemeral"
+}
+
+var anthropicEphemeral = &anthropicCacheControl{Type: "ephemeral"}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+func (u anthropicUsage) usage() *Usage {
+	return &Usage{
+		InputTokens:      u.InputTokens,
+		OutputTokens:     u.OutputTokens,
+		CacheReadTokens:  u.CacheReadInputTokens,
+		CacheWriteTokens: u.CacheCreationInputTokens,
+	}
 }
 
 type anthropicResponse struct {
@@ -84,6 +114,7 @@ type anthropicResponse struct {
 	Role       string             `json:"role"`
 	Content    []anthropicContent `json:"content"`
 	StopReason string             `json:"stop_reason"`
+	Usage      *anthropicUsage    `json:"usage,omitempty"`
 	Error      *anthropicError    `json:"error,omitempty"`
 }
 
@@ -96,13 +127,34 @@ type anthropicError struct {
 // wire format, sends the request with retry logic, and converts the response
 // back to the internal Message type.
 func (c *anthropicClient) Chat(ctx context.Context, systemPrompt string, messages []Message, tools []ToolDef) (Message, error) {
-	wireMessages := toAnthropicMessages(messages)
+	req := c.newRequest(systemPrompt, messages, tools)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Message{}, fmt.Errorf("anthropic: marshal request: %w", err)
+	}
 
+	var resp anthropicResponse
+	if err := c.doWithRetry(ctx, body, &resp); err != nil {
+		return Message{}, err
+	}
+	if resp.Error != nil {
+		return Message{}, fmt.Errorf("anthropic: %s: %s", resp.Error.Type, resp.Error.Message)
+	}
+
+	return fromAnthropicResponse(resp), nil
+}
+
+// newRequest builds a Messages API request, with cache breakpoints after the
+// tools and after the system prompt.
+func (c *anthropicClient) newRequest(systemPrompt string, messages []Message, tools []ToolDef) anthropicRequest {
 	req := anthropicRequest{
 		Model:     c.model,
 		MaxTokens: anthropicMaxTokens,
-		System:    systemPrompt,
-		Messages:  wireMessages,
+		Messages:  toAnthropicMessages(messages),
+	}
+	if systemPrompt != "" {
+		req.System = []anthropicContent{{Type: "text", Text: systemPrompt, CacheControl: anthropicEphemeral}}
 	}
 	for _, t := range tools {
 		schema := t.InputSchema
@@ -115,21 +167,10 @@ func (c *anthropicClient) Chat(ctx context.Context, systemPrompt string, message
 			InputSchema: schema,
 		})
 	}
-
-	body, err := json.Marshal(req)
-	if err != nil {
-		return Message{}, fmt.Errorf("anthropic: marshal request: %w", err)
-	}
-
-	var resp anthropicResponse
-	if err := c.doWithRetry(ctx, body, &resp); err != nil {
-		return Message{}, err
+	if len(req.Tools) > 0 {
+		req.Tools[len(req.Tools)-1].CacheControl = anthropicEphemeral
 	}
-	if resp.Error != nil {
-		return Message{}, fmt.Errorf("anthropic: %s: %s", resp.Error.Type, resp.Error.Message)
-	}
-
-	return fromAnthropicResponse(resp), nil
+	return req
 }
 
 // toAnthropicMessages converts the internal history to Anthropic wire format.
@@ -197,6 +238,9 @@ func toAnthropicMessages(messages []Message) []anthropicMessage {
 // fromAnthropicResponse converts an Anthropic response to the internal Message type.
 func fromAnthropicResponse(resp anthropicResponse) Message {
 	msg := Message{Role: RoleAssistant}
+	if resp.Usage != nil {
+		msg.Usage = resp.Usage.usage()
+	}
 	for _, block := range resp.Content {
 		switch block.Type {
 		case "text":
@@ -226,6 +270,12 @@ type anthropicStreamData struct {
 	Error        *anthropicError       `json:"error,omitempty"`
 	ContentBlock *anthropicStreamBlock `json:"content_block,omitempty"`
 	Delta        *anthropicStreamDelta `json:"delta,omitempty"`
+	// Message is sent with message_start and carries the input usage;
+	// message_delta carries the output usage so far in Usage.
+	Message *struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message,omitempty"`
+	Usage *anthropicUsage `json:"usage,omitempty"`
 }
 
 type anthropicStreamBlock struct {
@@ -244,25 +294,8 @@ type anthropicStreamDelta struct {
 // to write text delta chunks to textOut as they arrive, and reconstructs tool
 // calls from accumulated input_json_delta events.
 func (c *anthropicClient) StreamChat(ctx context.Context, systemPrompt string, messages []Message, tools []ToolDef, textOut io.Writer) (Message, error) {
-	wireMessages := toAnthropicMessages(messages)
-	req := anthropicRequest{
-		Model:     c.model,
-		MaxTokens: anthropicMaxTokens,
-		System:    systemPrompt,
-		Messages:  wireMessages,
-		Stream:    true,
-	}
-	for _, t := range tools {
-		schema := t.InputSchema
-		if schema == nil {
-			schema = map[string]any{"type": "object", "properties": map[string]any{}}
-		}
-		req.Tools = append(req.Tools, anthropicTool{
-			Name:        t.Name,
-			Description: t.Description,
-			InputSchema: schema,
-		})
-	}
+	req := c.newRequest(systemPrompt, messages, tools)
+	req.Stream = true
 
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -300,6 +333,7 @@ func (c *anthropicClient) StreamChat(ctx context.Context, systemPrompt string, m
 	toolOrder := []int{} // preserves insertion order
 
 	var textBuf strings.Builder
+	var usage *Usage
 	for ev := range scanSSE(resp.Body) {
 		if ev.data == "" || ev.data == "[DONE]" {
 			continue
@@ -313,6 +347,14 @@ func (c *anthropicClient) StreamChat(ctx context.Context, systemPrompt string, m
 			if d.Error != nil {
 				return Message{}, fmt.Errorf("anthropic: %s: %s", d.Error.Type, d.Error.Message)
 			}
+		case "message_start":
+			if d.Message != nil {
+				usage = d.Message.Usage.usage()
+			}
+		case "message_delta":
+			if d.Usage != nil && usage != nil {
+				usage.OutputTokens = d.Usage.OutputTokens
+			}
 		case "content_block_start":
 			if d.ContentBlock == nil {
 				continue
@@ -343,7 +385,7 @@ func (c *anthropicClient) StreamChat(ctx context.Context, systemPrompt string, m
 		}
 	}
 
-	msg := Message{Role: RoleAssistant, Content: textBuf.String()}
+	msg := Message{Role: RoleAssistant, Content: textBuf.String(), Usage: usage}
 	for _, idx := range toolOrder {
 		tc := toolCalls[idx]
 		var args map[string]any
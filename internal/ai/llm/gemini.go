@@ -83,8 +83,27 @@ type geminiFunctionDecl struct {
 }
 
 type geminiResponse struct {
-	Candidates []geminiCandidate `json:"candidates"`
-	Error      *geminiError      `json:"error,omitempty"`
+	Candidates    []geminiCandidate    `json:"candidates"`
+	UsageMetadata *geminiUsageMetadata `json:"usageMetadata,omitempty"`
+	Error         *geminiError         `json:"error,omitempty"`
+}
+
+// geminiUsageMetadata is the token usage of a response. PromptTokenCount
+// includes the cached tokens; thinking models report their thoughts
+// separately from the candidates, and both are billed as output.
+type geminiUsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+}
+
+func (u geminiUsageMetadata) usage() *Usage {
+	return &Usage{
+		InputTokens:     u.PromptTokenCount - u.CachedContentTokenCount,
+		OutputTokens:    u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		CacheReadTokens: u.CachedContentTokenCount,
+	}
 }
 
 type geminiCandidate struct {
@@ -140,7 +159,11 @@ func (c *geminiClient) Chat(ctx context.Context, systemPrompt string, messages [
 		return Message{}, fmt.Errorf("gemini: empty candidates in response")
 	}
 
-	return fromGeminiContent(resp.Candidates[0].Content), nil
+	msg := fromGeminiContent(resp.Candidates[0].Content)
+	if resp.UsageMetadata != nil {
+		msg.Usage = resp.UsageMetadata.usage()
+	}
+	return msg, nil
 }
 
 // toGeminiContents converts the internal history to Gemini wire format.
@@ -279,6 +302,10 @@ func (c *geminiClient) StreamChat(ctx context.Context, systemPrompt string, mess
 		if chunk.Error != nil {
 			return Message{}, fmt.Errorf("gemini: %s (%d): %s", chunk.Error.Status, chunk.Error.Code, chunk.Error.Message)
 		}
+		if chunk.UsageMetadata != nil {
+			// Each chunk reports the usage so far; the last one is the total.
+			msg.Usage = chunk.UsageMetadata.usage()
+		}
 		if len(chunk.Candidates) == 0 {
 			continue
 		}
@@ -29,20 +29,22 @@ type Message struct {
 	Content    string
 	ToolCalls  []ToolCall  // populated when the LLM requests tool invocations
 	ToolResult *ToolResult // populated for RoleToolResult messages
+	Usage      *Usage      // set on responses when the provider reports token usage
 }
 
-// ToolCall represents the LLM's request to invoke a named tool.
+// ToolCall represents the LLM's request to invoke a named tool. The JSON tags
+// are the saved conversation format (see chatstorage).
 type ToolCall struct {
-	ID        string         // provider-assigned ID used to correlate results
-	ToolName  string
-	Arguments map[string]any
+	ID        string         `json:"id"` // provider-assigned ID used to correlate results
+	ToolName  string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
 }
 
 // ToolResult is the response fed back to the LLM after a tool executes.
 type ToolResult struct {
-	CallID  string
-	Content string
-	IsError bool
+	CallID  string `json:"call_id"`
+	Content string `json:"content"`
+	IsError bool   `json:"is_error,omitempty"`
 }
 
 // ToolDef is the schema the LLM sees when deciding which tools to call.
@@ -68,6 +70,11 @@ type LLMClient interface {
 	Model() string
 }
 
+// ProviderOpenAICompatible selects a self-hosted or proxied endpoint that
+// speaks the OpenAI chat completions API: Ollama, vLLM, LiteLLM, Azure
+// OpenAI, and the like.
+const ProviderOpenAICompatible = "openai-compatible"
+
 // Config holds provider, model, and API key preferences for NewClient.
 // API keys here are fallbacks; environment variables always take precedence.
 type Config struct {
@@ -76,13 +83,35 @@ type Config struct {
 	AnthropicAPIKey string // fallback if ANTHROPIC_API_KEY env var is unset
 	OpenAIAPIKey    string // fallback if OPENAI_API_KEY env var is unset
 	GeminiAPIKey    string // fallback if GEMINI_API_KEY env var is unset
+
+	// OpenAI-compatible endpoints. BaseURL is the API root, e.g.
+	// http://localhost:11434/v1 for Ollama; requests go to
+	// BaseURL/chat/completions. With Deployment set the endpoint is Azure
+	// OpenAI: requests go to BaseURL/openai/deployments/<Deployment>/chat/completions
+	// and authenticate with an api-key header. APIVersion is sent as the
+	// api-version query parameter. Headers are added to every request.
+	BaseURL    string
+	Deployment string
+	APIVersion string
+	Headers    map[string]string
+	// CompatibleAPIKey is optional: local servers usually need none.
+	CompatibleAPIKey string // fallback if OPENAI_COMPATIBLE_API_KEY env var is unset
 }
 
 // NewClient constructs an LLMClient using the following priority:
+//  0. An OpenAI-compatible endpoint, when cfg.Provider is "openai-compatible"
+//     or cfg.BaseURL is set without a provider; the model name is then the
+//     server's, whatever its prefix
 //  1. Model name prefix: claude-→Anthropic, gpt-/o1/o3→OpenAI, gemini-→Gemini
 //  2. cfg.Provider explicit override
 //  3. Which API key is available (env var > config file key)
 func NewClient(cfg Config) (LLMClient, error) {
+	if cfg.Provider == ProviderOpenAICompatible || (cfg.Provider == "" && cfg.BaseURL != "") {
+		return newOpenAICompatibleClient(cfg)
+	}
+	if cfg.BaseURL != "" && cfg.Provider != "openai" {
+		return nil, fmt.Errorf("base_url only applies to the openai and %s providers, not %q", ProviderOpenAICompatible, cfg.Provider)
+	}
 	if cfg.Model != "" {
 		switch {
 		case strings.HasPrefix(cfg.Model, "claude-"):
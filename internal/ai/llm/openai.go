@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -20,9 +21,15 @@ const (
 	openaiRetryMaxMs     = 30000
 )
 
+// openaiClient speaks the OpenAI chat completions API, to OpenAI itself or
+// to any compatible endpoint.
 type openaiClient struct {
-	apiKey string
-	model  string
+	provider string
+	apiKey   string
+	model    string
+	endpoint string // full chat completions URL, query included
+	azure    bool   // authenticate with an api-key header instead of a bearer token
+	headers  map[string]string
 }
 
 func newOpenAIClient(cfg Config) (LLMClient, error) {
@@ -37,12 +44,93 @@ func newOpenAIClient(cfg Config) (LLMClient, error) {
 	if model == "" {
 		model = openaiDefaultModel
 	}
-	return &openaiClient{apiKey: key, model: model}, nil
+	c := &openaiClient{provider: "openai", apiKey: key, model: model, endpoint: openaiAPIURL, headers: cfg.Headers}
+	if cfg.BaseURL != "" {
+		// An OpenAI-compatible gateway in front of OpenAI, such as LiteLLM.
+		endpoint, err := compatibleEndpoint(cfg)
+		if err != nil {
+			return nil, err
+		}
+		c.endpoint = endpoint
+	}
+	return c, nil
 }
 
-func (c *openaiClient) Provider() string { return "openai" }
+// newOpenAICompatibleClient targets cfg.BaseURL. Unlike OpenAI itself, the
+// endpoint may need no API key, and has no default model unless it is an
+// Azure deployment, which fixes the model.
+func newOpenAICompatibleClient(cfg Config) (LLMClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("the %s provider needs a base URL; run: datumctl ai config set base_url <url>", ProviderOpenAICompatible)
+	}
+	endpoint, err := compatibleEndpoint(cfg)
+	if err != nil {
+		return nil, err
+	}
+	key := os.Getenv("OPENAI_COMPATIBLE_API_KEY")
+	if key == "" {
+		key = cfg.CompatibleAPIKey
+	}
+	model := cfg.Model
+	if model == "" {
+		model = cfg.Deployment
+	}
+	if model == "" {
+		return nil, fmt.Errorf("the %s provider needs a model name; pass --model or run: datumctl ai config set model <name>", ProviderOpenAICompatible)
+	}
+	return &openaiClient{
+		provider: ProviderOpenAICompatible,
+		apiKey:   key,
+		model:    model,
+		endpoint: endpoint,
+		azure:    cfg.Deployment != "",
+		headers:  cfg.Headers,
+	}, nil
+}
+
+// compatibleEndpoint builds the chat completions URL for cfg.BaseURL.
+func compatibleEndpoint(cfg Config) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(cfg.BaseURL, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("base URL %q must be an absolute http or https URL", cfg.BaseURL)
+	}
+	if cfg.Deployment != "" {
+		u = u.JoinPath("openai", "deployments", cfg.Deployment, "chat", "completions")
+	} else {
+		u = u.JoinPath("chat", "completions")
+	}
+	if cfg.APIVersion != "" {
+		q := u.Query()
+		q.Set("api-version", cfg.APIVersion)
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+func (c *openaiClient) Provider() string { return c.provider }
 func (c *openaiClient) Model() string    { return c.model }
 
+// newRequest builds a chat completions request with the endpoint's
+// authentication and extra headers.
+func (c *openaiClient) newRequest(ctx context.Context, body []byte) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("openai: build request: %w", err)
+	}
+	for name, value := range c.headers {
+		httpReq.Header.Set(name, value)
+	}
+	switch {
+	case c.apiKey == "":
+	case c.azure:
+		httpReq.Header.Set("api-key", c.apiKey)
+	default:
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return httpReq, nil
+}
+
 // --- wire types ---
 
 type openaiRequest struct {
@@ -83,9 +171,32 @@ type openaiToolDef struct {
 
 type openaiResponse struct {
 	Choices []openaiChoice `json:"choices"`
+	Usage   *openaiUsage   `json:"usage,omitempty"`
 	Error   *openaiError   `json:"error,omitempty"`
 }
 
+// openaiUsage is the token usage of a completion. PromptTokens includes
+// the cached ones, which OpenAI caches and discounts automatically.
+type openaiUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details,omitempty"`
+}
+
+func (u openaiUsage) usage() *Usage {
+	var cached int
+	if u.PromptTokensDetails != nil {
+		cached = u.PromptTokensDetails.CachedTokens
+	}
+	return &Usage{
+		InputTokens:     u.PromptTokens - cached,
+		OutputTokens:    u.CompletionTokens,
+		CacheReadTokens: cached,
+	}
+}
+
 type openaiChoice struct {
 	Message      openaiMessage `json:"message"`
 	FinishReason string        `json:"finish_reason"`
@@ -139,7 +250,11 @@ func (c *openaiClient) Chat(ctx context.Context, systemPrompt string, messages [
 		return Message{}, fmt.Errorf("openai: empty choices in response")
 	}
 
-	return fromOpenAIMessage(resp.Choices[0].Message), nil
+	msg := fromOpenAIMessage(resp.Choices[0].Message)
+	if resp.Usage != nil {
+		msg.Usage = resp.Usage.usage()
+	}
+	return msg, nil
 }
 
 // toOpenAIMessages converts internal history to OpenAI wire format.
@@ -210,17 +325,28 @@ func (c *openaiClient) StreamChat(ctx context.Context, systemPrompt string, mess
 	}
 	wireMessages = append(wireMessages, toOpenAIMessages(messages)...)
 
+	type openaiStreamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
+	}
 	type openaiStreamRequest struct {
-		Model    string          `json:"model"`
-		Messages []openaiMessage `json:"messages"`
-		Tools    []openaiTool    `json:"tools,omitempty"`
-		Stream   bool            `json:"stream"`
+		Model         string               `json:"model"`
+		Messages      []openaiMessage      `json:"messages"`
+		Tools         []openaiTool         `json:"tools,omitempty"`
+		Stream        bool                 `json:"stream"`
+		StreamOptions *openaiStreamOptions `json:"stream_options,omitempty"`
 	}
 	req := openaiStreamRequest{
 		Model:    c.model,
 		Messages: wireMessages,
 		Stream:   true,
 	}
+	if c.provider == "openai" {
+		// Ask for a final chunk, with no choices, that carries the usage.
+		// Compatible endpoints are not asked: older Azure API versions and
+		// several self-hosted servers reject stream_options, though some
+		// report usage anyway.
+		req.StreamOptions = &openaiStreamOptions{IncludeUsage: true}
+	}
 	for _, t := range tools {
 		params := t.InputSchema
 		if params == nil {
@@ -241,12 +367,10 @@ func (c *openaiClient) StreamChat(ctx context.Context, systemPrompt string, mess
 		return Message{}, fmt.Errorf("openai: marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, openaiAPIURL, bytes.NewReader(body))
+	httpReq, err := c.newRequest(ctx, body)
 	if err != nil {
-		return Message{}, fmt.Errorf("openai: build request: %w", err)
+		return Message{}, err
 	}
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "text/event-stream")
 
 	resp, err := http.DefaultClient.Do(httpReq)
@@ -272,6 +396,7 @@ func (c *openaiClient) StreamChat(ctx context.Context, systemPrompt string, mess
 				ToolCalls []openaiIndexedTC `json:"tool_calls"`
 			} `json:"delta"`
 		} `json:"choices"`
+		Usage *openaiUsage `json:"usage,omitempty"`
 		Error *openaiError `json:"error,omitempty"`
 	}
 
@@ -284,6 +409,7 @@ func (c *openaiClient) StreamChat(ctx context.Context, systemPrompt string, mess
 	toolOrder := []int{}
 
 	var textBuf strings.Builder
+	var usage *Usage
 	for ev := range scanSSE(resp.Body) {
 		if ev.data == "" || ev.data == "[DONE]" {
 			continue
@@ -295,6 +421,9 @@ func (c *openaiClient) StreamChat(ctx context.Context, systemPrompt string, mess
 		if chunk.Error != nil {
 			return Message{}, fmt.Errorf("openai: %s: %s", chunk.Error.Type, chunk.Error.Message)
 		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.usage()
+		}
 		if len(chunk.Choices) == 0 {
 			continue
 		}
@@ -321,7 +450,7 @@ func (c *openaiClient) StreamChat(ctx context.Context, systemPrompt string, mess
 		}
 	}
 
-	msg := Message{Role: RoleAssistant, Content: textBuf.String()}
+	msg := Message{Role: RoleAssistant, Content: textBuf.String(), Usage: usage}
 	for _, idx := range toolOrder {
 		ta := toolByIndex[idx]
 		var args map[string]any
@@ -355,12 +484,10 @@ func (c *openaiClient) doWithRetry(ctx context.Context, body []byte, out *openai
 			}
 		}
 
-		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, openaiAPIURL, bytes.NewReader(body))
+		httpReq, err := c.newRequest(ctx, body)
 		if err != nil {
-			return fmt.Errorf("openai: build request: %w", err)
+			return err
 		}
-		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-		httpReq.Header.Set("Content-Type", "application/json")
 
 		resp, err := http.DefaultClient.Do(httpReq)
 		if err != nil {
@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stubCompletions serves a chat completions endpoint and records the last
+// request it saw.
+type stubCompletions struct {
+	server *httptest.Server
+	req    *http.Request
+	body   map[string]any
+}
+
+func newStubCompletions(t *testing.T) *stubCompletions {
+	t.Helper()
+	stub := &stubCompletions{}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.req = r
+		data, _ := io.ReadAll(r.Body)
+		stub.body = nil
+		_ = json.Unmarshal(data, &stub.body)
+		if stub.body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, chunk := range []string{
+				`{"choices":[{"delta":{"content":"Hel"}}]}`,
+				`{"choices":[{"delta":{"content":"lo"}}]}`,
+				`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"list_resources","arguments":"{\"kind\":"}}]}}]}`,
+				`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"DNSZone\"}"}}]}}]}`,
+				`{"choices":[],"usage":{"prompt_tokens":1200,"completion_tokens":30,"prompt_tokens_details":{"cached_tokens":1024}}}`,
+				`[DONE]`,
+			} {
+				fmt.Fprintf(w, "data: %s\n\n", chunk)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":2}}`)
+	}))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func TestOpenAICompatibleWithoutKey(t *testing.T) {
+	t.Setenv("OPENAI_COMPATIBLE_API_KEY", "")
+	stub := newStubCompletions(t)
+	client, err := NewClient(Config{
+		Provider: ProviderOpenAICompatible,
+		BaseURL:  stub.server.URL + "/v1/",
+		Model:    "gpt-4o", // a prefix that would otherwise pick OpenAI itself
+		Headers:  map[string]string{"X-Team": "platform"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Provider() != ProviderOpenAICompatible || client.Model() != "gpt-4o" {
+		t.Errorf("client = %s/%s", client.Provider(), client.Model())
+	}
+
+	var streamed strings.Builder
+	msg, err := client.StreamChat(context.Background(), "be brief", []Message{{Role: RoleUser, Content: "hi"}}, nil, &streamed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stub.req.URL.Path != "/v1/chat/completions" {
+		t.Errorf("path = %q, want /v1/chat/completions", stub.req.URL.Path)
+	}
+	if got := stub.req.Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none without a key", got)
+	}
+	if got := stub.req.Header.Get("X-Team"); got != "platform" {
+		t.Errorf("X-Team = %q, want the configured header", got)
+	}
+	if stub.body["model"] != "gpt-4o" {
+		t.Errorf("model = %v", stub.body["model"])
+	}
+	if streamed.String() != "Hello" || msg.Content != "Hello" {
+		t.Errorf("streamed %q, content %q", streamed.String(), msg.Content)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Arguments["kind"] != "DNSZone" {
+		t.Errorf("tool calls = %+v", msg.ToolCalls)
+	}
+	if opts, ok := stub.body["stream_options"]; ok {
+		t.Errorf("stream_options = %v, want none for a compatible endpoint", opts)
+	}
+	if want := (Usage{InputTokens: 176, OutputTokens: 30, CacheReadTokens: 1024}); msg.Usage == nil || *msg.Usage != want {
+		t.Errorf("usage = %+v, want %+v", msg.Usage, want)
+	}
+}
+
+func TestOpenAICompatibleAzureDeployment(t *testing.T) {
+	t.Setenv("OPENAI_COMPATIBLE_API_KEY", "azure-key")
+	stub := newStubCompletions(t)
+	client, err := NewClient(Config{
+		BaseURL:    stub.server.URL,
+		Deployment: "gpt-4o-prod",
+		APIVersion: "2024-10-21",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Provider() != ProviderOpenAICompatible || client.Model() != "gpt-4o-prod" {
+		t.Errorf("client = %s/%s, want the deployment as the model", client.Provider(), client.Model())
+	}
+
+	msg, err := client.Chat(context.Background(), "", []Message{{Role: RoleUser, Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Content != "Hello" {
+		t.Errorf("content = %q", msg.Content)
+	}
+	if want := (Usage{InputTokens: 12, OutputTokens: 2}); msg.Usage == nil || *msg.Usage != want {
+		t.Errorf("usage = %+v, want %+v", msg.Usage, want)
+	}
+	if got := stub.req.URL.Path; got != "/openai/deployments/gpt-4o-prod/chat/completions" {
+		t.Errorf("path = %q", got)
+	}
+	if got := stub.req.URL.Query().Get("api-version"); got != "2024-10-21" {
+		t.Errorf("api-version = %q", got)
+	}
+	if stub.req.Header.Get("api-key") != "azure-key" || stub.req.Header.Get("Authorization") != "" {
+		t.Errorf("auth headers = api-key %q, Authorization %q; want only api-key",
+			stub.req.Header.Get("api-key"), stub.req.Header.Get("Authorization"))
+	}
+}
+
+func TestOpenAIThroughGateway(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	stub := newStubCompletions(t)
+	client, err := NewClient(Config{Provider: "openai", BaseURL: stub.server.URL + "/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.StreamChat(context.Background(), "", []Message{{Role: RoleUser, Content: "hi"}}, nil, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if opts, _ := stub.body["stream_options"].(map[string]any); opts["include_usage"] != true {
+		t.Errorf("stream_options = %v, want include_usage", stub.body["stream_options"])
+	}
+	if stub.req.URL.Path != "/v1/chat/completions" || stub.req.Header.Get("Authorization") != "Bearer sk-test" {
+		t.Errorf("request = %s with Authorization %q", stub.req.URL.Path, stub.req.Header.Get("Authorization"))
+	}
+	if client.Provider() != "openai" || client.Model() != openaiDefaultModel {
+		t.Errorf("client = %s/%s", client.Provider(), client.Model())
+	}
+}
+
+func TestOpenAICompatibleConfigErrors(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	for name, cfg := range map[string]Config{
+		"no base URL":       {Provider: ProviderOpenAICompatible, Model: "llama3"},
+		"no model":          {Provider: ProviderOpenAICompatible, BaseURL: "http://localhost:11434/v1"},
+		"relative base URL": {BaseURL: "localhost:11434/v1", Model: "llama3"},
+		"other provider":    {Provider: "anthropic", BaseURL: "http://localhost:11434/v1", AnthropicAPIKey: "k"},
+	} {
+		if _, err := NewClient(cfg); err == nil {
+			t.Errorf("%s: NewClient succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package llm
+
+import "strings"
+
+// Usage counts the tokens of one or more model calls, as the provider
+// reported them. InputTokens excludes the cached tokens, which are counted
+// separately because they are billed at other rates.
+type Usage struct {
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
+}
+
+// Add adds o to u.
+func (u *Usage) Add(o Usage) {
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.CacheReadTokens += o.CacheReadTokens
+	u.CacheWriteTokens += o.CacheWriteTokens
+}
+
+// Total is every token processed: input, cached input, and output.
+func (u Usage) Total() int {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// price is a model's list price in US dollars per million tokens.
+type price struct {
+	input, output, cacheRead, cacheWrite float64
+}
+
+// prices maps model names to list prices. A name also prices its dated and
+// numbered versions (claude-sonnet-4-6, gpt-4o-2024-08-06), the longest
+// match winning, but never a named variant such as o1-mini or o3-pro: those
+// are priced differently, so each needs its own entry.
+var prices = map[string]map[string]price{
+	"anthropic": {
+		"claude-opus-4-5":   {5, 25, 0.50, 6.25},
+		"claude-opus-4-6":   {5, 25, 0.50, 6.25},
+		"claude-opus-4":     {15, 75, 1.50, 18.75},
+		"claude-sonnet-4":   {3, 15, 0.30, 3.75},
+		"claude-3-7-sonnet": {3, 15, 0.30, 3.75},
+		"claude-3-5-sonnet": {3, 15, 0.30, 3.75},
+		"claude-haiku-4":    {1, 5, 0.10, 1.25},
+		"claude-3-5-haiku":  {0.80, 4, 0.08, 1},
+	},
+	"openai": {
+		"gpt-4o":       {2.50, 10, 1.25, 0},
+		"gpt-4o-mini":  {0.15, 0.60, 0.075, 0},
+		"gpt-4.1":      {2, 8, 0.50, 0},
+		"gpt-4.1-mini": {0.40, 1.60, 0.10, 0},
+		"gpt-4.1-nano": {0.10, 0.40, 0.025, 0},
+		"gpt-5":        {1.25, 10, 0.125, 0},
+		"gpt-5-mini":   {0.25, 2, 0.025, 0},
+		"gpt-5-nano":   {0.05, 0.40, 0.005, 0},
+		"o1":           {15, 60, 7.50, 0},
+		"o1-mini":      {1.10, 4.40, 0.55, 0},
+		"o3":           {2, 8, 0.50, 0},
+		"o3-mini":      {1.10, 4.40, 0.55, 0},
+		"o4-mini":      {1.10, 4.40, 0.275, 0},
+	},
+	"gemini": {
+		"gemini-2.0-flash":      {0.10, 0.40, 0.025, 0},
+		"gemini-2.0-flash-lite": {0.075, 0.30, 0.01875, 0},
+		"gemini-2.5-flash":      {0.30, 2.50, 0.075, 0},
+		"gemini-2.5-pro":        {1.25, 10, 0.31, 0},
+	},
+}
+
+// EstimateCost returns the list-price cost of u in US dollars for a
+// provider's model. ok is false for models without a known price, including
+// every OpenAI-compatible endpoint, whose pricing is the operator's.
+func EstimateCost(provider, model string, u Usage) (cost float64, ok bool) {
+	var best string
+	for name := range prices[provider] {
+		if versionOf(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return 0, false
+	}
+	p := prices[provider][best]
+	cost = float64(u.InputTokens)*p.input +
+		float64(u.OutputTokens)*p.output +
+		float64(u.CacheReadTokens)*p.cacheRead +
+		float64(u.CacheWriteTokens)*p.cacheWrite
+	return cost / 1_000_000, true
+}
+
+// versionOf reports whether model is name or a version of it: name followed
+// by a dash and a date or version number, as in claude-opus-4-1 or
+// gpt-4o-mini-2024-07-18.
+func versionOf(model, name string) bool {
+	rest, ok := strings.CutPrefix(model, name)
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	return len(rest) > 1 && rest[0] == '-' && rest[1] >= '0' && rest[1] <= '9'
+}
@@ -0,0 +1,36 @@
+package llm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	u := Usage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 2_000_000, CacheWriteTokens: 400_000}
+	for _, tc := range []struct {
+		provider, model string
+		want            float64
+		wantOK          bool
+	}{
+		// 3 + 1.5 + 0.6 + 1.5
+		{"anthropic", "claude-sonnet-4-6", 6.6, true},
+		// The longest prefix wins: gpt-4o-mini, not gpt-4o.
+		{"openai", "gpt-4o-mini-2024-07-18", 0.15 + 0.06 + 0.15, true},
+		{"gemini", "gemini-2.0-flash", 0.10 + 0.04 + 0.05, true},
+		// Cheaper siblings have their own prices, not their family's.
+		{"openai", "o1-mini", 1.10 + 0.44 + 1.10, true},
+		{"openai", "o3-mini-2025-01-31", 1.10 + 0.44 + 1.10, true},
+		{"openai", "gpt-5-nano", 0.05 + 0.04 + 0.01, true},
+		{"openai", "o1-2024-12-17", 15 + 6 + 15, true},
+		// An unlisted variant is unknown rather than priced as its family.
+		{"openai", "o1-pro", 0, false},
+		{"gemini", "gemini-2.5-flash-lite", 0, false},
+		{"openai", "davinci-002", 0, false},
+		{ProviderOpenAICompatible, "gpt-4o", 0, false},
+	} {
+		got, ok := EstimateCost(tc.provider, tc.model, u)
+		if ok != tc.wantOK || math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("EstimateCost(%s, %s) = %v, %v; want %v, %v", tc.provider, tc.model, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
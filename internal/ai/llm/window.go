@@ -0,0 +1,27 @@
+package llm
+
+import "strings"
+
+// ContextWindow returns the context window, in tokens, of a provider's
+// model. Unknown models get their provider's smallest common window, and
+// OpenAI-compatible servers a conservative 32k, since their models vary;
+// set context_tokens in the AI config when a model allows more.
+func ContextWindow(provider, model string) int {
+	switch provider {
+	case "anthropic":
+		return 200_000
+	case "gemini":
+		return 1_000_000
+	case "openai":
+		switch {
+		case strings.HasPrefix(model, "gpt-4.1"):
+			return 1_000_000
+		case strings.HasPrefix(model, "gpt-5"):
+			return 400_000
+		case strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"), strings.HasPrefix(model, "o4"):
+			return 200_000
+		}
+		return 128_000
+	}
+	return 32_768
+}
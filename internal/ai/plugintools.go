@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+	"go.datum.net/datumctl/internal/client"
+	"go.datum.net/datumctl/internal/plugindispatch"
+	"go.datum.net/datumctl/internal/pluginstore"
+)
+
+// toolNamePattern is the tool name format every supported provider accepts.
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// UserPluginsDir returns the user's managed plugins directory, whose tools
+// the commands offer, or "" when it cannot be resolved.
+func UserPluginsDir() string {
+	dir, err := pluginstore.PluginsDir("")
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// addPluginTools registers plugin tools from pluginsDir, if set. Only managed
+// plugins with a recorded SHA256 that still matches the binary contribute
+// tools; PATH plugins never do. A plugin whose manifest needs a newer plugin
+// API is skipped, as is any tool whose name is invalid or already taken.
+//
+// Tools are named <plugin>_<tool> so they cannot shadow built-in tools, and
+// mutating ones go through the confirmation gate like apply_manifest.
+func addPluginTools(r *Registry, factory *client.DatumCloudFactory, pluginsDir string) {
+	if pluginsDir == "" {
+		return
+	}
+	manifest, err := pluginstore.Load(pluginsDir)
+	if err != nil {
+		return
+	}
+	names := make([]string, 0, len(manifest.Plugins))
+	for name := range manifest.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, plugin := range names {
+		entry := manifest.Plugins[plugin]
+		if entry == nil || entry.SHA256 == "" || entry.Manifest == nil || len(entry.Manifest.Tools) == 0 {
+			continue
+		}
+		binaryPath, managed, err := plugindispatch.FindPlugin(plugin, pluginsDir)
+		if err != nil || !managed {
+			continue
+		}
+		if plugindispatch.VerifyManagedPluginIntegrity(pluginsDir, plugin, binaryPath) != nil {
+			continue
+		}
+		if _, err := plugindispatch.CheckCompatibilityAtInvocation(entry.Manifest, "", plugindispatch.PluginAPIVersion); err != nil {
+			continue
+		}
+
+		for _, pt := range entry.Manifest.Tools {
+			name := plugin + "_" + pt.Name
+			if pt.Name == "" || !toolNamePattern.MatchString(name) {
+				continue
+			}
+			if _, taken := r.Find(name); taken {
+				continue
+			}
+			schema := pt.InputSchema
+			if schema == nil {
+				schema = map[string]any{"type": "object", "properties": map[string]any{}}
+			}
+			r.add(Tool{
+				Def: llm.ToolDef{
+					Name:        name,
+					Description: fmt.Sprintf("%s (provided by the %s plugin)", pt.Description, plugin),
+					InputSchema: schema,
+				},
+				RequiresConfirm: pt.Mutating,
+				Execute: func(ctx context.Context, args map[string]any) (string, error) {
+					// The binary may have been replaced since the registry was built.
+					if err := plugindispatch.VerifyManagedPluginIntegrity(pluginsDir, plugin, binaryPath); err != nil {
+						return "", err
+					}
+					return plugindispatch.RunTool(ctx, binaryPath, pt.Name, args, factory)
+				},
+			})
+		}
+	}
+}
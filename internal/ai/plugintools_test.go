@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"go.datum.net/datumctl/internal/client"
+	"go.datum.net/datumctl/internal/pluginstore"
+)
+
+// installFakePlugin writes a shell-script plugin into dir and records it in
+// plugins.json with its SHA256 (or none, when recordHash is false) and the
+// given tools.
+func installFakePlugin(t *testing.T, dir, name, script string, recordHash bool, tools ...pluginstore.PluginTool) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+	m, err := pluginstore.Load(dir)
+	if err != nil {
+		t.Fatalf("load plugins.json: %v", err)
+	}
+	if m.Plugins == nil {
+		m.Plugins = map[string]*pluginstore.InstalledPlugin{}
+	}
+	entry := &pluginstore.InstalledPlugin{
+		Source:   name,
+		Version:  "v0.1.0",
+		Manifest: &pluginstore.PluginManifest{Name: name, Version: "v0.1.0", APIVersion: 1, Tools: tools},
+	}
+	if recordHash {
+		data, _ := os.ReadFile(path)
+		sum := sha256.Sum256(data)
+		entry.SHA256 = hex.EncodeToString(sum[:])
+	}
+	m.Plugins[name] = entry
+	if err := pluginstore.Save(dir, m); err != nil {
+		t.Fatalf("save plugins.json: %v", err)
+	}
+	return path
+}
+
+func TestPluginTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake plugins are shell scripts")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	factory, err := client.NewDatumFactory(context.Background())
+	if err != nil {
+		t.Fatalf("NewDatumFactory: %v", err)
+	}
+
+	dir := t.TempDir()
+	dns := installFakePlugin(t, dir, "dns", `echo "$2"; cat`+"\n", true,
+		pluginstore.PluginTool{Name: "list_zones", Description: "List DNS zones"},
+		pluginstore.PluginTool{Name: "delete_zone", Description: "Delete a DNS zone", Mutating: true},
+		pluginstore.PluginTool{Name: "bad name", Description: "Not a valid tool name"},
+	)
+	installFakePlugin(t, dir, "unrecorded", "echo hi\n", false,
+		pluginstore.PluginTool{Name: "hello", Description: "Say hello"})
+	tampered := installFakePlugin(t, dir, "tampered", "echo hi\n", true,
+		pluginstore.PluginTool{Name: "hello", Description: "Say hello"})
+	if err := os.WriteFile(tampered, []byte("#!/bin/sh\necho pwned\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewEmptyRegistry()
+	addPluginTools(r, factory, dir)
+
+	var names []string
+	for _, def := range r.Defs() {
+		names = append(names, def.Name)
+	}
+	if got := strings.Join(names, ","); got != "dns_list_zones,dns_delete_zone" {
+		t.Fatalf("registered tools = %s, want dns_list_zones,dns_delete_zone", got)
+	}
+
+	if _, ok := NewRegistry(factory, dir).Find("dns_list_zones"); !ok {
+		t.Error("NewRegistry did not offer the tools of plugins in pluginsDir")
+	}
+	if _, ok := NewRegistry(factory, "").Find("dns_list_zones"); ok {
+		t.Error("NewRegistry without a pluginsDir offered plugin tools")
+	}
+
+	list, _ := r.Find("dns_list_zones")
+	if list.RequiresConfirm {
+		t.Error("a read-only plugin tool should not require confirmation")
+	}
+	if !strings.Contains(list.Def.Description, "dns plugin") {
+		t.Errorf("description %q should name the plugin", list.Def.Description)
+	}
+	if del, _ := r.Find("dns_delete_zone"); !del.RequiresConfirm {
+		t.Error("a mutating plugin tool should require confirmation")
+	}
+
+	out, err := list.Execute(context.Background(), map[string]any{"project": "p1"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if want := "list_zones\n" + `{"project":"p1"}`; out != want {
+		t.Errorf("Execute output = %q, want %q", out, want)
+	}
+
+	// A binary replaced after the registry was built must not run.
+	if err := os.WriteFile(dns, []byte("#!/bin/sh\necho pwned\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := list.Execute(context.Background(), nil); err == nil {
+		t.Errorf("Execute ran a modified binary: %q", out)
+	}
+}
@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+// Policy decides tool calls that need confirmation before anyone is asked:
+// some can run unattended, some never run, and the rest go to the
+// confirmation gate. It is read from the "policy" section of the AI config
+// file; YesFor comes from the --yes-for flag.
+//
+// A gated call is decided by the first of these that applies:
+//  1. AutoApproveDryRun approves apply_manifest with dryRun=true.
+//  2. MutationProjects denies a call outside the listed projects.
+//  3. The first matching Rule approves, denies, or asks.
+//  4. A matching YesFor scope approves.
+//  5. Otherwise the gate asks.
+type Policy struct {
+	// AutoApproveDryRun runs apply_manifest validations (dryRun=true)
+	// without asking: they change nothing.
+	AutoApproveDryRun bool `json:"auto_approve_dry_run,omitempty"`
+
+	// MutationProjects, when set, allows changes only while the session is
+	// in one of these projects. Everything else is denied, including changes
+	// at organization or platform level.
+	MutationProjects []string `json:"mutation_projects,omitempty"`
+
+	// Rules are checked in order; the first that matches decides.
+	Rules []PolicyRule `json:"rules,omitempty"`
+
+	// YesFor approves what it matches unless a rule denied it first.
+	YesFor []PolicyRule `json:"-"`
+}
+
+// Policy actions.
+const (
+	PolicyApprove = "approve"
+	PolicyDeny    = "deny"
+	PolicyAsk     = "ask"
+)
+
+// PolicyRule matches gated tool calls. Empty lists match anything; kinds
+// match case-insensitively.
+type PolicyRule struct {
+	// Action is approve, deny, or ask.
+	Action   string   `json:"action"`
+	Tools    []string `json:"tools,omitempty"`
+	Kinds    []string `json:"kinds,omitempty"`
+	Projects []string `json:"projects,omitempty"`
+	// Reason is shown when the rule decides, e.g. why a kind is protected.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Verdict is the outcome of evaluating a Policy.
+type Verdict int
+
+const (
+	// VerdictAsk leaves the call to the confirmation gate.
+	VerdictAsk Verdict = iota
+	VerdictApprove
+	VerdictDeny
+)
+
+// Decision is a Verdict with a human-readable reason.
+type Decision struct {
+	Verdict Verdict
+	Reason  string
+}
+
+// Validate reports the first malformed rule.
+func (p *Policy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for i, rule := range p.Rules {
+		switch rule.Action {
+		case PolicyApprove, PolicyDeny, PolicyAsk:
+		default:
+			return fmt.Errorf("policy rule %d: action %q must be approve, deny, or ask", i+1, rule.Action)
+		}
+	}
+	return nil
+}
+
+// ParseYesFor parses a --yes-for scope: comma-separated key=value pairs,
+// where key is tool, kind, or project, e.g. "kind=DNSRecord,project=prod".
+// A key may repeat to allow several values.
+func ParseYesFor(scope string) (PolicyRule, error) {
+	rule := PolicyRule{Action: PolicyApprove, Reason: "--yes-for " + scope}
+	for _, pair := range strings.Split(scope, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || value == "" {
+			return PolicyRule{}, fmt.Errorf("--yes-for %q: want key=value pairs, e.g. kind=DNSRecord", scope)
+		}
+		switch key {
+		case "tool":
+			rule.Tools = append(rule.Tools, value)
+		case "kind":
+			rule.Kinds = append(rule.Kinds, value)
+		case "project":
+			rule.Projects = append(rule.Projects, value)
+		default:
+			return PolicyRule{}, fmt.Errorf("--yes-for %q: unknown key %q (want tool, kind, or project)", scope, key)
+		}
+	}
+	return rule, nil
+}
+
+// Evaluate decides a gated call made while the session is in project
+// (empty at organization or platform level). A nil Policy always asks.
+func (p *Policy) Evaluate(call llm.ToolCall, project string) Decision {
+	if p == nil {
+		return Decision{Verdict: VerdictAsk}
+	}
+	if dryRun, _ := call.Arguments["dryRun"].(bool); p.AutoApproveDryRun && dryRun && call.ToolName == "apply_manifest" {
+		return Decision{Verdict: VerdictApprove, Reason: "dry runs are auto-approved"}
+	}
+	if len(p.MutationProjects) > 0 && !slices.Contains(p.MutationProjects, project) {
+		where := "outside a project"
+		if project != "" {
+			where = "in project " + project
+		}
+		return Decision{Verdict: VerdictDeny, Reason: fmt.Sprintf(
+			"changes are only allowed in projects %s, and this session is %s", strings.Join(p.MutationProjects, ", "), where)}
+	}
+	kind := callKind(call)
+	for _, rule := range p.Rules {
+		if !rule.matches(call.ToolName, kind, project) {
+			continue
+		}
+		if rule.Action == PolicyApprove {
+			return Decision{Verdict: VerdictApprove, Reason: rule.reason()}
+		}
+		if rule.Action == PolicyDeny {
+			return Decision{Verdict: VerdictDeny, Reason: rule.reason()}
+		}
+		break // ask: only --yes-for can still approve it
+	}
+	for _, rule := range p.YesFor {
+		if rule.matches(call.ToolName, kind, project) {
+			return Decision{Verdict: VerdictApprove, Reason: rule.Reason}
+		}
+	}
+	return Decision{Verdict: VerdictAsk}
+}
+
+func (r PolicyRule) matches(tool, kind, project string) bool {
+	if len(r.Tools) > 0 && !slices.Contains(r.Tools, tool) {
+		return false
+	}
+	if len(r.Kinds) > 0 && !slices.ContainsFunc(r.Kinds, func(k string) bool { return strings.EqualFold(k, kind) }) {
+		return false
+	}
+	return len(r.Projects) == 0 || slices.Contains(r.Projects, project)
+}
+
+func (r PolicyRule) reason() string {
+	if r.Reason != "" {
+		return r.Reason
+	}
+	return "policy rule: " + r.Action
+}
+
+// callKind returns the resource kind a call acts on: the kind argument of
+// delete_resource, or the kind of apply_manifest's manifest. It is empty
+// when the call does not say, which only kind-less rules match.
+func callKind(call llm.ToolCall) string {
+	if kind := stringArg(call.Arguments, "kind"); kind != "" {
+		return kind
+	}
+	if rawYAML := stringArg(call.Arguments, "yaml"); rawYAML != "" {
+		if obj, err := parseYAMLManifest(rawYAML); err == nil {
+			return obj.GetKind()
+		}
+	}
+	return ""
+}
+
+// DeniedResult is the tool result for a call the policy denied, telling the
+// model not to retry it.
+func DeniedResult(reason string) string {
+	b, _ := json.Marshal(map[string]any{"skipped": true, "reason": "denied by policy: " + reason})
+	return string(b)
+}
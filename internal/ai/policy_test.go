@@ -0,0 +1,189 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+	syaml "sigs.k8s.io/yaml"
+)
+
+const testPolicy = `
+auto_approve_dry_run: true
+rules:
+  - action: deny
+    tools: [delete_resource]
+    kinds: [Project, Organization]
+    reason: projects and organizations are deleted in the portal
+  - action: approve
+    tools: [apply_manifest]
+    kinds: [DNSRecordSet]
+    projects: [staging]
+  - action: ask
+    kinds: [DNSZone]
+`
+
+func mustPolicy(t *testing.T, doc string) *Policy {
+	t.Helper()
+	var p Policy
+	if err := syaml.Unmarshal([]byte(doc), &p); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return &p
+}
+
+func applyCall(kind string, dryRun bool) llm.ToolCall {
+	return llm.ToolCall{ToolName: "apply_manifest", Arguments: map[string]any{
+		"yaml":   "apiVersion: v1\nkind: " + kind + "\nmetadata:\n  name: x\n",
+		"dryRun": dryRun,
+	}}
+}
+
+func deleteCall(kind string) llm.ToolCall {
+	return llm.ToolCall{ToolName: "delete_resource", Arguments: map[string]any{"kind": kind, "name": "x"}}
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := mustPolicy(t, testPolicy)
+	yes, err := ParseYesFor("kind=DNSZone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy.YesFor = []PolicyRule{yes}
+
+	for _, tc := range []struct {
+		name    string
+		call    llm.ToolCall
+		project string
+		want    Verdict
+	}{
+		{"dry run", applyCall("Domain", true), "prod", VerdictApprove},
+		{"protected kind", deleteCall("project"), "prod", VerdictDeny},
+		{"approved in staging", applyCall("DNSRecordSet", false), "staging", VerdictApprove},
+		{"not approved in prod", applyCall("DNSRecordSet", false), "prod", VerdictAsk},
+		{"ask rule, then --yes-for", deleteCall("DNSZone"), "prod", VerdictApprove},
+		{"no rule", deleteCall("Domain"), "prod", VerdictAsk},
+	} {
+		if got := policy.Evaluate(tc.call, tc.project).Verdict; got != tc.want {
+			t.Errorf("%s: verdict = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+
+	// --yes-for never overrides a deny rule.
+	all, _ := ParseYesFor("tool=delete_resource")
+	policy.YesFor = append(policy.YesFor, all)
+	if got := policy.Evaluate(deleteCall("Organization"), "prod"); got.Verdict != VerdictDeny || !strings.Contains(got.Reason, "portal") {
+		t.Errorf("--yes-for overrode a deny rule: %+v", got)
+	}
+
+	var nilPolicy *Policy
+	if nilPolicy.Evaluate(deleteCall("Domain"), "").Verdict != VerdictAsk {
+		t.Error("a nil policy must always ask")
+	}
+}
+
+func TestPolicyMutationProjects(t *testing.T) {
+	policy := mustPolicy(t, "auto_approve_dry_run: true\nmutation_projects: [staging]\n")
+	if got := policy.Evaluate(deleteCall("Domain"), "prod"); got.Verdict != VerdictDeny || !strings.Contains(got.Reason, "project prod") {
+		t.Errorf("prod = %+v, want denied", got)
+	}
+	if got := policy.Evaluate(deleteCall("Domain"), ""); got.Verdict != VerdictDeny {
+		t.Errorf("organization level = %+v, want denied", got)
+	}
+	if got := policy.Evaluate(deleteCall("Domain"), "staging"); got.Verdict != VerdictAsk {
+		t.Errorf("staging = %+v, want asked", got)
+	}
+	if got := policy.Evaluate(applyCall("Domain", true), "prod"); got.Verdict != VerdictApprove {
+		t.Errorf("a dry run changes nothing and should be approved anywhere, got %+v", got)
+	}
+}
+
+func TestParseYesForAndValidate(t *testing.T) {
+	rule, err := ParseYesFor("tool=apply_manifest, kind=DNSRecordSet,kind=DNSZone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rule.Tools) != 1 || len(rule.Kinds) != 2 || rule.Action != PolicyApprove {
+		t.Errorf("parsed %+v", rule)
+	}
+	for _, bad := range []string{"", "DNSRecord", "kind=", "namespace=default"} {
+		if _, err := ParseYesFor(bad); err == nil {
+			t.Errorf("ParseYesFor(%q) succeeded", bad)
+		}
+	}
+	if err := (&Policy{Rules: []PolicyRule{{Action: "allow"}}}).Validate(); err == nil {
+		t.Error("Validate accepted an unknown action")
+	}
+}
+
+// scriptedLLM asks for one tool call, then answers.
+type scriptedLLM struct {
+	call  llm.ToolCall
+	calls int
+}
+
+func (s *scriptedLLM) Chat(ctx context.Context, systemPrompt string, messages []llm.Message, tools []llm.ToolDef) (llm.Message, error) {
+	return s.StreamChat(ctx, systemPrompt, messages, tools, nil)
+}
+
+func (s *scriptedLLM) StreamChat(ctx context.Context, systemPrompt string, messages []llm.Message, tools []llm.ToolDef, textOut io.Writer) (llm.Message, error) {
+	s.calls++
+	if s.calls == 1 {
+		return llm.Message{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{s.call}}, nil
+	}
+	if textOut != nil {
+		io.WriteString(textOut, "done")
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: "done"}, nil
+}
+
+func (s *scriptedLLM) Provider() string { return "test" }
+func (s *scriptedLLM) Model() string    { return "test" }
+
+func TestAgentEnforcesPolicyBeforeGate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		call    llm.ToolCall
+		wantRun bool
+		wantLog string
+	}{
+		{"denied", deleteCall("Project"), false, "delete_resource denied by policy"},
+		{"approved", applyCall("Domain", true), true, "apply_manifest approved by policy"},
+		{"asked", deleteCall("Domain"), false, "requires interactive mode"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ran := false
+			execute := func(ctx context.Context, args map[string]any) (string, error) {
+				ran = true
+				return "ok", nil
+			}
+			var errOut bytes.Buffer
+			agent := NewAgent(AgentOptions{
+				LLM: &scriptedLLM{call: tc.call},
+				Registry: NewRegistryOf(
+					Tool{Def: llm.ToolDef{Name: "apply_manifest"}, RequiresConfirm: true, Execute: execute},
+					Tool{Def: llm.ToolDef{Name: "delete_resource"}, RequiresConfirm: true, Execute: execute},
+				),
+				Out:     io.Discard,
+				ErrOut:  &errOut,
+				Policy:  mustPolicy(t, testPolicy),
+				Project: func() string { return "prod" },
+			})
+			if err := agent.Run(context.Background(), "go"); err != nil {
+				t.Fatal(err)
+			}
+			if ran != tc.wantRun {
+				t.Errorf("tool ran = %v, want %v", ran, tc.wantRun)
+			}
+			if !strings.Contains(errOut.String(), tc.wantLog) {
+				t.Errorf("stderr lacks %q:\n%s", tc.wantLog, errOut.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"go.datum.net/datumctl/internal/client"
+	"go.datum.net/datumctl/internal/console/data"
+)
+
+// dependentsTimeout bounds the scan for owner-referenced dependents shown in
+// a delete preview; types that are slow or fail to list are skipped.
+const dependentsTimeout = 15 * time.Second
+
+// previewApply dry-runs an apply_manifest call on the server with the same
+// field manager the tool uses, and returns the unified diff between the live
+// object and the result.
+func previewApply(ctx context.Context, factory *client.DatumCloudFactory, args map[string]any) (string, error) {
+	obj, err := parseYAMLManifest(stringArg(args, "yaml"))
+	if err != nil {
+		return "", fmt.Errorf("parse manifest: %w", err)
+	}
+	gvr, namespaced, err := resolveGVR(factory, obj.GetKind(), obj.GetAPIVersion())
+	if err != nil {
+		return "", err
+	}
+	dc, err := factory.DynamicClient()
+	if err != nil {
+		return "", fmt.Errorf("dynamic client: %w", err)
+	}
+	var res dynamic.ResourceInterface = dc.Resource(gvr)
+	if namespaced && obj.GetNamespace() != "" {
+		res = dc.Resource(gvr).Namespace(obj.GetNamespace())
+	}
+
+	fromLabel := "live"
+	live, err := res.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	switch {
+	case k8serrors.IsNotFound(err):
+		live, fromLabel = nil, "(does not exist)"
+	case err != nil:
+		return "", err
+	}
+
+	after, err := res.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{
+		FieldManager: "datumctl-ai",
+		Force:        true,
+		DryRun:       []string{metav1.DryRunAll},
+	})
+	if err != nil {
+		return "", fmt.Errorf("dry-run apply: %w", err)
+	}
+
+	diff, err := data.UnifiedDiff(cleanForPreview(live), cleanForPreview(after), fromLabel, "after apply")
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "No changes: the live object already matches the manifest.\n", nil
+	}
+	return diff, nil
+}
+
+// previewDelete shows the object a delete_resource call would remove, as a
+// diff to nothing, followed by the dependents that name it (directly or
+// transitively) in their ownerReferences and would be garbage-collected.
+func previewDelete(ctx context.Context, factory *client.DatumCloudFactory, args map[string]any) (string, error) {
+	kind := stringArg(args, "kind")
+	name := stringArg(args, "name")
+	namespace := stringArg(args, "namespace")
+
+	gvr, namespaced, err := resolveGVR(factory, kind, stringArg(args, "apiVersion"))
+	if err != nil {
+		return "", err
+	}
+	dc, err := factory.DynamicClient()
+	if err != nil {
+		return "", fmt.Errorf("dynamic client: %w", err)
+	}
+	var res dynamic.ResourceInterface = dc.Resource(gvr)
+	if namespaced && namespace != "" {
+		res = dc.Resource(gvr).Namespace(namespace)
+	}
+	obj, err := res.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	diff, err := data.UnifiedDiff(cleanForPreview(obj), nil, "live", "(deleted)")
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(diff)
+
+	dependents := findDependents(ctx, factory, dc, obj)
+	if len(dependents) > 0 {
+		b.WriteString("\nDependents that will be garbage-collected:\n")
+		for _, d := range dependents {
+			fmt.Fprintf(&b, "  %s\n", d)
+		}
+	}
+	return b.String(), nil
+}
+
+// findDependents lists every listable type in the owner's namespace (or
+// everywhere, for a cluster-scoped owner) and follows ownerReferences from
+// the owner's UID. It is best effort: on any failure it returns what it has.
+func findDependents(ctx context.Context, factory *client.DatumCloudFactory, dc dynamic.Interface, owner *unstructured.Unstructured) []string {
+	ctx, cancel := context.WithTimeout(ctx, dependentsTimeout)
+	defer cancel()
+
+	disc, err := factory.ToDiscoveryClient()
+	if err != nil {
+		return nil
+	}
+	lists, _ := disc.ServerPreferredResources()
+
+	// children maps an owner UID to the objects that reference it.
+	children := map[types.UID][]*unstructured.Unstructured{}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "list") {
+				continue
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			gvr := gv.WithResource(r.Name)
+			var items *unstructured.UnstructuredList
+			if r.Namespaced && owner.GetNamespace() != "" {
+				items, err = dc.Resource(gvr).Namespace(owner.GetNamespace()).List(ctx, metav1.ListOptions{})
+			} else {
+				items, err = dc.Resource(gvr).List(ctx, metav1.ListOptions{})
+			}
+			if err != nil {
+				continue
+			}
+			for i := range items.Items {
+				item := &items.Items[i]
+				for _, ref := range item.GetOwnerReferences() {
+					children[ref.UID] = append(children[ref.UID], item)
+				}
+			}
+		}
+	}
+
+	var out []string
+	seen := map[types.UID]bool{owner.GetUID(): true}
+	queue := []types.UID{owner.GetUID()}
+	for len(queue) > 0 {
+		uid := queue[0]
+		queue = queue[1:]
+		for _, child := range children[uid] {
+			if seen[child.GetUID()] {
+				continue
+			}
+			seen[child.GetUID()] = true
+			queue = append(queue, child.GetUID())
+			out = append(out, child.GetKind()+" "+qualifiedName(child.GetNamespace(), child.GetName()))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// cleanForPreview drops the fields that change on every write, so the
+// preview shows only what the call itself changes. A nil object stays nil.
+func cleanForPreview(obj *unstructured.Unstructured) map[string]any {
+	if obj == nil {
+		return nil
+	}
+	m := obj.DeepCopy().Object
+	delete(m, "status")
+	if meta, ok := m["metadata"].(map[string]any); ok {
+		for _, noisy := range []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp"} {
+			delete(meta, noisy)
+		}
+	}
+	return m
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -20,6 +21,9 @@ type Tool struct {
 	Def             llm.ToolDef
 	RequiresConfirm bool
 	Execute         func(ctx context.Context, args map[string]any) (string, error)
+	// Preview, if set, describes what a confirmed call would change (e.g. a
+	// dry-run diff). It is shown instead of the raw arguments when asking.
+	Preview func(ctx context.Context, args map[string]any) (string, error)
 }
 
 // Registry holds the set of tools available in an agentic session.
@@ -27,8 +31,10 @@ type Registry struct {
 	tools []Tool
 }
 
-// NewRegistry builds the full tool set backed by a DatumCloudFactory.
-func NewRegistry(factory *client.DatumCloudFactory) *Registry {
+// NewRegistry builds the full tool set backed by a DatumCloudFactory, with
+// the tools of the managed plugins installed in pluginsDir (usually
+// UserPluginsDir()). An empty pluginsDir offers no plugin tools.
+func NewRegistry(factory *client.DatumCloudFactory, pluginsDir string) *Registry {
 	r := &Registry{}
 
 	r.add(Tool{
@@ -170,7 +176,11 @@ func NewRegistry(factory *client.DatumCloudFactory) *Registry {
 					},
 					"limit": map[string]any{
 						"type":        "integer",
-						"description": "Maximum number of results (optional)",
+						"description": fmt.Sprintf("Maximum number of results (default %d)", defaultListLimit),
+					},
+					"continue": map[string]any{
+						"type":        "string",
+						"description": "Continue token from a previous call, to fetch the next page (optional)",
 					},
 				},
 				"required": []string{"kind"},
@@ -181,15 +191,7 @@ func NewRegistry(factory *client.DatumCloudFactory) *Registry {
 			apiVersion := stringArg(args, "apiVersion")
 			namespace := stringArg(args, "namespace")
 			labelSelector := stringArg(args, "labelSelector")
-			var limit int64
-			if v, ok := args["limit"]; ok {
-				switch n := v.(type) {
-				case float64:
-					limit = int64(n)
-				case int64:
-					limit = n
-				}
-			}
+			limit := intArg(args, "limit", defaultListLimit)
 
 			gvr, namespaced, err := resolveGVR(factory, kind, apiVersion)
 			if err != nil {
@@ -201,7 +203,7 @@ func NewRegistry(factory *client.DatumCloudFactory) *Registry {
 				return "", fmt.Errorf("dynamic client: %w", err)
 			}
 
-			opts := metav1.ListOptions{LabelSelector: labelSelector}
+			opts := metav1.ListOptions{LabelSelector: labelSelector, Continue: stringArg(args, "continue")}
 			if limit > 0 {
 				opts.Limit = limit
 			}
@@ -235,11 +237,14 @@ func NewRegistry(factory *client.DatumCloudFactory) *Registry {
 				return fmt.Sprintf("No %s resources found in namespace %q", gvr.Resource, namespace), nil
 			}
 
+			for i := range lst.Items {
+				trimForModel(lst.Items[i].Object, true)
+			}
 			b, err := syaml.Marshal(lst)
 			if err != nil {
 				return "", err
 			}
-			return string(b), nil
+			return string(b) + paginationHint(lst), nil
 		},
 	})
 
@@ -306,6 +311,7 @@ func NewRegistry(factory *client.DatumCloudFactory) *Registry {
 				return "", err
 			}
 
+			trimForModel(obj.Object, false)
 			if strings.ToLower(format) == "json" {
 				b, _ := json.MarshalIndent(obj.Object, "", "  ")
 				return string(b), nil
@@ -391,6 +397,9 @@ func NewRegistry(factory *client.DatumCloudFactory) *Registry {
 			},
 		},
 		RequiresConfirm: true,
+		Preview: func(ctx context.Context, args map[string]any) (string, error) {
+			return previewApply(ctx, factory, args)
+		},
 		Execute: func(ctx context.Context, args map[string]any) (string, error) {
 			rawYAML := stringArg(args, "yaml")
 			dryRun, _ := args["dryRun"].(bool)
@@ -457,6 +466,9 @@ func NewRegistry(factory *client.DatumCloudFactory) *Registry {
 			},
 		},
 		RequiresConfirm: true,
+		Preview: func(ctx context.Context, args map[string]any) (string, error) {
+			return previewDelete(ctx, factory, args)
+		},
 		Execute: func(ctx context.Context, args map[string]any) (string, error) {
 			kind := stringArg(args, "kind")
 			name := stringArg(args, "name")
@@ -533,6 +545,9 @@ func NewRegistry(factory *client.DatumCloudFactory) *Registry {
 		},
 	})
 
+	addInsightTools(r, factory)
+	addPluginTools(r, factory, pluginsDir)
+
 	return r
 }
 
@@ -557,9 +572,23 @@ func (r *Registry) Find(name string) (*Tool, bool) {
 
 func (r *Registry) add(t Tool) { r.tools = append(r.tools, t) }
 
+// Without returns a copy of r lacking the named tools.
+func (r *Registry) Without(names ...string) *Registry {
+	out := &Registry{}
+	for _, t := range r.tools {
+		if !slices.Contains(names, t.Def.Name) {
+			out.add(t)
+		}
+	}
+	return out
+}
+
 // NewEmptyRegistry returns a Registry with no tools, used when no context is set.
 func NewEmptyRegistry() *Registry { return &Registry{} }
 
+// NewRegistryOf returns a Registry holding exactly the given tools.
+func NewRegistryOf(tools ...Tool) *Registry { return &Registry{tools: tools} }
+
 // --- helpers ---
 
 func stringArg(args map[string]any, key string) string {
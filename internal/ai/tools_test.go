@@ -0,0 +1,17 @@
+package ai
+
+import "testing"
+
+func TestRegistryWithout(t *testing.T) {
+	full := NewRegistry(nil, "")
+	r := full.Without("change_context")
+	if _, ok := r.Find("change_context"); ok {
+		t.Error("change_context is still offered")
+	}
+	if _, ok := full.Find("change_context"); !ok {
+		t.Error("Without changed the registry it was called on")
+	}
+	if got, want := len(r.Defs()), len(full.Defs())-1; got != want {
+		t.Errorf("%d tools left, want %d", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+// addUsage counts a model call's usage toward the turn and the session.
+// Providers that report no usage leave both unchanged.
+func (a *Agent) addUsage(u *llm.Usage) {
+	if u == nil {
+		return
+	}
+	a.turnUsage.Add(*u)
+	a.sessionUsage.Add(*u)
+}
+
+// checkBudget fails once the session has used MaxSessionTokens. It is
+// checked before each model call, so the call that crosses the budget
+// completes and the next one is refused.
+func (a *Agent) checkBudget() error {
+	limit := a.opts.MaxSessionTokens
+	if limit <= 0 || a.sessionUsage.Total() < limit {
+		return nil
+	}
+	return fmt.Errorf("session token budget exhausted: %s of %s tokens used; start a new conversation or raise max_tokens_per_session",
+		formatTokens(a.sessionUsage.Total()), formatTokens(limit))
+}
+
+// SessionUsage returns the tokens used by every model call of the agent so
+// far, summaries included.
+func (a *Agent) SessionUsage() llm.Usage {
+	return a.sessionUsage
+}
+
+// UsageSummary describes the last turn's and the session's token usage, with
+// an estimated cost when the model's price is known, e.g.
+//
+//	turn 14.2k in (12.8k cached), 312 out · session 51.0k tokens, ~$0.04
+//
+// It returns "" when the provider reported no usage.
+func (a *Agent) UsageSummary() string {
+	turn, session := a.turnUsage, a.sessionUsage
+	if session.Total() == 0 {
+		return ""
+	}
+	var b strings.Builder
+	in := turn.InputTokens + turn.CacheReadTokens + turn.CacheWriteTokens
+	fmt.Fprintf(&b, "turn %s in", formatTokens(in))
+	if turn.CacheReadTokens > 0 {
+		fmt.Fprintf(&b, " (%s cached)", formatTokens(turn.CacheReadTokens))
+	}
+	fmt.Fprintf(&b, ", %s out · session %s tokens", formatTokens(turn.OutputTokens), formatTokens(session.Total()))
+	if cost, ok := llm.EstimateCost(a.opts.LLM.Provider(), a.opts.LLM.Model(), session); ok {
+		fmt.Fprintf(&b, ", %s", formatCost(cost))
+	}
+	return b.String()
+}
+
+// formatTokens abbreviates a token count: 312, 14.2k, 1.25M.
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.2fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// formatCost renders an estimated cost in US dollars.
+func formatCost(cost float64) string {
+	if cost < 0.01 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("~$%.2f", cost)
+}
@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+// meteredLLM answers every call and reports the same usage for each.
+type meteredLLM struct {
+	usage llm.Usage
+	calls int
+}
+
+func (m *meteredLLM) Chat(ctx context.Context, systemPrompt string, messages []llm.Message, tools []llm.ToolDef) (llm.Message, error) {
+	return m.StreamChat(ctx, systemPrompt, messages, tools, nil)
+}
+
+func (m *meteredLLM) StreamChat(ctx context.Context, systemPrompt string, messages []llm.Message, tools []llm.ToolDef, textOut io.Writer) (llm.Message, error) {
+	m.calls++
+	u := m.usage
+	return llm.Message{Role: llm.RoleAssistant, Content: "ok", Usage: &u}, nil
+}
+
+func (m *meteredLLM) Provider() string { return "anthropic" }
+func (m *meteredLLM) Model() string    { return "claude-sonnet-4-6" }
+
+func TestUsageSummary(t *testing.T) {
+	client := &meteredLLM{usage: llm.Usage{InputTokens: 1_200, OutputTokens: 300, CacheReadTokens: 13_000}}
+	agent := NewAgent(AgentOptions{LLM: client, Registry: NewEmptyRegistry(), Out: io.Discard, ErrOut: io.Discard})
+
+	if got := agent.UsageSummary(); got != "" {
+		t.Errorf("summary before any call = %q, want none", got)
+	}
+	agent.RunTurn(context.Background(), "one")
+	result := agent.RunTurn(context.Background(), "two")
+
+	if result.Usage != client.usage || result.SessionUsage.Total() != 2*client.usage.Total() {
+		t.Errorf("turn usage %+v, session %+v; want one call's and two calls'", result.Usage, result.SessionUsage)
+	}
+	want := "turn 14.2k in (13.0k cached), 300 out · session 29.0k tokens, ~$0.02"
+	if got := agent.UsageSummary(); got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+}
+
+func TestMaxSessionTokens(t *testing.T) {
+	client := &meteredLLM{usage: llm.Usage{InputTokens: 600, OutputTokens: 100}}
+	agent := NewAgent(AgentOptions{
+		LLM:              client,
+		Registry:         NewEmptyRegistry(),
+		MaxSessionTokens: 1_000,
+		Out:              io.Discard,
+		ErrOut:           io.Discard,
+	})
+
+	// The call that crosses the budget completes; the next one is refused.
+	for _, q := range []string{"one", "two"} {
+		if result := agent.RunTurn(context.Background(), q); result.Err != nil {
+			t.Fatalf("turn %q: %v", q, result.Err)
+		}
+	}
+	result := agent.RunTurn(context.Background(), "three")
+	if result.Err == nil || !strings.Contains(result.Err.Error(), "budget exhausted") {
+		t.Fatalf("err = %v, want the session budget exhausted", result.Err)
+	}
+	if client.calls != 2 {
+		t.Errorf("model called %d times, want 2", client.calls)
+	}
+
+	// A new or resumed conversation starts a new budget.
+	for name, reset := range map[string]func(){
+		"cleared": agent.ClearHistory,
+		"resumed": func() { agent.SetHistory([]llm.Message{{Role: llm.RoleUser, Content: "earlier"}}) },
+	} {
+		reset()
+		if got := agent.SessionUsage(); got.Total() != 0 {
+			t.Errorf("%s: session usage = %+v, want none", name, got)
+		}
+		if got := agent.UsageSummary(); got != "" {
+			t.Errorf("%s: summary = %q, want none", name, got)
+		}
+		if result := agent.RunTurn(context.Background(), "again"); result.Err != nil {
+			t.Errorf("%s: %v, want a new budget", name, result.Err)
+		}
+	}
+}
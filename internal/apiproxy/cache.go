@@ -0,0 +1,263 @@
+package apiproxy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheHeader marks responses the proxy answered from its cache ("hit") or
+// by sharing another client's identical in-flight request ("coalesced").
+const CacheHeader = "X-Datum-Proxy-Cache"
+
+const (
+	// maxCacheEntryBytes bounds a single cached body. Larger responses pass
+	// through uncached.
+	maxCacheEntryBytes = 8 << 20
+	// maxCacheEntries bounds the number of cached responses.
+	maxCacheEntries = 1024
+)
+
+// cachedResponse is a complete response to a GET.
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// flight is a GET in progress that identical requests wait on.
+type flight struct {
+	done chan struct{}
+	// resp is the completed response, or nil if it could not be shared (too
+	// large, or the client went away mid-response).
+	resp *cachedResponse
+}
+
+// responseCache serves repeated GETs of the same list or object from memory
+// for a short TTL, and coalesces identical concurrent GETs into one upstream
+// request. Watches and other streams are never cached or coalesced: they
+// pass straight through, with no duration limit. Any mutating request
+// empties the cache, before it is forwarded and again once it completes, so
+// a client never reads back a response older than its own write.
+type responseCache struct {
+	next    http.Handler
+	ttl     time.Duration
+	metrics *Metrics
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	flights map[string]*flight
+	// generation advances on every invalidation; a response fetched across
+	// one is shared with waiting requests but not cached.
+	generation uint64
+}
+
+func newResponseCache(next http.Handler, ttl time.Duration, metrics *Metrics) *responseCache {
+	return &responseCache{
+		next:    next,
+		ttl:     ttl,
+		metrics: metrics,
+		now:     time.Now,
+		entries: map[string]*cachedResponse{},
+		flights: map[string]*flight{},
+	}
+}
+
+func (c *responseCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		c.next.ServeHTTP(w, r)
+		return
+	case r.Method != http.MethodGet:
+		c.invalidate()
+		defer c.invalidate()
+		c.next.ServeHTTP(w, r)
+		return
+	case isStreamRequest(r):
+		c.next.ServeHTTP(w, r)
+		return
+	}
+
+	key := cacheKey(r)
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		if c.now().Before(entry.expires) {
+			c.mu.Unlock()
+			c.metrics.cacheResult("hit")
+			serveCached(w, entry, "hit")
+			return
+		}
+		delete(c.entries, key)
+	}
+	if f, ok := c.flights[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-r.Context().Done():
+			return
+		}
+		if f.resp != nil {
+			c.metrics.cacheResult("coalesced")
+			serveCached(w, f.resp, "coalesced")
+			return
+		}
+		// The leader's response could not be shared; fetch our own.
+		c.metrics.cacheResult("miss")
+		c.next.ServeHTTP(w, r)
+		return
+	}
+	f := &flight{done: make(chan struct{})}
+	c.flights[key] = f
+	generation := c.generation
+	c.mu.Unlock()
+
+	c.metrics.cacheResult("miss")
+	tee := &teeResponse{ResponseWriter: w}
+	// The reverse proxy aborts the handler with a panic when the upstream
+	// cuts a response short; a partial body must never be shared.
+	aborted := true
+	defer func() {
+		c.mu.Lock()
+		delete(c.flights, key)
+		if resp := tee.complete(r); resp != nil && !aborted {
+			f.resp = resp
+			if resp.status == http.StatusOK && generation == c.generation && !isStreamResponse(resp.header) {
+				resp.expires = c.now().Add(c.ttl)
+				c.store(key, resp)
+			}
+		}
+		c.mu.Unlock()
+		close(f.done)
+	}()
+	c.next.ServeHTTP(tee, r)
+	aborted = false
+}
+
+// store adds an entry, evicting expired ones when full. Called with mu held.
+func (c *responseCache) store(key string, resp *cachedResponse) {
+	if len(c.entries) >= maxCacheEntries {
+		now := c.now()
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			return
+		}
+	}
+	c.entries[key] = resp
+}
+
+func (c *responseCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+}
+
+// cacheKey identifies interchangeable GETs: same session, path, and query,
+// and the same representation negotiated (a Table and a plain list of the
+// same resource differ, as do gzip and identity bodies).
+func cacheKey(r *http.Request) string {
+	return strings.Join([]string{
+		r.Header.Get(SessionHeader),
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		r.Header.Get("Accept"),
+		r.Header.Get("Accept-Encoding"),
+	}, "\x00")
+}
+
+// isStreamRequest reports whether r asks for an open-ended response: a
+// watch, or followed logs.
+func isStreamRequest(r *http.Request) bool {
+	if isWatch(r) {
+		return true
+	}
+	switch r.URL.Query().Get("follow") {
+	case "true", "1":
+		return true
+	}
+	return false
+}
+
+func isStreamResponse(h http.Header) bool {
+	return strings.HasPrefix(h.Get("Content-Type"), "text/event-stream")
+}
+
+func serveCached(w http.ResponseWriter, resp *cachedResponse, how string) {
+	h := w.Header()
+	for name, values := range resp.header {
+		h[name] = values
+	}
+	h.Set("Content-Length", strconv.Itoa(len(resp.body)))
+	h.Set(CacheHeader, how)
+	w.WriteHeader(resp.status)
+	_, _ = w.Write(resp.body)
+}
+
+// teeResponse passes a response through to the client while keeping a copy,
+// up to maxCacheEntryBytes, to share and cache.
+type teeResponse struct {
+	http.ResponseWriter
+
+	wroteHeader bool
+	status      int
+	header      http.Header
+	body        []byte
+	overflow    bool
+}
+
+func (t *teeResponse) WriteHeader(code int) {
+	if code >= 100 && code < 200 {
+		t.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		t.status = code
+		t.header = t.Header().Clone()
+	}
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *teeResponse) Write(b []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+	n, err := t.ResponseWriter.Write(b)
+	if !t.overflow {
+		if len(t.body)+n > maxCacheEntryBytes {
+			t.overflow, t.body = true, nil
+		} else {
+			t.body = append(t.body, b[:n]...)
+		}
+	}
+	return n, err
+}
+
+// Flush and Unwrap keep the wrapped writer streamable, as for loggedResponse.
+func (t *teeResponse) Flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (t *teeResponse) Unwrap() http.ResponseWriter { return t.ResponseWriter }
+
+// complete returns the captured response, or nil if it cannot be shared:
+// too large, cut short by the client going away, or synthesized by the
+// proxy (a token refresh failure must be retried, not replayed).
+func (t *teeResponse) complete(r *http.Request) *cachedResponse {
+	if !t.wroteHeader || t.overflow || r.Context().Err() != nil || t.header.Get(proxyErrorHeader) != "" {
+		return nil
+	}
+	header := t.header.Clone()
+	header.Del("Content-Length")
+	return &cachedResponse{status: t.status, header: header, body: t.body}
+}
@@ -0,0 +1,144 @@
+package apiproxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCachingProxy(t *testing.T, upstream *recordingUpstream, ttl time.Duration) (*httptest.Server, *responseCache) {
+	t.Helper()
+	var cache *responseCache
+	server, err := New(Config{
+		Upstream:    parseURL(t, upstream.server.URL),
+		TokenSource: staticToken("tok"),
+		CacheTTL:    ttl,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Find the cache in the chain so the test can move its clock.
+	var h http.Handler = server.Handler()
+	for cache == nil {
+		switch v := h.(type) {
+		case *requestLogger:
+			h = v.next
+		case *hostValidator:
+			h = v.next
+		case *responseCache:
+			cache = v
+		default:
+			t.Fatalf("no response cache in the handler chain (reached %T)", h)
+		}
+	}
+	proxy := httptest.NewServer(server.Handler())
+	t.Cleanup(proxy.Close)
+	return proxy, cache
+}
+
+func TestCacheServesRepeatedGETsUntilTTL(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	proxy, cache := newCachingProxy(t, upstream, 5*time.Second)
+	start := time.Now()
+	var elapsed atomic.Int64
+	cache.now = func() time.Time { return start.Add(time.Duration(elapsed.Load())) }
+
+	const zones = "/apis/networking.datumapis.com/v1alpha/dnszones"
+	resp, body := fetch(t, http.MethodGet, proxy.URL+zones, "")
+	if resp.Header.Get(CacheHeader) != "" || body != "ok" {
+		t.Fatalf("first GET = %q (cache %q), want an upstream answer", body, resp.Header.Get(CacheHeader))
+	}
+	resp, body = fetch(t, http.MethodGet, proxy.URL+zones, "")
+	// "coalesced" if it raced the first response's bookkeeping.
+	if resp.Header.Get(CacheHeader) == "" || body != "ok" {
+		t.Errorf("second GET = %q (cache %q), want a cache hit", body, resp.Header.Get(CacheHeader))
+	}
+	fetch(t, http.MethodGet, proxy.URL+zones+"?limit=5", "")
+	if got := upstream.count(); got != 2 {
+		t.Errorf("upstream saw %d requests, want 2 (a different query is a different entry)", got)
+	}
+
+	elapsed.Store(int64(5 * time.Second))
+	fetch(t, http.MethodGet, proxy.URL+zones, "")
+	if got := upstream.count(); got != 3 {
+		t.Errorf("upstream saw %d requests, want 3 after the TTL expired", got)
+	}
+}
+
+func TestCacheInvalidatedByMutations(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	proxy, _ := newCachingProxy(t, upstream, time.Minute)
+
+	const zones = "/apis/networking.datumapis.com/v1alpha/namespaces/default/dnszones"
+	fetch(t, http.MethodGet, proxy.URL+zones, "")
+	fetch(t, http.MethodGet, proxy.URL+zones, "")
+	fetch(t, http.MethodDelete, proxy.URL+zones+"/a", "")
+	resp, _ := fetch(t, http.MethodGet, proxy.URL+zones, "")
+	if resp.Header.Get(CacheHeader) != "" {
+		t.Errorf("GET after a DELETE came from the cache (%q)", resp.Header.Get(CacheHeader))
+	}
+	if got := upstream.count(); got != 3 {
+		t.Errorf("upstream saw %d requests, want 3 (GET, DELETE, GET)", got)
+	}
+}
+
+func TestCacheNeverCachesWatchesOrErrors(t *testing.T) {
+	upstream := newRecordingUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		io.WriteString(w, "ok")
+	})
+	proxy, _ := newCachingProxy(t, upstream, time.Minute)
+
+	for range 2 {
+		fetch(t, http.MethodGet, proxy.URL+"/apis/foo?watch=true", "")
+		fetch(t, http.MethodGet, proxy.URL+"/apis/foo?fail=1", "")
+	}
+	if got := upstream.count(); got != 4 {
+		t.Errorf("upstream saw %d requests, want 4: watches and errors are never cached", got)
+	}
+}
+
+func TestCacheCoalescesConcurrentGETs(t *testing.T) {
+	const clients = 5
+	arrived := make(chan struct{}, clients)
+	release := make(chan struct{})
+	upstream := newRecordingUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+		io.WriteString(w, "ok")
+	})
+	proxy, _ := newCachingProxy(t, upstream, time.Minute)
+
+	results := make(chan string, clients)
+	var wg sync.WaitGroup
+	for range clients {
+		wg.Go(func() {
+			resp, body := fetch(t, http.MethodGet, proxy.URL+"/apis/foo", "")
+			results <- body + ":" + resp.Header.Get(CacheHeader)
+		})
+	}
+	// Clients that arrive after the leader finishes get a cache hit instead;
+	// either way, only the leader reaches the upstream.
+	<-arrived
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	counts := map[string]int{}
+	for r := range results {
+		counts[r]++
+	}
+	if got := upstream.count(); got != 1 {
+		t.Errorf("upstream saw %d requests, want 1 for %d identical concurrent GETs (results %v)", got, clients, counts)
+	}
+	if counts["ok:"] != 1 || counts["ok:coalesced"]+counts["ok:hit"] != clients-1 {
+		t.Errorf("results = %v, want one upstream answer and the rest shared", counts)
+	}
+}
@@ -0,0 +1,313 @@
+package apiproxy
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// FaultHeader marks every response a fault rule touched — delayed, failed,
+// or cut short — with the name of the rule, so a test can never mistake an
+// injected fault for real upstream behavior.
+const FaultHeader = "X-Datum-Proxy-Fault"
+
+// Faults makes the proxy misbehave on purpose, for testing how clients cope
+// with a slow or failing API. Each request is checked against Rules in
+// order, and the first that matches (and fires, see Probability) is applied.
+type Faults struct {
+	Rules []FaultRule `json:"faults"`
+}
+
+// FaultRule matches requests like a PolicyRule, optionally narrowed by path,
+// and says what to do to them. Latency combines with any one of Status,
+// Conflict, or DropWatchAfter.
+type FaultRule struct {
+	// Name identifies the rule in logs and the FaultHeader. It defaults to
+	// "rule N", counting from 1.
+	Name string `json:"name,omitempty"`
+
+	PolicyRule
+
+	// Paths are glob patterns (path.Match syntax) on the request path, as
+	// the upstream will see it: after any mount or /as/ prefix is stripped.
+	Paths []string `json:"paths,omitempty"`
+
+	// Probability is the fraction of matching requests the rule fires on,
+	// from 0 to 1. Zero means always.
+	Probability float64 `json:"probability,omitempty"`
+
+	// Times caps how often the rule fires over the life of the proxy. Zero
+	// means no limit.
+	Times int `json:"times,omitempty"`
+
+	// Latency delays the request before it is forwarded.
+	Latency metav1.Duration `json:"latency,omitempty"`
+
+	// Status answers with a synthesized error instead of forwarding, e.g.
+	// 429, 500, or 503.
+	Status int `json:"status,omitempty"`
+
+	// RetryAfter sets a Retry-After header on a Status response.
+	RetryAfter metav1.Duration `json:"retryAfter,omitempty"`
+
+	// Conflict answers with a 409 Conflict, as the API does when an update
+	// carries a stale resourceVersion.
+	Conflict bool `json:"conflict,omitempty"`
+
+	// DropWatchAfter cuts a watch connection after this many events,
+	// without a clean end of stream, as a dropped connection would.
+	DropWatchAfter int `json:"dropWatchAfter,omitempty"`
+}
+
+// ParseFaults parses a YAML or JSON fault document, rejecting unknown fields
+// and rules that could not do what they say.
+func ParseFaults(data []byte) (*Faults, error) {
+	var f Faults
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, err
+	}
+	if len(f.Rules) == 0 {
+		return nil, fmt.Errorf("no fault rules")
+	}
+	for i := range f.Rules {
+		rule := &f.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", rule.Name, err)
+		}
+	}
+	return &f, nil
+}
+
+func (r *FaultRule) validate() error {
+	for _, verb := range r.Verbs {
+		if !slices.Contains(knownVerbs, verb) {
+			return fmt.Errorf("unknown verb %q (want one of %s)", verb, strings.Join(knownVerbs, ", "))
+		}
+	}
+	for _, pattern := range r.Paths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+		}
+	}
+	actions := 0
+	for _, set := range []bool{r.Status != 0, r.Conflict, r.DropWatchAfter != 0} {
+		if set {
+			actions++
+		}
+	}
+	switch {
+	case actions > 1:
+		return fmt.Errorf("set at most one of status, conflict, and dropWatchAfter")
+	case actions == 0 && r.Latency.Duration == 0:
+		return fmt.Errorf("set latency, status, conflict, or dropWatchAfter")
+	case r.Status != 0 && (r.Status < 400 || r.Status > 599):
+		return fmt.Errorf("status %d is not an error status (want 400-599)", r.Status)
+	case r.RetryAfter.Duration != 0 && r.Status == 0:
+		return fmt.Errorf("retryAfter needs a status")
+	case r.RetryAfter.Duration < 0 || r.Latency.Duration < 0:
+		return fmt.Errorf("durations cannot be negative")
+	case r.DropWatchAfter < 0 || r.Times < 0:
+		return fmt.Errorf("dropWatchAfter and times cannot be negative")
+	case r.Probability < 0 || r.Probability > 1:
+		return fmt.Errorf("probability %v is not between 0 and 1", r.Probability)
+	}
+	return nil
+}
+
+func (r *FaultRule) matches(req *http.Request, info requestInfo) bool {
+	if !r.PolicyRule.matches(info) {
+		return false
+	}
+	if len(r.Paths) > 0 && !slices.ContainsFunc(r.Paths, func(pattern string) bool {
+		ok, _ := path.Match(pattern, req.URL.Path)
+		return ok
+	}) {
+		return false
+	}
+	return r.DropWatchAfter == 0 || info.Verb == "watch"
+}
+
+// faultInjector applies Faults in front of the reverse proxy or replayer.
+// It is shared by every session, so Times counts across all of them.
+// Faults are logged even in quiet mode: nobody should have to guess whether
+// a failure was real.
+type faultInjector struct {
+	faults  *Faults
+	log     *eventLog
+	metrics *Metrics
+	// random returns a number in [0, 1); replaced in tests.
+	random func() float64
+	sleep  func(*http.Request, time.Duration)
+
+	mu    sync.Mutex
+	fired []int
+}
+
+func newFaultInjector(faults *Faults, log *eventLog, metrics *Metrics) *faultInjector {
+	return &faultInjector{
+		faults:  faults,
+		log:     log,
+		metrics: metrics,
+		random:  rand.Float64,
+		sleep:   sleepUnlessCanceled,
+		fired:   make([]int, len(faults.Rules)),
+	}
+}
+
+func (f *faultInjector) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule := f.pick(r)
+		if rule == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set(FaultHeader, rule.Name)
+		if d := rule.Latency.Duration; d > 0 {
+			f.record(r, rule, "latency", "delayed "+d.String())
+			f.sleep(r, d)
+			if r.Context().Err() != nil {
+				return
+			}
+		}
+		switch {
+		case rule.Conflict:
+			f.record(r, rule, "conflict", "answered 409 Conflict")
+			writeStatus(w, http.StatusConflict, "Conflict", fmt.Sprintf(
+				"Operation cannot be fulfilled: the object has been modified; please apply your changes to the latest version and try again (fault injected by datumctl api proxy, %s)", rule.Name))
+		case rule.Status != 0:
+			action := fmt.Sprintf("answered %d", rule.Status)
+			if d := rule.RetryAfter.Duration; d > 0 {
+				seconds := int((d + time.Second - 1) / time.Second)
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				action += fmt.Sprintf(" with Retry-After %ds", seconds)
+			}
+			f.record(r, rule, "status", action)
+			writeStatus(w, rule.Status, statusReason(rule.Status), fmt.Sprintf(
+				"fault injected by datumctl api proxy (%s)", rule.Name))
+		case rule.DropWatchAfter > 0:
+			next.ServeHTTP(&watchDropper{ResponseWriter: w, remaining: rule.DropWatchAfter, drop: func() {
+				f.record(r, rule, "watch_drop", fmt.Sprintf("dropped the watch after %d events", rule.DropWatchAfter))
+			}}, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// pick returns the first rule that matches r and fires, counting it
+// against the rule's Times.
+func (f *faultInjector) pick(r *http.Request) *FaultRule {
+	info := parseRequestInfo(r)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.faults.Rules {
+		rule := &f.faults.Rules[i]
+		if !rule.matches(r, info) || (rule.Times > 0 && f.fired[i] >= rule.Times) {
+			continue
+		}
+		if rule.Probability > 0 && f.random() >= rule.Probability {
+			continue
+		}
+		f.fired[i]++
+		return rule
+	}
+	return nil
+}
+
+func (f *faultInjector) record(r *http.Request, rule *FaultRule, kind, action string) {
+	f.metrics.faultInjected(rule.Name, kind)
+	path := redactedRequestPath(r)
+	f.log.write("fault", logFields{"method": r.Method, "path": path, "rule": rule.Name, "kind": kind, "message": action},
+		fmt.Sprintf("%-4s %s FAULT (%s): %s", r.Method, path, rule.Name, action))
+}
+
+func sleepUnlessCanceled(r *http.Request, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+}
+
+// statusReason returns the Kubernetes Status reason the API uses for code.
+func statusReason(code int) string {
+	switch code {
+	case http.StatusBadRequest:
+		return "BadRequest"
+	case http.StatusUnauthorized:
+		return "Unauthorized"
+	case http.StatusForbidden:
+		return "Forbidden"
+	case http.StatusNotFound:
+		return "NotFound"
+	case http.StatusConflict:
+		return "Conflict"
+	case http.StatusGone:
+		return "Expired"
+	case http.StatusUnprocessableEntity:
+		return "Invalid"
+	case http.StatusTooManyRequests:
+		return "TooManyRequests"
+	case http.StatusGatewayTimeout:
+		return "Timeout"
+	case http.StatusServiceUnavailable:
+		return "ServiceUnavailable"
+	case http.StatusInternalServerError:
+		return "InternalError"
+	}
+	return strings.ReplaceAll(http.StatusText(code), " ", "")
+}
+
+// watchDropper passes a watch stream through until it has carried the
+// given number of events (newline-delimited JSON), then aborts the
+// connection mid-stream, with no terminating chunk.
+type watchDropper struct {
+	http.ResponseWriter
+	remaining int
+	drop      func()
+}
+
+func (d *watchDropper) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			n, err := d.ResponseWriter.Write(b)
+			return written + n, err
+		}
+		n, err := d.ResponseWriter.Write(b[:i+1])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[i+1:]
+		if d.remaining--; d.remaining == 0 {
+			d.Flush()
+			d.drop()
+			panic(http.ErrAbortHandler)
+		}
+	}
+	return written, nil
+}
+
+func (d *watchDropper) Flush() {
+	if flusher, ok := d.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (d *watchDropper) Unwrap() http.ResponseWriter { return d.ResponseWriter }
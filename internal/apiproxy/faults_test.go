@@ -0,0 +1,210 @@
+package apiproxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const zonesPath = "/apis/networking.datumapis.com/v1alpha/namespaces/default/dnszones"
+
+func mustParseFaults(t *testing.T, doc string) *Faults {
+	t.Helper()
+	faults, err := ParseFaults([]byte(doc))
+	if err != nil {
+		t.Fatalf("ParseFaults: %v", err)
+	}
+	return faults
+}
+
+func TestParseFaults(t *testing.T) {
+	faults := mustParseFaults(t, `
+faults:
+  - name: slow lists
+    verbs: [list]
+    latency: 2s
+  - status: 503
+    retryAfter: 5s
+    probability: 0.1
+`)
+	if faults.Rules[0].Name != "slow lists" || faults.Rules[1].Name != "rule 2" {
+		t.Errorf("rule names = %q, %q", faults.Rules[0].Name, faults.Rules[1].Name)
+	}
+	if faults.Rules[0].Latency.Duration != 2*time.Second || faults.Rules[1].RetryAfter.Duration != 5*time.Second {
+		t.Errorf("parsed %+v", faults.Rules)
+	}
+
+	for _, bad := range []string{
+		"faults: []\n",
+		"faults:\n  - verbs: [list]\n",                      // no action
+		"faults:\n  - status: 503\n    conflict: true\n",    // two actions
+		"faults:\n  - status: 200\n",                        // not an error
+		"faults:\n  - conflict: true\n    retryAfter: 1s\n", // retryAfter without status
+		"faults:\n  - status: 500\n    probability: 2\n",    // probability out of range
+		"faults:\n  - status: 500\n    verbs: [remove]\n",   // unknown verb
+		"faults:\n  - status: 500\n    paths: ['[']\n",      // bad glob
+		"faults:\n  - status: 500\n    latnecy: 1s\n",       // unknown field
+	} {
+		if _, err := ParseFaults([]byte(bad)); err == nil {
+			t.Errorf("ParseFaults(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestFaultStatusWithRetryAfter(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	var logBuf bytes.Buffer
+	proxy := newTestProxy(t, upstream.server.URL, func(c *Config) {
+		c.LogWriter = &logBuf
+		c.Quiet = true
+		c.Faults = mustParseFaults(t, `
+faults:
+  - name: overloaded
+    verbs: [list]
+    status: 503
+    retryAfter: 1500ms
+`)
+	})
+
+	resp, body := fetch(t, http.MethodGet, proxy.URL+zonesPath, "")
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2 (rounded up to whole seconds)", got)
+	}
+	if got := resp.Header.Get(FaultHeader); got != "overloaded" {
+		t.Errorf("%s = %q, want the rule name", FaultHeader, got)
+	}
+	if resp.Header.Get(proxyErrorHeader) != "true" {
+		t.Errorf("an injected error must carry %s", proxyErrorHeader)
+	}
+	if status := decodeStatus(t, strings.NewReader(body)); status.Reason != "ServiceUnavailable" {
+		t.Errorf("reason = %q, want ServiceUnavailable", status.Reason)
+	}
+	if upstream.count() != 0 {
+		t.Errorf("upstream saw %d requests; an injected error must not be forwarded", upstream.count())
+	}
+	if !strings.Contains(logBuf.String(), "FAULT (overloaded): answered 503 with Retry-After 2s") {
+		t.Errorf("fault not logged in quiet mode:\n%s", logBuf.String())
+	}
+
+	// Other verbs pass through untouched.
+	resp, _ = fetch(t, http.MethodGet, proxy.URL+zonesPath+"/a", "")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(FaultHeader) != "" {
+		t.Errorf("get = %d (fault %q), want an untouched 200", resp.StatusCode, resp.Header.Get(FaultHeader))
+	}
+}
+
+func TestFaultConflictOnPath(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	proxy := newTestProxy(t, upstream.server.URL, func(c *Config) {
+		c.Faults = mustParseFaults(t, `
+faults:
+  - verbs: [update]
+    paths: ["`+zonesPath+`/flaky"]
+    conflict: true
+    times: 1
+`)
+	})
+
+	resp, body := fetch(t, http.MethodPut, proxy.URL+zonesPath+"/flaky", "{}")
+	if resp.StatusCode != http.StatusConflict || decodeStatus(t, strings.NewReader(body)).Reason != "Conflict" {
+		t.Fatalf("first update = %d %s, want a 409 Conflict", resp.StatusCode, body)
+	}
+	// times: 1 — the retry goes through, as does any other object.
+	for _, name := range []string{"flaky", "steady"} {
+		if resp, _ := fetch(t, http.MethodPut, proxy.URL+zonesPath+"/"+name, "{}"); resp.StatusCode != http.StatusOK {
+			t.Errorf("update %s = %d, want 200", name, resp.StatusCode)
+		}
+	}
+	if upstream.count() != 2 {
+		t.Errorf("upstream saw %d requests, want 2", upstream.count())
+	}
+}
+
+func TestFaultLatency(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	proxy := newTestProxy(t, upstream.server.URL, func(c *Config) {
+		c.Faults = mustParseFaults(t, "faults:\n  - latency: 200ms\n")
+	})
+
+	start := time.Now()
+	resp, body := fetch(t, http.MethodGet, proxy.URL+zonesPath, "")
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("request took %s, want at least the injected 200ms", elapsed)
+	}
+	if resp.StatusCode != http.StatusOK || body != "ok" || resp.Header.Get(FaultHeader) != "rule 1" {
+		t.Errorf("delayed request = %d %q (fault %q), want the upstream answer, marked", resp.StatusCode, body, resp.Header.Get(FaultHeader))
+	}
+}
+
+func TestFaultDropsWatchAfterEvents(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		for i := range 5 {
+			fmt.Fprintf(w, `{"type":"ADDED","object":{"metadata":{"name":"zone-%d"}}}`+"\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer upstream.Close()
+	proxy := newTestProxy(t, upstream.URL, func(c *Config) {
+		c.Faults = mustParseFaults(t, "faults:\n  - dropWatchAfter: 2\n")
+	})
+
+	// A plain list is not a watch, so the rule leaves it alone.
+	if resp, _ := fetch(t, http.MethodGet, proxy.URL+zonesPath, ""); resp.Header.Get(FaultHeader) != "" {
+		t.Error("dropWatchAfter applied to a list")
+	}
+
+	resp, err := http.Get(proxy.URL + zonesPath + "?watch=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err == nil {
+		t.Error("watch ended cleanly; a dropped connection must end without a terminating chunk")
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("watch carried %d events before the drop, want 2:\n%s", lines, data)
+	}
+}
+
+func TestFaultProbabilityAndTimes(t *testing.T) {
+	faults := mustParseFaults(t, `
+faults:
+  - name: sometimes
+    status: 500
+    probability: 0.5
+  - name: twice
+    status: 429
+    times: 2
+`)
+	injector := newFaultInjector(faults, newEventLog(nil, ""), nil)
+	rolls := []float64{0.2, 0.7, 0.9, 0.8}
+	injector.random = func() float64 {
+		r := rolls[0]
+		rolls = rolls[1:]
+		return r
+	}
+
+	var got []string
+	for range 4 {
+		rule := injector.pick(httptest.NewRequest(http.MethodGet, zonesPath, nil))
+		if rule == nil {
+			got = append(got, "none")
+			continue
+		}
+		got = append(got, rule.Name)
+	}
+	want := []string{"sometimes", "twice", "twice", "none"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("picked %v, want %v", got, want)
+	}
+}
@@ -1,13 +1,13 @@
 package apiproxy
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
 	"strconv"
 	"strings"
 
@@ -19,34 +19,34 @@ import (
 // Upstream responses — including 401/403 — pass through without it.
 const proxyErrorHeader = "X-Datum-Proxy-Error"
 
-// rewriteTo returns the ReverseProxy Rewrite func: the inbound path and
-// query are forwarded verbatim, joined under the upstream root (which may
-// carry a control-plane path prefix), with the upstream host as the
-// outbound Host header.
-func rewriteTo(upstream *url.URL) func(*httputil.ProxyRequest) {
-	return func(pr *httputil.ProxyRequest) {
-		pr.SetURL(upstream)
-		pr.Out.Host = upstream.Host
-		// Never forward a locally supplied credential upstream — the
-		// oauth2.Transport injects the real one. Deleting rather than
-		// overwriting also keeps stale tokens baked into client configs
-		// from half-working.
-		pr.Out.Header.Del("Authorization")
-		// No SetXForwarded: the upstream gains nothing from knowing
-		// about 127.0.0.1.
-	}
+// rewrite is the ReverseProxy Rewrite func: the inbound path (already
+// stripped of its mount prefix) and query are forwarded verbatim, joined
+// under the upstream root the router chose (which may carry a control-plane
+// path prefix), with the upstream host as the outbound Host header.
+func rewrite(pr *httputil.ProxyRequest) {
+	upstream := upstreamFor(pr.In)
+	pr.SetURL(upstream)
+	pr.Out.Host = upstream.Host
+	// Never forward a locally supplied credential upstream — the
+	// oauth2.Transport injects the real one. Deleting rather than
+	// overwriting also keeps stale tokens baked into client configs
+	// from half-working.
+	pr.Out.Header.Del("Authorization")
+	// No SetXForwarded: the upstream gains nothing from knowing
+	// about 127.0.0.1.
 }
 
 // hostValidator rejects any request whose Host header is not a local
 // address, before the upstream sees anything — the DNS-rebinding defense: a
 // malicious page that rebinds its hostname to 127.0.0.1 still sends that
-// hostname in Host.
+// hostname in Host. Requests over a unix socket are exempt: browsers cannot
+// reach one, and socket clients fill in Host arbitrarily.
 type hostValidator struct {
 	next http.Handler
 }
 
 func (h *hostValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if !allowedHost(r.Host) {
+	if !overUnixSocket(r) && !allowedHost(r.Host) {
 		writeStatus(w, http.StatusForbidden, "Forbidden",
 			fmt.Sprintf("host %q is not a local address; the datumctl proxy only serves local clients", r.Host))
 		return
@@ -54,6 +54,31 @@ func (h *hostValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.next.ServeHTTP(w, r)
 }
 
+// overUnixSocket reports whether r arrived on a unix domain socket listener.
+func overUnixSocket(r *http.Request) bool {
+	_, ok := r.Context().Value(http.LocalAddrContextKey).(*net.UnixAddr)
+	return ok
+}
+
+// tokenValidator rejects requests that do not carry the proxy's local bearer
+// token. It runs before rewrite, which strips the header so the local
+// token never reaches the upstream.
+type tokenValidator struct {
+	next  http.Handler
+	token string
+}
+
+func (v *tokenValidator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(v.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="datumctl api proxy"`)
+		writeStatus(w, http.StatusUnauthorized, "Unauthorized",
+			"missing or invalid proxy token; send the token printed when the proxy started as 'Authorization: Bearer <token>'")
+		return
+	}
+	v.next.ServeHTTP(w, r)
+}
+
 // allowedHost reports whether hostport is localhost, 127.0.0.1, or [::1],
 // with or without a port.
 func allowedHost(hostport string) bool {
@@ -118,6 +143,7 @@ func writeStatus(w http.ResponseWriter, code int, reason, message string) {
 		Reason:     reason,
 		Message:    message,
 	})
+	noteProxyError(w, reason)
 	h := w.Header()
 	h.Set("Content-Type", "application/json")
 	h.Set("Content-Length", strconv.Itoa(len(body)))
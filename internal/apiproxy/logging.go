@@ -1,10 +1,12 @@
 package apiproxy
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,23 +21,82 @@ var redactedQueryKeys = map[string]bool{
 	"authorization": true,
 }
 
+// LogFormat selects how the proxy writes its log.
+type LogFormat string
+
+const (
+	// LogFormatText writes human-readable lines (the default).
+	LogFormatText LogFormat = "text"
+	// LogFormatJSON writes one JSON object per line, for log collectors.
+	LogFormatJSON LogFormat = "json"
+)
+
+// eventLog is the proxy's log. Every line goes through it — request lines,
+// denials, token refresh failures — so the two formats never mix in one
+// stream.
+type eventLog struct {
+	out  io.Writer
+	json bool
+
+	mu sync.Mutex
+}
+
+func newEventLog(out io.Writer, format LogFormat) *eventLog {
+	if out == nil {
+		out = io.Discard
+	}
+	return &eventLog{out: out, json: format == LogFormatJSON}
+}
+
+// Write lets the reverse proxy's own error log go through the event log.
+func (l *eventLog) Write(p []byte) (int, error) {
+	message := strings.TrimRight(string(p), "\n")
+	l.write("error", logFields{"message": message}, message)
+	return len(p), nil
+}
+
+// logFields are the fields of a JSON log line besides "time" and "event".
+type logFields map[string]any
+
+// write logs one event: text as a timestamped line, or fields as a JSON
+// object tagged with event.
+func (l *eventLog) write(event string, fields logFields, text string) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.json {
+		fmt.Fprintf(l.out, "%s %s\n", now.Format(timeFormat), text)
+		return
+	}
+	entry := logFields{"time": now.UTC().Format(time.RFC3339Nano), "event": event}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	line, _ := json.Marshal(entry)
+	_, _ = l.out.Write(append(line, '\n'))
+}
+
 // requestLogger writes one line per request — and for streaming responses,
 // one when headers arrive and one on stream end — recording method, path,
 // status, duration, and bytes. Headers and token values are never logged.
+// It also feeds the request metrics, which keep counting in quiet mode.
 type requestLogger struct {
-	next  http.Handler
-	out   io.Writer
-	quiet bool
+	next    http.Handler
+	log     *eventLog
+	quiet   bool
+	metrics *Metrics
 }
 
 func (l *requestLogger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if l.quiet {
+	if l.quiet && l.metrics == nil {
 		l.next.ServeHTTP(w, r)
 		return
 	}
 	logged := &loggedResponse{
 		ResponseWriter: w,
-		out:            l.out,
+		log:            l.log,
+		quiet:          l.quiet,
+		metrics:        l.metrics,
 		method:         r.Method,
 		path:           redactedRequestPath(r),
 		isHead:         r.Method == http.MethodHead,
@@ -50,10 +111,15 @@ func (l *requestLogger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // redactedRequestPath returns the request path plus query with token-shaped
 // query values redacted, preserving parameter order.
 func redactedRequestPath(r *http.Request) string {
-	rawQuery := r.URL.RawQuery
-	if rawQuery == "" {
+	if r.URL.RawQuery == "" {
 		return r.URL.Path
 	}
+	return r.URL.Path + "?" + redactQuery(r.URL.RawQuery)
+}
+
+// redactQuery redacts token-shaped values in a raw query, preserving
+// parameter order.
+func redactQuery(rawQuery string) string {
 	pairs := strings.Split(rawQuery, "&")
 	for i, pair := range pairs {
 		key, _, found := strings.Cut(pair, "=")
@@ -61,7 +127,7 @@ func redactedRequestPath(r *http.Request) string {
 			pairs[i] = key + "=REDACTED"
 		}
 	}
-	return r.URL.Path + "?" + strings.Join(pairs, "&")
+	return strings.Join(pairs, "&")
 }
 
 // loggedResponse observes the response as it is written. A response with no
@@ -69,16 +135,20 @@ func redactedRequestPath(r *http.Request) string {
 // headers arrive and another with totals on completion.
 type loggedResponse struct {
 	http.ResponseWriter
-	out    io.Writer
-	method string
-	path   string
-	isHead bool
-	start  time.Time
+	log     *eventLog
+	quiet   bool
+	metrics *Metrics
+	method  string
+	path    string
+	isHead  bool
+	start   time.Time
 
 	wroteHeader bool
 	streaming   bool
 	status      int
 	bytes       int64
+	// errorReason is the Status reason of a proxy-synthesized response.
+	errorReason string
 }
 
 func (l *loggedResponse) WriteHeader(code int) {
@@ -92,8 +162,12 @@ func (l *loggedResponse) WriteHeader(code int) {
 		l.status = code
 		l.streaming = l.detectStreaming(code)
 		if l.streaming {
-			fmt.Fprintf(l.out, "%s %-4s %s %d …streaming\n",
-				time.Now().Format(timeFormat), l.method, l.path, code)
+			l.metrics.streamStarted()
+			if !l.quiet {
+				l.log.write("stream_start", logFields{
+					"method": l.method, "path": l.path, "status": code, "streaming": true,
+				}, fmt.Sprintf("%-4s %s %d …streaming", l.method, l.path, code))
+			}
 		}
 	}
 	l.ResponseWriter.WriteHeader(code)
@@ -118,6 +192,8 @@ func (l *loggedResponse) Flush() {
 
 func (l *loggedResponse) Unwrap() http.ResponseWriter { return l.ResponseWriter }
 
+func (l *loggedResponse) noteProxyError(reason string) { l.errorReason = reason }
+
 // detectStreaming reports whether the response is stream-shaped: no declared
 // length on a status/method that can carry a body.
 func (l *loggedResponse) detectStreaming(code int) bool {
@@ -133,9 +209,43 @@ func (l *loggedResponse) logCompletion() {
 		// The handler wrote nothing; net/http sends an implicit 200.
 		status = http.StatusOK
 	}
-	fmt.Fprintf(l.out, "%s %-4s %s %d %s %s\n",
-		time.Now().Format(timeFormat), l.method, l.path, status,
-		formatDuration(time.Since(l.start)), formatBytes(l.bytes))
+	elapsed := time.Since(l.start)
+	l.metrics.observeRequest(l.method, status, elapsed, l.streaming, l.errorReason)
+	if l.quiet {
+		return
+	}
+	fields := logFields{
+		"method":     l.method,
+		"path":       l.path,
+		"status":     status,
+		"bytes":      l.bytes,
+		"durationMs": elapsed.Milliseconds(),
+		"streaming":  l.streaming,
+	}
+	if l.errorReason != "" {
+		fields["errorReason"] = l.errorReason
+	}
+	if cache := l.Header().Get(CacheHeader); cache != "" {
+		fields["cache"] = cache
+	}
+	l.log.write("request", fields, fmt.Sprintf("%-4s %s %d %s %s",
+		l.method, l.path, status, formatDuration(elapsed), formatBytes(l.bytes)))
+}
+
+// noteProxyError tells the request logger, through any wrapping writers, the
+// reason of the Status the proxy is about to synthesize.
+func noteProxyError(w http.ResponseWriter, reason string) {
+	for {
+		if noter, ok := w.(interface{ noteProxyError(string) }); ok {
+			noter.noteProxyError(reason)
+			return
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = unwrapper.Unwrap()
+	}
 }
 
 func formatDuration(d time.Duration) string {
@@ -2,6 +2,7 @@ package apiproxy
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -119,6 +120,56 @@ func TestStreamingLogsHeaderArrivalAndCompletion(t *testing.T) {
 	}
 }
 
+func TestJSONLogFormat(t *testing.T) {
+	source := &fakeTokenSource{token: "stale", expired: true, refreshErr: errTokenDead}
+	upstream := newRecordingUpstream(t, nil)
+	var buf bytes.Buffer
+	handler := newLoggingProxy(t, upstream.server.URL, &buf, false, func(c *Config) {
+		c.LogFormat = LogFormatJSON
+		c.TokenSource = source
+	})
+
+	doRecorded(t, handler, "http://127.0.0.1:8001/apis/foo?access_token=supersecret")
+
+	lines := logLines(&buf)
+	if len(lines) != 2 {
+		t.Fatalf("log lines = %q, want a refresh failure and a request line", lines)
+	}
+	entries := make([]map[string]any, len(lines))
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &entries[i]); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		if _, ok := entries[i]["time"]; !ok {
+			t.Errorf("line %q has no time", line)
+		}
+	}
+	if entries[0]["event"] != "token_refresh_failed" {
+		t.Errorf("first event = %v, want token_refresh_failed", entries[0]["event"])
+	}
+	request := entries[1]
+	for key, want := range map[string]any{
+		"event":       "request",
+		"method":      "GET",
+		"path":        "/apis/foo?access_token=REDACTED",
+		"status":      float64(http.StatusBadGateway),
+		"streaming":   false,
+		"errorReason": "ProxyAuthenticationFailed",
+	} {
+		if request[key] != want {
+			t.Errorf("request %s = %v, want %v", key, request[key], want)
+		}
+	}
+	for _, key := range []string{"bytes", "durationMs"} {
+		if _, ok := request[key]; !ok {
+			t.Errorf("request line has no %s", key)
+		}
+	}
+	if strings.Contains(buf.String(), "supersecret") {
+		t.Errorf("JSON log leaks a token: %s", buf.String())
+	}
+}
+
 func TestQuietSuppressesRequestLines(t *testing.T) {
 	upstream := newRecordingUpstream(t, nil)
 	var buf bytes.Buffer
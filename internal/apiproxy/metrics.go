@@ -0,0 +1,133 @@
+package apiproxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "datumctl_api_proxy"
+
+// Metrics is the proxy's Prometheus instrumentation. It uses a registry of
+// its own, so the endpoint exposes the proxy and its process and nothing
+// else. A nil *Metrics records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	duration        *prometheus.HistogramVec
+	activeStreams   prometheus.Gauge
+	proxyErrors     *prometheus.CounterVec
+	refreshFailures *prometheus.CounterVec
+	cacheRequests   *prometheus.CounterVec
+	faults          *prometheus.CounterVec
+}
+
+// NewMetrics creates the proxy's metrics.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Requests served, by method and status code.",
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of non-streaming requests. Streams are open-ended and counted in active_streams instead.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		activeStreams: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "active_streams",
+			Help:      "Streaming responses (watches, server-sent events) currently open.",
+		}),
+		proxyErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "proxy_errors_total",
+			Help:      "Responses synthesized by the proxy rather than the upstream, by Status reason.",
+		}, []string{"reason"}),
+		refreshFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "token_refresh_failures_total",
+			Help:      "Failed token refresh attempts, by session (empty for a single-session proxy).",
+		}, []string{"session"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_requests_total",
+			Help:      "Cacheable GETs by result (hit, coalesced, miss), when --cache-ttl is set.",
+		}, []string{"result"}),
+		faults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "faults_injected_total",
+			Help:      "Faults injected by --faults rules, by rule and kind (latency, status, conflict, watch_drop).",
+		}, []string{"rule", "kind"}),
+	}
+	m.registry.MustRegister(
+		m.requests, m.duration, m.activeStreams, m.proxyErrors, m.refreshFailures, m.cacheRequests, m.faults,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) streamStarted() {
+	if m != nil {
+		m.activeStreams.Inc()
+	}
+}
+
+func (m *Metrics) observeRequest(method string, status int, elapsed time.Duration, streaming bool, errorReason string) {
+	if m == nil {
+		return
+	}
+	method = methodLabel(method)
+	m.requests.WithLabelValues(method, strconv.Itoa(status)).Inc()
+	if streaming {
+		m.activeStreams.Dec()
+	} else {
+		m.duration.WithLabelValues(method).Observe(elapsed.Seconds())
+	}
+	if errorReason != "" {
+		m.proxyErrors.WithLabelValues(errorReason).Inc()
+	}
+}
+
+func (m *Metrics) tokenRefreshFailed(session string) {
+	if m != nil {
+		m.refreshFailures.WithLabelValues(session).Inc()
+	}
+}
+
+func (m *Metrics) cacheResult(result string) {
+	if m != nil {
+		m.cacheRequests.WithLabelValues(result).Inc()
+	}
+}
+
+func (m *Metrics) faultInjected(rule, kind string) {
+	if m != nil {
+		m.faults.WithLabelValues(rule, kind).Inc()
+	}
+}
+
+// methodLabel bounds the method label to the standard methods, so a client
+// sending arbitrary ones cannot grow the series without limit.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
@@ -0,0 +1,92 @@
+package apiproxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestMetricsCountRequestsStreamsAndFailures(t *testing.T) {
+	release := make(chan struct{})
+	upstream := newRecordingUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("watch") == "true" {
+			io.WriteString(w, `{"type":"ADDED"}`+"\n")
+			w.(http.Flusher).Flush()
+			<-release
+			return
+		}
+		io.WriteString(w, "ok")
+	})
+	metrics := NewMetrics()
+	var logs bytes.Buffer
+	handler := newLoggingProxy(t, upstream.server.URL, &logs, true, func(c *Config) {
+		c.Metrics = metrics
+		c.ReadOnly = true
+	})
+
+	doRecorded(t, handler, "http://127.0.0.1:8001/apis/foo")
+	denied := httptest.NewRequest(http.MethodDelete, "http://127.0.0.1:8001/apis/foo", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), denied)
+
+	proxy := httptest.NewServer(handler)
+	defer proxy.Close()
+	resp, err := http.Get(proxy.URL + "/apis/foo?watch=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newLineReader(resp.Body).next(t) // the stream is open once an event arrives
+	if out := scrape(t, metrics); !strings.Contains(out, "datumctl_api_proxy_active_streams 1") {
+		t.Errorf("metrics during a watch lack active_streams 1:\n%s", out)
+	}
+	close(release)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	proxy.Close() // waits for the handler, and so its completion metrics
+
+	out := scrape(t, metrics)
+	for _, want := range []string{
+		`datumctl_api_proxy_requests_total{code="200",method="GET"} 2`,
+		`datumctl_api_proxy_requests_total{code="403",method="DELETE"} 1`,
+		`datumctl_api_proxy_proxy_errors_total{reason="Forbidden"} 1`,
+		`datumctl_api_proxy_request_duration_seconds_count{method="GET"} 1`,
+		`datumctl_api_proxy_active_streams 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics lack %s:\n%s", want, out)
+		}
+	}
+}
+
+func TestMetricsCountTokenRefreshFailures(t *testing.T) {
+	source := &fakeTokenSource{token: "stale", expired: true, refreshErr: errTokenDead}
+	upstream := newRecordingUpstream(t, nil)
+	metrics := NewMetrics()
+	var logs bytes.Buffer
+	handler := newLoggingProxy(t, upstream.server.URL, &logs, true, func(c *Config) {
+		c.Metrics = metrics
+		c.TokenSource = source
+	})
+	for range 3 {
+		doRecorded(t, handler, "http://127.0.0.1:8001/apis/foo")
+	}
+	out := scrape(t, metrics)
+	// One refresh attempt per cooldown window, however many requests fail.
+	for _, want := range []string{
+		`datumctl_api_proxy_token_refresh_failures_total{session=""} 1`,
+		`datumctl_api_proxy_proxy_errors_total{reason="ProxyAuthenticationFailed"} 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics lack %s:\n%s", want, out)
+		}
+	}
+}
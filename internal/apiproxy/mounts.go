@@ -0,0 +1,112 @@
+package apiproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Mount routes local requests under Prefix to Upstream, with the prefix
+// removed: with Prefix "/p/web", GET /p/web/apis/x is forwarded as
+// GET <Upstream>/apis/x.
+type Mount struct {
+	// Prefix is a local path prefix such as "/p/web". It matches whole path
+	// segments only; "" or "/" matches every request.
+	Prefix string
+
+	// Upstream is the root requests under Prefix are forwarded to, as for
+	// Config.Upstream.
+	Upstream *url.URL
+}
+
+// NormalizeMountPrefix cleans a mount prefix: a leading slash, no trailing
+// slash, "/" for the root.
+func NormalizeMountPrefix(prefix string) (string, error) {
+	if !strings.HasPrefix(prefix, "/") {
+		return "", fmt.Errorf("mount prefix %q must start with /", prefix)
+	}
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		return "/", nil
+	}
+	for _, segment := range strings.Split(prefix[1:], "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("mount prefix %q has an empty or relative path segment", prefix)
+		}
+	}
+	return prefix, nil
+}
+
+type upstreamContextKey struct{}
+
+// router picks the mount for each request, strips its prefix, and records
+// the mount's upstream for the reverse proxy's Rewrite. It runs before the
+// policy, so rules see the path the upstream will see.
+type router struct {
+	next   http.Handler
+	mounts []Mount // longest prefix first
+}
+
+func newRouter(next http.Handler, mounts []Mount) *router {
+	sorted := append([]Mount(nil), mounts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].Prefix) > len(sorted[j].Prefix) })
+	return &router{next: next, mounts: sorted}
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, m := range rt.mounts {
+		rest, ok := stripMountPrefix(r.URL.Path, m.Prefix)
+		if !ok {
+			continue
+		}
+		out := r.WithContext(context.WithValue(r.Context(), upstreamContextKey{}, m.Upstream))
+		if rest != r.URL.Path {
+			u := *r.URL
+			u.Path = rest
+			u.RawPath = ""
+			if r.URL.RawPath != "" {
+				if rawRest, ok := stripMountPrefix(r.URL.RawPath, m.Prefix); ok {
+					u.RawPath = rawRest
+				}
+			}
+			out.URL = &u
+		}
+		rt.next.ServeHTTP(w, out)
+		return
+	}
+	writeStatus(w, http.StatusNotFound, "NotFound",
+		fmt.Sprintf("no proxy mount serves %s; mounted prefixes: %s", r.URL.Path, rt.prefixes()))
+}
+
+func (rt *router) prefixes() string {
+	names := make([]string, 0, len(rt.mounts))
+	for _, m := range rt.mounts {
+		names = append(names, m.Prefix)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// stripMountPrefix removes prefix from path on a segment boundary.
+func stripMountPrefix(path, prefix string) (string, bool) {
+	if prefix == "" || prefix == "/" {
+		return path, true
+	}
+	rest, ok := strings.CutPrefix(path, prefix)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+		return "", false
+	}
+	if rest == "" {
+		rest = "/"
+	}
+	return rest, true
+}
+
+// upstreamFor returns the upstream the router chose for r.
+func upstreamFor(r *http.Request) *url.URL {
+	u, _ := r.Context().Value(upstreamContextKey{}).(*url.URL)
+	return u
+}
@@ -0,0 +1,135 @@
+package apiproxy
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRequestInfo(t *testing.T) {
+	const cp = "/apis/resourcemanager.miloapis.com/v1alpha1/projects/p1/control-plane"
+	tests := []struct {
+		method string
+		path   string
+		want   requestInfo
+	}{
+		{"GET", "/apis", requestInfo{Verb: "get"}},
+		{"GET", "/version", requestInfo{Verb: "get"}},
+		{"GET", "/apis/networking.datumapis.com/v1alpha", requestInfo{Verb: "get", APIGroup: "networking.datumapis.com"}},
+		{"GET", "/api/v1/namespaces", requestInfo{IsResourceRequest: true, Verb: "list", Resource: "namespaces"}},
+		{"GET", "/api/v1/namespaces/default", requestInfo{IsResourceRequest: true, Verb: "get", Resource: "namespaces", Namespace: "default", Name: "default"}},
+		{"GET", "/api/v1/namespaces/default/configmaps?watch=true", requestInfo{IsResourceRequest: true, Verb: "watch", Resource: "configmaps", Namespace: "default"}},
+		{"POST", cp + "/apis/networking.datumapis.com/v1alpha/namespaces/default/dnszones", requestInfo{IsResourceRequest: true, Verb: "create", APIGroup: "networking.datumapis.com", Resource: "dnszones", Namespace: "default"}},
+		{"PATCH", cp + "/apis/networking.datumapis.com/v1alpha/namespaces/default/dnszones/z/status", requestInfo{IsResourceRequest: true, Verb: "patch", APIGroup: "networking.datumapis.com", Resource: "dnszones", Subresource: "status", Namespace: "default", Name: "z"}},
+		{"DELETE", "/apis/resourcemanager.miloapis.com/v1alpha1/organizations", requestInfo{IsResourceRequest: true, Verb: "deletecollection", APIGroup: "resourcemanager.miloapis.com", Resource: "organizations"}},
+		{"DELETE", "/apis/resourcemanager.miloapis.com/v1alpha1/projects/p1", requestInfo{IsResourceRequest: true, Verb: "delete", APIGroup: "resourcemanager.miloapis.com", Resource: "projects", Name: "p1"}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := parseRequestInfo(req); got != tt.want {
+			t.Errorf("%s %s:\n got %+v\nwant %+v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy([]byte(`
+allow:
+  - verbs: [get, list, watch]
+deny:
+  - resources: [secrets]
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy: %v", err)
+	}
+	if len(p.Allow) != 1 || len(p.Deny) != 1 {
+		t.Errorf("parsed %+v", p)
+	}
+
+	for _, bad := range []string{
+		"allow:\n  - verb: [get]\n",    // unknown field
+		"deny:\n  - verbs: [remove]\n", // unknown verb
+	} {
+		if _, err := ParsePolicy([]byte(bad)); err == nil {
+			t.Errorf("ParsePolicy(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestPolicyDecide(t *testing.T) {
+	p := &Policy{
+		Allow: []PolicyRule{
+			{Verbs: []string{"get", "list", "watch"}},
+			{Groups: []string{"networking.datumapis.com"}, Resources: []string{"dnszones"}, Namespaces: []string{"default"}},
+		},
+		Deny: []PolicyRule{
+			{Resources: []string{"secrets"}},
+			{Verbs: []string{"*"}, Resources: []string{"dnszones/status"}},
+		},
+	}
+	const dns = "/apis/networking.datumapis.com/v1alpha/namespaces/"
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{"GET", "/apis", true},
+		{"GET", "/openapi/v3", true},
+		{"GET", dns + "default/dnszones", true},
+		{"POST", dns + "default/dnszones", true},
+		{"POST", dns + "other/dnszones", false},
+		{"PUT", dns + "default/dnszones/z/status", false},
+		{"GET", "/api/v1/namespaces/default/secrets", false},
+		{"DELETE", "/apis/resourcemanager.miloapis.com/v1alpha1/projects/p1", false},
+	}
+	for _, tt := range tests {
+		got, why := p.decide(parseRequestInfo(httptest.NewRequest(tt.method, tt.path, nil)))
+		if got != tt.want {
+			t.Errorf("%s %s: allowed = %v (%s), want %v", tt.method, tt.path, got, why, tt.want)
+		}
+	}
+}
+
+func TestReadOnlyRejectsMutations(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	var logs bytes.Buffer
+	proxy := newTestProxy(t, upstream.server.URL, func(c *Config) {
+		c.ReadOnly = true
+		c.Quiet = true
+		c.LogWriter = &logs
+	})
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		req, _ := http.NewRequest(method, proxy.URL+"/apis/networking.datumapis.com/v1alpha/namespaces/default/dnszones/z", strings.NewReader("{}"))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", method, resp.StatusCode)
+		}
+		if resp.Header.Get(proxyErrorHeader) != "true" {
+			t.Errorf("%s: denial must carry the proxy error marker", method)
+		}
+		if status := decodeStatus(t, resp.Body); status.Reason != "Forbidden" {
+			t.Errorf("%s: Status reason = %q, want Forbidden", method, status.Reason)
+		}
+		resp.Body.Close()
+	}
+	if n := upstream.count(); n != 0 {
+		t.Fatalf("upstream saw %d request(s); denied requests must never reach it", n)
+	}
+	if got := strings.Count(logs.String(), "denied:"); got != 4 {
+		t.Errorf("logged %d denials in quiet mode, want 4:\n%s", got, logs.String())
+	}
+
+	resp, err := http.Get(proxy.URL + "/apis/networking.datumapis.com/v1alpha/namespaces/default/dnszones?watch=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("watch: status = %d, want 200", resp.StatusCode)
+	}
+}
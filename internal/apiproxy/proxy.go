@@ -49,9 +49,14 @@ const (
 type Config struct {
 	// Upstream is the proxy root every request is forwarded under: the
 	// endpoint root by default, or a control-plane URL whose path prefix
-	// local request paths are joined onto.
+	// local request paths are joined onto. Exactly one of Upstream and
+	// Mounts must be set.
 	Upstream *url.URL
 
+	// Mounts serves several upstreams from one listener, each under its own
+	// local path prefix. Requests outside every prefix get a synthesized 404.
+	Mounts []Mount
+
 	// TokenSource supplies the bearer token injected into each outbound
 	// request (typically authutil.GetTokenSourceForUser).
 	TokenSource oauth2.TokenSource
@@ -63,33 +68,84 @@ type Config struct {
 	// Nil discards log output.
 	LogWriter io.Writer
 
+	// LogFormat selects text (the default) or JSON log lines.
+	LogFormat LogFormat
+
 	// Quiet suppresses per-request log lines. Token refresh failures are
 	// still logged, once per refresh attempt.
 	Quiet bool
-}
 
-// Server is a configured proxy engine. Callers either mount Handler on a
-// server of their own or hand a listener to Serve.
-type Server struct {
-	handler    http.Handler
-	httpServer *http.Server
+	// Metrics, when set, is updated with request counts, latencies, active
+	// streams, and token refresh failures (see NewMetrics).
+	Metrics *Metrics
+
+	// LocalToken, when non-empty, is a bearer token every local client must
+	// present in its Authorization header. Requests without it are rejected
+	// before they reach the upstream; the header is stripped either way.
+	LocalToken string
+
+	// ReadOnly rejects every request other than GET and HEAD (which covers
+	// watches) with a synthesized 403.
+	ReadOnly bool
+
+	// Policy, when set, restricts requests by verb, API group, resource,
+	// and namespace. It is evaluated after ReadOnly.
+	Policy *Policy
+
+	// Sessions serves several datumctl sessions from one listener, selected
+	// per request (see SessionHeader). When set, Upstream, Mounts,
+	// TokenSource, and TLSClientConfig must be empty: each session carries
+	// its own.
+	Sessions []Session
+
+	// CacheTTL, when positive, caches successful GET responses for this long
+	// and coalesces identical concurrent GETs into one upstream request.
+	// Watches are never cached, and any mutating request empties the cache.
+	CacheTTL time.Duration
+
+	// Recorder, when set, writes every forwarded request and its response to
+	// a fixture directory (see NewRecorder).
+	Recorder *Recorder
+
+	// Replay, when set, answers every request from recorded fixtures instead
+	// of an upstream. Upstream, Mounts, TokenSource, TLSClientConfig,
+	// Sessions, and Recorder must then be empty: a replaying proxy needs no
+	// credentials and makes no outbound connections.
+	Replay *Replayer
+
+	// Faults, when set, injects latency, errors, conflicts, and dropped
+	// watches into requests that pass the policy, right in front of the
+	// reverse proxy (or replayer). It cannot be combined with Recorder, so
+	// fixtures never capture an injected fault.
+	Faults *Faults
 }
 
-// New builds a proxy engine from cfg.
-func New(cfg Config) (*Server, error) {
-	if cfg.Upstream == nil {
-		return nil, fmt.Errorf("apiproxy: Upstream is required")
+// newSessionHandler builds the handler chain for one session: mount routing,
+// policy enforcement, and a reverse proxy with the session's own token
+// source and upstream connection pool.
+func newSessionHandler(sess Session, cfg Config, events *eventLog, faults *faultInjector) (http.Handler, error) {
+	mounts := sess.Mounts
+	switch {
+	case sess.Upstream == nil && len(mounts) == 0:
+		return nil, fmt.Errorf("apiproxy: Upstream or Mounts is required")
+	case sess.Upstream != nil && len(mounts) > 0:
+		return nil, fmt.Errorf("apiproxy: only one of Upstream and Mounts may be set")
+	case sess.Upstream != nil:
+		mounts = []Mount{{Prefix: "/", Upstream: sess.Upstream}}
 	}
-	if cfg.Upstream.Scheme == "" || cfg.Upstream.Host == "" {
-		return nil, fmt.Errorf("apiproxy: Upstream must be an absolute URL, got %q", cfg.Upstream)
+	seen := map[string]bool{}
+	for _, m := range mounts {
+		if m.Upstream == nil || m.Upstream.Scheme == "" || m.Upstream.Host == "" {
+			return nil, fmt.Errorf("apiproxy: Upstream must be an absolute URL, got %q", m.Upstream)
+		}
+		if seen[m.Prefix] {
+			return nil, fmt.Errorf("apiproxy: duplicate mount prefix %q", m.Prefix)
+		}
+		seen[m.Prefix] = true
 	}
-	if cfg.TokenSource == nil {
+	if sess.TokenSource == nil {
 		return nil, fmt.Errorf("apiproxy: TokenSource is required")
 	}
-	logWriter := cfg.LogWriter
-	if logWriter == nil {
-		logWriter = io.Discard
-	}
 
 	upstreamTransport := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
@@ -98,27 +154,110 @@ func New(cfg Config) (*Server, error) {
 			KeepAlive: 30 * time.Second,
 		}).DialContext,
 		ForceAttemptHTTP2:     true,
-		TLSClientConfig:       cfg.TLSClientConfig,
+		TLSClientConfig:       sess.TLSClientConfig,
 		TLSHandshakeTimeout:   tlsHandshakeTimeout,
 		ResponseHeaderTimeout: responseHeaderTimeout,
 		IdleConnTimeout:       90 * time.Second,
 	}
 
+	tokenSource := newCooldownTokenSource(sess.TokenSource, refreshCooldown, events)
+	tokenSource.session = sess.Name
+	tokenSource.metrics = cfg.Metrics
+
 	proxy := &httputil.ReverseProxy{
-		Rewrite: rewriteTo(cfg.Upstream),
+		Rewrite: rewrite,
 		Transport: &oauth2.Transport{
-			Source: newCooldownTokenSource(cfg.TokenSource, refreshCooldown, logWriter),
+			Source: tokenSource,
 			Base:   upstreamTransport,
 		},
 		// Flush every upstream write to the client immediately: unbuffered
 		// streaming (watch, SSE, chunked transfer) is the point of the proxy.
 		FlushInterval: -1,
 		ErrorHandler:  handleProxyError,
-		ErrorLog:      log.New(logWriter, "", 0),
+		ErrorLog:      log.New(events, "", 0),
+	}
+
+	var handler http.Handler = proxy
+	if faults != nil {
+		handler = faults.wrap(handler)
+	}
+	if cfg.ReadOnly || cfg.Policy != nil {
+		handler = &policyEnforcer{next: handler, readOnly: cfg.ReadOnly, policy: cfg.Policy, log: events}
+	}
+	return newRouter(handler, mounts), nil
+}
+
+// Server is a configured proxy engine. Callers either mount Handler on a
+// server of their own or hand a listener to Serve.
+type Server struct {
+	handler    http.Handler
+	httpServer *http.Server
+}
+
+// New builds a proxy engine from cfg.
+func New(cfg Config) (*Server, error) {
+	switch cfg.LogFormat {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		return nil, fmt.Errorf("apiproxy: unknown LogFormat %q", cfg.LogFormat)
+	}
+	events := newEventLog(cfg.LogWriter, cfg.LogFormat)
+	var faults *faultInjector
+	if cfg.Faults != nil {
+		if cfg.Recorder != nil {
+			return nil, fmt.Errorf("apiproxy: Faults cannot be combined with a Recorder")
+		}
+		faults = newFaultInjector(cfg.Faults, events, cfg.Metrics)
 	}
 
-	var handler http.Handler = &hostValidator{next: proxy}
-	handler = &requestLogger{next: handler, out: logWriter, quiet: cfg.Quiet}
+	var handler http.Handler
+	switch {
+	case cfg.Replay != nil:
+		if cfg.Upstream != nil || len(cfg.Mounts) > 0 || cfg.TokenSource != nil || cfg.TLSClientConfig != nil ||
+			len(cfg.Sessions) > 0 || cfg.Recorder != nil {
+			return nil, fmt.Errorf("apiproxy: Replay cannot be combined with an upstream, sessions, or a Recorder")
+		}
+		handler = cfg.Replay
+		if faults != nil {
+			handler = faults.wrap(handler)
+		}
+		if cfg.ReadOnly || cfg.Policy != nil {
+			handler = &policyEnforcer{next: handler, readOnly: cfg.ReadOnly, policy: cfg.Policy, log: events}
+		}
+	case len(cfg.Sessions) == 0:
+		h, err := newSessionHandler(Session{
+			Upstream:        cfg.Upstream,
+			Mounts:          cfg.Mounts,
+			TokenSource:     cfg.TokenSource,
+			TLSClientConfig: cfg.TLSClientConfig,
+		}, cfg, events, faults)
+		if err != nil {
+			return nil, err
+		}
+		handler = h
+	default:
+		if cfg.Upstream != nil || len(cfg.Mounts) > 0 || cfg.TokenSource != nil || cfg.TLSClientConfig != nil {
+			return nil, fmt.Errorf("apiproxy: set either Sessions or Upstream/Mounts/TokenSource/TLSClientConfig, not both")
+		}
+		selector, err := newSessionSelector(cfg, events, faults)
+		if err != nil {
+			return nil, err
+		}
+		handler = selector
+	}
+
+	if cfg.Recorder != nil {
+		handler = cfg.Recorder.wrap(handler, events)
+	}
+
+	if cfg.CacheTTL > 0 {
+		handler = newResponseCache(handler, cfg.CacheTTL, cfg.Metrics)
+	}
+	if cfg.LocalToken != "" {
+		handler = &tokenValidator{next: handler, token: cfg.LocalToken}
+	}
+	handler = &hostValidator{next: handler}
+	handler = &requestLogger{next: handler, log: events, quiet: cfg.Quiet, metrics: cfg.Metrics}
 
 	return &Server{
 		handler: handler,
@@ -131,8 +270,10 @@ func New(cfg Config) (*Server, error) {
 	}, nil
 }
 
-// Handler returns the proxy handler: host validation, request logging, and
-// the reverse proxy itself.
+// Handler returns the proxy handler: host validation, local token checks,
+// caching, recording, session selection, mount routing, policy enforcement,
+// fault injection, request logging, and the reverse proxy (or replayer)
+// itself.
 func (s *Server) Handler() http.Handler { return s.handler }
 
 // Serve accepts connections on l until Shutdown is called, applying
@@ -161,16 +302,19 @@ func (e *tokenRefreshError) Unwrap() error { return e.err }
 type cooldownTokenSource struct {
 	source   oauth2.TokenSource
 	cooldown time.Duration
-	out      io.Writer
+	log      *eventLog
 	now      func() time.Time
+	// session names the session in log lines of a multi-session proxy.
+	session string
+	metrics *Metrics
 
 	mu       sync.Mutex
 	lastErr  *tokenRefreshError
 	failedAt time.Time
 }
 
-func newCooldownTokenSource(source oauth2.TokenSource, cooldown time.Duration, out io.Writer) *cooldownTokenSource {
-	return &cooldownTokenSource{source: source, cooldown: cooldown, out: out, now: time.Now}
+func newCooldownTokenSource(source oauth2.TokenSource, cooldown time.Duration, log *eventLog) *cooldownTokenSource {
+	return &cooldownTokenSource{source: source, cooldown: cooldown, log: log, now: time.Now}
 }
 
 // Token implements oauth2.TokenSource.
@@ -191,8 +335,15 @@ func (c *cooldownTokenSource) Token() (*oauth2.Token, error) {
 		c.failedAt = c.now()
 		// Logged here, once per refresh attempt, rather than once per
 		// request in the error handler.
-		fmt.Fprintf(c.out, "%s token refresh failed: %s\n",
-			c.now().Format(timeFormat), strings.ReplaceAll(err.Error(), "\n", " — "))
+		c.metrics.tokenRefreshFailed(c.session)
+		message := strings.ReplaceAll(err.Error(), "\n", " — ")
+		fields := logFields{"message": message}
+		forSession := ""
+		if c.session != "" {
+			fields["session"] = c.session
+			forSession = " for session " + c.session
+		}
+		c.log.write("token_refresh_failed", fields, fmt.Sprintf("token refresh failed%s: %s", forSession, message))
 		return nil, c.lastErr
 	}
 	return token, nil
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -114,13 +115,14 @@ func staticToken(token string) oauth2.TokenSource {
 	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
 }
 
-// newTestProxy builds a proxy engine against upstream and serves its handler
-// on an httptest server (which binds 127.0.0.1, so Host validation passes).
+// newTestProxy builds a proxy engine against upstream (none if empty, for
+// mutators that set Mounts) and serves its handler on an httptest server
+// (which binds 127.0.0.1, so Host validation passes).
 func newTestProxy(t *testing.T, upstream string, mutate ...func(*Config)) *httptest.Server {
 	t.Helper()
-	cfg := Config{
-		Upstream:    parseURL(t, upstream),
-		TokenSource: staticToken("good-token"),
+	cfg := Config{TokenSource: staticToken("good-token")}
+	if upstream != "" {
+		cfg.Upstream = parseURL(t, upstream)
 	}
 	for _, m := range mutate {
 		m(&cfg)
@@ -470,7 +472,231 @@ func TestNewValidatesConfig(t *testing.T) {
 		t.Error("New must reject a nil TokenSource")
 	}
 
+	cfg = valid()
+	cfg.Mounts = []Mount{{Prefix: "/p", Upstream: parseURL(t, "https://api.example.test")}}
+	if _, err := New(cfg); err == nil {
+		t.Error("New must reject both Upstream and Mounts")
+	}
+
+	cfg = valid()
+	cfg.Upstream = nil
+	cfg.Mounts = []Mount{
+		{Prefix: "/p", Upstream: parseURL(t, "https://api.example.test")},
+		{Prefix: "/p", Upstream: parseURL(t, "https://api.example.test")},
+	}
+	if _, err := New(cfg); err == nil {
+		t.Error("New must reject duplicate mount prefixes")
+	}
+
 	if _, err := New(valid()); err != nil {
 		t.Errorf("New with a valid config: %v", err)
 	}
 }
+
+func TestLocalTokenRequired(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	proxy := newTestProxy(t, upstream.server.URL, func(c *Config) { c.LocalToken = "local-secret" })
+
+	for _, auth := range []string{"", "Bearer wrong", "local-secret", "Basic bG9jYWwtc2VjcmV0"} {
+		req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/apis/foo", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, want 401", auth, resp.StatusCode)
+		}
+		if resp.Header.Get(proxyErrorHeader) != "true" {
+			t.Errorf("Authorization %q: proxy-synthesized 401 must carry the proxy error marker", auth)
+		}
+	}
+	if n := upstream.count(); n != 0 {
+		t.Fatalf("upstream saw %d request(s); requests without the local token must never reach it", n)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, proxy.URL+"/apis/foo", nil)
+	req.Header.Set("Authorization", "Bearer local-secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := upstream.last(t).header.Get("Authorization"); got != "Bearer good-token" {
+		t.Errorf("upstream Authorization = %q; the local token must be replaced by the session token", got)
+	}
+}
+
+func TestUnixSocketSkipsHostValidation(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	server, err := New(Config{Upstream: parseURL(t, upstream.server.URL), TokenSource: staticToken("good-token")})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sock := filepath.Join(t.TempDir(), "proxy.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	go server.Serve(l)
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	resp, err := client.Get("http://proxy.sock/apis/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200 for a socket client with an arbitrary Host", resp.StatusCode)
+	}
+}
+
+func TestMountsRouteByPrefix(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	const web = "/apis/resourcemanager.miloapis.com/v1alpha1/projects/web/control-plane"
+	const org = "/apis/resourcemanager.miloapis.com/v1alpha1/organizations/acme/control-plane"
+	proxy := newTestProxy(t, "", func(c *Config) {
+		c.Mounts = []Mount{
+			{Prefix: "/p/web", Upstream: parseURL(t, upstream.server.URL+web)},
+			{Prefix: "/p", Upstream: parseURL(t, upstream.server.URL+org)},
+		}
+	})
+
+	tests := []struct {
+		local    string
+		wantPath string
+	}{
+		{"/p/web/apis/foo", web + "/apis/foo"},
+		{"/p/web", web + "/"},
+		{"/p/webby/apis/foo", org + "/webby/apis/foo"}, // segment boundary: not /p/web
+		{"/p/apis/foo", org + "/apis/foo"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(proxy.URL + tt.local + "?watch=true")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", tt.local, resp.StatusCode)
+			continue
+		}
+		got := upstream.last(t)
+		if got.path != tt.wantPath || got.rawQuery != "watch=true" {
+			t.Errorf("%s: upstream got %s?%s, want %s?watch=true", tt.local, got.path, got.rawQuery, tt.wantPath)
+		}
+	}
+
+	before := upstream.count()
+	resp, err := http.Get(proxy.URL + "/apis/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get(proxyErrorHeader) != "true" {
+		t.Errorf("unmounted path: status = %d, marker = %q; want a synthesized 404",
+			resp.StatusCode, resp.Header.Get(proxyErrorHeader))
+	}
+	if upstream.count() != before {
+		t.Error("a request outside every mount reached the upstream")
+	}
+}
+
+func TestMountsApplyPolicyToStrippedPath(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	proxy := newTestProxy(t, "", func(c *Config) {
+		c.Mounts = []Mount{{Prefix: "/p/web", Upstream: parseURL(t, upstream.server.URL)}}
+		c.Policy = &Policy{Deny: []PolicyRule{{Resources: []string{"secrets"}}}}
+	})
+
+	resp, err := http.Get(proxy.URL + "/p/web/api/v1/namespaces/default/secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403: the policy must see the path without its mount prefix", resp.StatusCode)
+	}
+}
+
+func TestSessionsSelectPerRequest(t *testing.T) {
+	upstream := newRecordingUpstream(t, nil)
+	failing := &fakeTokenSource{expired: true, refreshErr: errors.New("refresh token revoked")}
+	proxy := newTestProxy(t, "", func(c *Config) {
+		c.TokenSource = nil
+		c.Sessions = []Session{
+			{Name: "admin", Upstream: parseURL(t, upstream.server.URL), TokenSource: staticToken("admin-token")},
+			{Name: "bot", Upstream: parseURL(t, upstream.server.URL), TokenSource: staticToken("bot-token")},
+			{Name: "expired", Upstream: parseURL(t, upstream.server.URL), TokenSource: failing},
+		}
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		wantStatus int
+		wantToken  string
+		wantPath   string
+	}{
+		{"default", "/apis/foo", "", 200, "Bearer admin-token", "/apis/foo"},
+		{"header", "/apis/foo", "bot", 200, "Bearer bot-token", "/apis/foo"},
+		{"path", "/as/bot/apis/foo", "", 200, "Bearer bot-token", "/apis/foo"},
+		{"path and same header", "/as/bot/apis/foo", "bot", 200, "Bearer bot-token", "/apis/foo"},
+		{"conflict", "/as/bot/apis/foo", "admin", 400, "", ""},
+		{"unknown", "/as/nobody/apis/foo", "", 404, "", ""},
+		{"one session's failure is its own", "/apis/foo", "expired", 502, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := upstream.count()
+			req, _ := http.NewRequest(http.MethodGet, proxy.URL+tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(SessionHeader, tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != 200 {
+				if upstream.count() != before {
+					t.Error("a rejected request reached the upstream")
+				}
+				return
+			}
+			got := upstream.last(t)
+			if got.header.Get("Authorization") != tt.wantToken || got.path != tt.wantPath {
+				t.Errorf("upstream got %s with %q, want %s with %q", got.path, got.header.Get("Authorization"), tt.wantPath, tt.wantToken)
+			}
+			if got.header.Get(SessionHeader) != "" {
+				t.Errorf("%s was forwarded upstream", SessionHeader)
+			}
+		})
+	}
+
+	// The failing session's cooldown must not block the others.
+	resp, err := http.Get(proxy.URL + "/as/bot/apis/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || failing.refreshCount.Load() != 1 {
+		t.Errorf("after another session's refresh failure: status = %d, refreshes = %d; want 200, 1",
+			resp.StatusCode, failing.refreshCount.Load())
+	}
+}
@@ -0,0 +1,276 @@
+package apiproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// fixtureVersion is written to every fixture so the format can evolve.
+const fixtureVersion = 1
+
+// fixture is one recorded request/response pair, stored as one JSON file.
+type fixture struct {
+	Version  int             `json:"version"`
+	Request  fixtureRequest  `json:"request"`
+	Response fixtureResponse `json:"response"`
+}
+
+type fixtureRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Query is the raw query with credential-shaped values redacted.
+	Query string `json:"query,omitempty"`
+	// Session is the X-Datum-Session header, the one request header that
+	// changes what a multi-session proxy answers.
+	Session string  `json:"session,omitempty"`
+	Body    payload `json:"body,omitzero"`
+}
+
+type fixtureResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	// Body holds a complete response; Stream holds a streaming one (watch,
+	// chunked transfer) event by event, with timing.
+	Body   payload       `json:"body,omitzero"`
+	Stream []streamChunk `json:"stream,omitzero"`
+	// Open marks a stream that was still open when the client went away. A
+	// replayed open stream stays open, like a quiet watch, until the client
+	// disconnects.
+	Open bool `json:"open,omitempty"`
+}
+
+// streamChunk is one write of a streaming response, At after the response
+// headers.
+type streamChunk struct {
+	At   duration `json:"at"`
+	Data payload  `json:"data"`
+}
+
+// payload is a body stored as text when it is valid UTF-8 (JSON, YAML, and
+// event streams stay readable and diffable) and base64 otherwise.
+type payload struct {
+	Text   string `json:"text,omitempty"`
+	Base64 []byte `json:"base64,omitempty"`
+}
+
+func newPayload(b []byte) payload {
+	if utf8.Valid(b) {
+		return payload{Text: string(b)}
+	}
+	return payload{Base64: b}
+}
+
+func (p payload) IsZero() bool { return p.Text == "" && len(p.Base64) == 0 }
+
+func (p payload) bytes() []byte {
+	if p.Base64 != nil {
+		return p.Base64
+	}
+	return []byte(p.Text)
+}
+
+// duration marshals as a Go duration string ("1.5s").
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) { return json.Marshal(time.Duration(d).String()) }
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	*d = duration(parsed)
+	return err
+}
+
+// recordedResponseHeaders are the response headers worth keeping in a
+// fixture; everything else (cookies, dates, tracing and audit IDs) is
+// connection- or moment-specific.
+var recordedResponseHeaders = []string{"Content-Type", "Content-Encoding", "Warning"}
+
+// Recorder captures every request the proxy forwards, and its response, as
+// a fixture file that Replayer can serve back. Credentials are never
+// recorded: request headers are dropped entirely and credential-shaped query
+// values are redacted.
+type Recorder struct {
+	dir string
+	seq atomic.Int64
+}
+
+// NewRecorder records into dir, creating it if needed. Numbering continues
+// after the highest-numbered fixture already there, so a directory can be
+// recorded into in several sessions, even after fixtures are deleted.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{dir: dir}
+	for _, path := range existing {
+		if seq, ok := fixtureSeq(filepath.Base(path)); ok && seq > r.seq.Load() {
+			r.seq.Store(seq)
+		}
+	}
+	return r, nil
+}
+
+// fixtureSeq returns the sequence number a fixture file name starts with.
+func fixtureSeq(name string) (int64, bool) {
+	prefix, _, ok := strings.Cut(name, "-")
+	if !ok {
+		return 0, false
+	}
+	seq, err := strconv.ParseInt(prefix, 10, 64)
+	return seq, err == nil
+}
+
+// wrap returns next with recording applied; write failures go to log.
+func (rec *Recorder) wrap(next http.Handler, log *eventLog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seq := rec.seq.Add(1)
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			writeStatus(w, http.StatusBadRequest, "BadRequest", "could not read request body: "+err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		cw := &capturingResponse{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+
+		// Proxy-synthesized answers (denials, token refresh failures) are
+		// not the platform's and would poison a fixture set.
+		if cw.Header().Get(proxyErrorHeader) != "" {
+			return
+		}
+		f := fixture{
+			Version: fixtureVersion,
+			Request: fixtureRequest{
+				Method:  r.Method,
+				Path:    r.URL.Path,
+				Query:   redactQuery(r.URL.RawQuery),
+				Session: r.Header.Get(SessionHeader),
+				Body:    newPayload(body),
+			},
+			Response: cw.response(r.Method == http.MethodHead, r.Context().Err() != nil),
+		}
+		if err := rec.write(seq, f); err != nil {
+			log.write("record_failed", logFields{"method": r.Method, "path": r.URL.Path, "message": err.Error()},
+				fmt.Sprintf("record %s %s failed: %v", r.Method, r.URL.Path, err))
+		}
+	})
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func (rec *Recorder) write(seq int64, f fixture) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	slug := strings.Trim(unsafeFileChars.ReplaceAllString(f.Request.Path, "_"), "_")
+	if len(slug) > 80 {
+		slug = slug[len(slug)-80:]
+	}
+	name := fmt.Sprintf("%06d-%s-%s.json", seq, f.Request.Method, slug)
+	tmp := filepath.Join(rec.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(rec.dir, name))
+}
+
+// capturingResponse tees a response into memory, chunk by chunk with timing.
+type capturingResponse struct {
+	http.ResponseWriter
+
+	wroteHeader bool
+	status      int
+	header      http.Header
+	start       time.Time
+	chunks      []streamChunk
+}
+
+func (c *capturingResponse) WriteHeader(code int) {
+	if code >= 100 && code < 200 {
+		c.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		c.status = code
+		c.header = c.Header().Clone()
+		c.start = time.Now()
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *capturingResponse) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	n, err := c.ResponseWriter.Write(b)
+	if n > 0 {
+		c.chunks = append(c.chunks, streamChunk{
+			At:   duration(time.Since(c.start)),
+			Data: newPayload(append([]byte(nil), b[:n]...)),
+		})
+	}
+	return n, err
+}
+
+// Flush and Unwrap keep the wrapped writer streamable, as for loggedResponse.
+func (c *capturingResponse) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (c *capturingResponse) Unwrap() http.ResponseWriter { return c.ResponseWriter }
+
+func (c *capturingResponse) response(isHead, clientGone bool) fixtureResponse {
+	status := c.status
+	if !c.wroteHeader {
+		status = http.StatusOK
+	}
+	resp := fixtureResponse{Status: status}
+	for _, name := range recordedResponseHeaders {
+		if values := c.header.Values(name); len(values) > 0 {
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+			resp.Header[name] = values
+		}
+	}
+
+	// Same rule as the request log: no declared length means a stream.
+	streaming := c.header != nil && c.header.Get("Content-Length") == "" &&
+		!isHead && status != http.StatusNoContent && status != http.StatusNotModified
+	if streaming {
+		resp.Stream = append([]streamChunk{}, c.chunks...)
+		resp.Open = clientGone
+		return resp
+	}
+	var body []byte
+	for _, chunk := range c.chunks {
+		body = append(body, chunk.Data.bytes()...)
+	}
+	resp.Body = newPayload(body)
+	return resp
+}
@@ -0,0 +1,177 @@
+package apiproxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newReplayProxy(t *testing.T, dir string) string {
+	t.Helper()
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+	return newTestProxy(t, "", func(c *Config) {
+		c.TokenSource = nil
+		c.Replay = replayer
+	}).URL
+}
+
+func fetch(t *testing.T, method, target, body string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+// TestRecordThenReplay records a list, a create, and a watch through the
+// proxy, then serves them back from a replaying proxy with the upstream gone.
+func TestRecordThenReplay(t *testing.T) {
+	var creates atomic.Int32
+	upstream := newRecordingUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("watch") == "true":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"type":"ADDED"}`+"\n")
+			w.(http.Flusher).Flush()
+			io.WriteString(w, `{"type":"DELETED"}`+"\n")
+		case r.Method == http.MethodPost:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=upstream-secret")
+			w.Header().Set("Content-Length", "13")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"created":%d}`, creates.Add(1))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", "12")
+			io.WriteString(w, `{"items":[]}`)
+		}
+	})
+
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording := newTestProxy(t, upstream.server.URL, func(c *Config) { c.Recorder = recorder })
+
+	const zones = "/apis/networking.datumapis.com/v1alpha/namespaces/default/dnszones"
+	fetch(t, http.MethodGet, recording.URL+zones+"?limit=500&access_token=leaked", "")
+	fetch(t, http.MethodPost, recording.URL+zones, `{"metadata":{"name":"a"}}`)
+	fetch(t, http.MethodPost, recording.URL+zones, `{"metadata":{"name":"a"}}`)
+	fetch(t, http.MethodGet, recording.URL+zones+"?watch=true&timeoutSeconds=301", "")
+	upstream.server.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 4 {
+		t.Fatalf("recorded %d fixtures, want 4: %v", len(files), files)
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		for _, secret := range []string{"good-token", "leaked", "upstream-secret"} {
+			if bytes.Contains(data, []byte(secret)) {
+				t.Errorf("%s contains %q:\n%s", filepath.Base(file), secret, data)
+			}
+		}
+	}
+
+	replaying := newReplayProxy(t, dir)
+
+	resp, body := fetch(t, http.MethodGet, replaying+zones+"?access_token=other&limit=500", "")
+	if resp.StatusCode != http.StatusOK || body != `{"items":[]}` {
+		t.Errorf("list = %d %q, want the recorded answer", resp.StatusCode, body)
+	}
+
+	// Identical requests are answered in recording order, then the last
+	// answer repeats; JSON formatting does not affect matching.
+	for i, want := range []string{`{"created":1}`, `{"created":2}`, `{"created":2}`} {
+		resp, body := fetch(t, http.MethodPost, replaying+zones, "{ \"metadata\": { \"name\": \"a\" } }")
+		if resp.StatusCode != http.StatusCreated || body != want {
+			t.Errorf("create %d = %d %q, want 201 %q", i, resp.StatusCode, body, want)
+		}
+	}
+
+	resp, body = fetch(t, http.MethodGet, replaying+zones+"?timeoutSeconds=42&watch=true", "")
+	if want := "{\"type\":\"ADDED\"}\n{\"type\":\"DELETED\"}\n"; resp.StatusCode != http.StatusOK || body != want {
+		t.Errorf("watch = %d %q, want %q", resp.StatusCode, body, want)
+	}
+	if cl := resp.Header.Get("Content-Length"); cl != "" {
+		t.Errorf("replayed watch carries Content-Length %q; it must stream", cl)
+	}
+
+	resp, _ = fetch(t, http.MethodDelete, replaying+zones+"/a", "")
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get(proxyErrorHeader) != "true" {
+		t.Errorf("unrecorded request = %d (marker %q), want a synthesized 404",
+			resp.StatusCode, resp.Header.Get(proxyErrorHeader))
+	}
+}
+
+func TestRecorderSkipsProxySynthesizedResponses(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := newTestProxy(t, "https://api.example.test", func(c *Config) {
+		c.Recorder = recorder
+		c.ReadOnly = true
+	})
+	if resp, _ := fetch(t, http.MethodDelete, proxy.URL+"/api/v1/namespaces/x", ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", resp.StatusCode)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+		t.Errorf("recorded %v; proxy-synthesized responses must not become fixtures", files)
+	}
+}
+
+func TestRecorderContinuesAfterHighestFixture(t *testing.T) {
+	dir := t.TempDir()
+	// Fixtures 2 to 4 were deleted; counting files would reuse number 3.
+	for _, name := range []string{"000001-GET-apis.json", "000005-GET-apis.json", "notes.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recorder.seq.Load(); got != 5 {
+		t.Errorf("seq = %d, want 5, the highest existing fixture", got)
+	}
+}
+
+func TestNewReplayerRejectsEmptyDir(t *testing.T) {
+	if _, err := NewReplayer(t.TempDir()); err == nil {
+		t.Error("NewReplayer must reject a directory without fixtures")
+	}
+}
+
+func TestNormalizeBody(t *testing.T) {
+	for _, tc := range []struct{ a, b string }{
+		{`{"b":1,"a":[1,2]}`, "{\n  \"a\": [1, 2],\n  \"b\": 1\n}"},
+		{`{"n":1.0000000000000001}`, `{"n": 1.0000000000000001}`},
+	} {
+		if normalizeBody([]byte(tc.a)) != normalizeBody([]byte(tc.b)) {
+			t.Errorf("normalizeBody(%q) != normalizeBody(%q)", tc.a, tc.b)
+		}
+	}
+	if normalizeBody([]byte("not json")) != "not json" {
+		t.Error("non-JSON bodies must compare byte for byte")
+	}
+}
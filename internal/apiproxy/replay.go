@@ -0,0 +1,167 @@
+package apiproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// volatileQueryKeys are ignored when matching a request to a fixture:
+// client-go randomizes a watch's timeoutSeconds on every call.
+var volatileQueryKeys = []string{"timeoutSeconds"}
+
+// Replayer answers requests from fixtures written by Recorder, with no
+// upstream and no credentials. A request matches a fixture on method, path,
+// query (ignoring parameter order and volatileQueryKeys), session, and body
+// (ignoring JSON formatting). Identical requests recorded several times are
+// answered in recording order, the last answer repeating once the rest are
+// used up.
+type Replayer struct {
+	mu      sync.Mutex
+	byKey   map[string][]*fixture
+	served  map[string]int
+	entries int
+}
+
+// NewReplayer loads every fixture in dir.
+func NewReplayer(dir string) (*Replayer, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no fixtures in %s", dir)
+	}
+	sort.Strings(paths)
+
+	rp := &Replayer{byKey: map[string][]*fixture{}, served: map[string]int{}}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var f fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if f.Version != fixtureVersion {
+			return nil, fmt.Errorf("%s: unsupported fixture version %d", filepath.Base(path), f.Version)
+		}
+		key := fixtureKey(f.Request.Method, f.Request.Path, f.Request.Query, f.Request.Session, f.Request.Body.bytes())
+		rp.byKey[key] = append(rp.byKey[key], &f)
+		rp.entries++
+	}
+	return rp, nil
+}
+
+// Len returns the number of fixtures loaded.
+func (rp *Replayer) Len() int { return rp.entries }
+
+func (rp *Replayer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, "BadRequest", "could not read request body: "+err.Error())
+		return
+	}
+	key := fixtureKey(r.Method, r.URL.Path, redactQuery(r.URL.RawQuery), r.Header.Get(SessionHeader), body)
+	f := rp.next(key)
+	if f == nil {
+		writeStatus(w, http.StatusNotFound, "NotFound",
+			fmt.Sprintf("no recorded response for %s %s", r.Method, redactedRequestPath(r)))
+		return
+	}
+	replay(w, r, f.Response)
+}
+
+func (rp *Replayer) next(key string) *fixture {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	candidates := rp.byKey[key]
+	if len(candidates) == 0 {
+		return nil
+	}
+	i := min(rp.served[key], len(candidates)-1)
+	rp.served[key]++
+	return candidates[i]
+}
+
+// replay writes a recorded response, pacing a stream's chunks as recorded.
+func replay(w http.ResponseWriter, r *http.Request, resp fixtureResponse) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	if resp.Stream == nil {
+		body := resp.Body.bytes()
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(resp.Status)
+		_, _ = w.Write(body)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.WriteHeader(resp.Status)
+	_ = rc.Flush()
+	start := time.Now()
+	for _, chunk := range resp.Stream {
+		if wait := time.Duration(chunk.At) - time.Since(start); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+		if _, err := w.Write(chunk.Data.bytes()); err != nil {
+			return
+		}
+		_ = rc.Flush()
+	}
+	if resp.Open {
+		// The recorded stream outlived its client; so does this one.
+		<-r.Context().Done()
+	}
+}
+
+// fixtureKey identifies the requests a fixture answers.
+func fixtureKey(method, path, rawQuery, session string, body []byte) string {
+	key, _ := json.Marshal([]string{method, path, normalizeQuery(rawQuery), session, normalizeBody(body)})
+	return string(key)
+}
+
+func normalizeQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	for _, key := range volatileQueryKeys {
+		values.Del(key)
+	}
+	return values.Encode() // sorted by key
+}
+
+// normalizeBody compacts a JSON body to a canonical form — sorted keys, no
+// insignificant whitespace — so formatting differences never defeat a match.
+// Other bodies are compared byte for byte.
+func normalizeBody(body []byte) string {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if len(body) == 0 || dec.Decode(&v) != nil || dec.More() {
+		return string(body)
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(canonical)
+}
@@ -0,0 +1,124 @@
+package apiproxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// requestInfo is what the proxy's policy sees of a request: the Kubernetes
+// verb and the resource it addresses, parsed from the method and path.
+type requestInfo struct {
+	// IsResourceRequest is false for discovery and other non-resource paths
+	// (/api, /apis/<group>, /version, /openapi/v3, ...).
+	IsResourceRequest bool
+
+	Verb        string
+	APIGroup    string
+	Resource    string
+	Subresource string
+	Namespace   string
+	Name        string
+}
+
+// parseRequestInfo classifies r the way a Kubernetes API server would. Paths
+// under a control-plane prefix
+// (/apis/<group>/<version>/<kind>/<name>/control-plane/...) are classified
+// by what follows the prefix, so a rule about dnszones applies no matter
+// which project's control plane the request goes through.
+func parseRequestInfo(r *http.Request) requestInfo {
+	parts := splitPath(r.URL.Path)
+	parts = stripControlPlanePrefixes(parts)
+
+	info := requestInfo{Verb: nonResourceVerb(r.Method)}
+
+	var rest []string
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		rest = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		info.APIGroup = parts[1]
+		rest = parts[3:]
+	default:
+		return requestInfo{Verb: info.Verb}
+	}
+	if len(rest) == 0 {
+		// Group-version discovery, e.g. /apis/networking.datumapis.com/v1alpha.
+		return requestInfo{Verb: info.Verb, APIGroup: info.APIGroup}
+	}
+
+	info.IsResourceRequest = true
+	if rest[0] == "namespaces" && len(rest) >= 3 {
+		info.Namespace = rest[1]
+		rest = rest[2:]
+	} else if rest[0] == "namespaces" && len(rest) == 2 {
+		// The namespace object itself.
+		info.Namespace = rest[1]
+	}
+	info.Resource = rest[0]
+	if len(rest) >= 2 {
+		info.Name = rest[1]
+	}
+	if len(rest) >= 3 {
+		info.Subresource = rest[2]
+	}
+	info.Verb = resourceVerb(r, info.Name != "")
+	return info
+}
+
+// stripControlPlanePrefixes drops every leading
+// apis/<group>/<version>/<kind>/<name>/control-plane segment run.
+func stripControlPlanePrefixes(parts []string) []string {
+	for len(parts) >= 6 && parts[0] == "apis" && parts[5] == "control-plane" {
+		parts = parts[6:]
+	}
+	return parts
+}
+
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+// resourceVerb maps an HTTP method on a resource path to its Kubernetes verb.
+func resourceVerb(r *http.Request, named bool) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if isWatch(r) {
+			return "watch"
+		}
+		if named {
+			return "get"
+		}
+		return "list"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if named {
+			return "delete"
+		}
+		return "deletecollection"
+	}
+	return strings.ToLower(r.Method)
+}
+
+func nonResourceVerb(method string) string {
+	if method == http.MethodHead {
+		return "get"
+	}
+	return strings.ToLower(method)
+}
+
+func isWatch(r *http.Request) bool {
+	switch r.URL.Query().Get("watch") {
+	case "true", "1":
+		return true
+	}
+	return false
+}
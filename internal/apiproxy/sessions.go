@@ -0,0 +1,102 @@
+package apiproxy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// SessionHeader selects a session per request when the proxy serves several.
+// It is consumed by the proxy and never forwarded upstream.
+const SessionHeader = "X-Datum-Session"
+
+// sessionPathPrefix selects a session by path: /as/<session>/apis/...
+const sessionPathPrefix = "/as/"
+
+// Session is one identity a multi-session proxy can act as. Its fields mean
+// what the same-named Config fields mean for a single-session proxy.
+type Session struct {
+	// Name selects the session in the X-Datum-Session header or an
+	// /as/<name>/ path prefix. It must be non-empty and contain no "/".
+	Name string
+
+	Upstream        *url.URL
+	Mounts          []Mount
+	TokenSource     oauth2.TokenSource
+	TLSClientConfig *tls.Config
+}
+
+// sessionSelector dispatches each request to its session's handler chain.
+// Each session has its own token source — and so its own refresh cooldown —
+// so one session's expired login never affects another's requests. Requests
+// that name no session go to the first one.
+type sessionSelector struct {
+	names    []string
+	handlers map[string]http.Handler
+}
+
+func newSessionSelector(cfg Config, events *eventLog, faults *faultInjector) (*sessionSelector, error) {
+	sel := &sessionSelector{handlers: map[string]http.Handler{}}
+	for _, sess := range cfg.Sessions {
+		if sess.Name == "" || strings.Contains(sess.Name, "/") {
+			return nil, fmt.Errorf("apiproxy: invalid session name %q", sess.Name)
+		}
+		if _, dup := sel.handlers[sess.Name]; dup {
+			return nil, fmt.Errorf("apiproxy: duplicate session %q", sess.Name)
+		}
+		h, err := newSessionHandler(sess, cfg, events, faults)
+		if err != nil {
+			return nil, fmt.Errorf("%w (session %q)", err, sess.Name)
+		}
+		sel.names = append(sel.names, sess.Name)
+		sel.handlers[sess.Name] = h
+	}
+	return sel, nil
+}
+
+func (s *sessionSelector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.Header.Get(SessionHeader)
+	fromPath, rest, hasPrefix := cutSessionPath(r.URL.Path)
+	if hasPrefix {
+		if name != "" && name != fromPath {
+			writeStatus(w, http.StatusBadRequest, "BadRequest",
+				fmt.Sprintf("%s header %q conflicts with path session %q", SessionHeader, name, fromPath))
+			return
+		}
+		name = fromPath
+	}
+	if name == "" {
+		name = s.names[0]
+	}
+	handler, ok := s.handlers[name]
+	if !ok {
+		writeStatus(w, http.StatusNotFound, "NotFound",
+			fmt.Sprintf("the proxy does not serve session %q; available: %s", name, strings.Join(s.names, ", ")))
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.Header.Del(SessionHeader)
+	if hasPrefix {
+		out.URL.Path = rest
+		out.URL.RawPath = ""
+	}
+	handler.ServeHTTP(w, out)
+}
+
+// cutSessionPath splits /as/<session>/rest into the session and /rest.
+func cutSessionPath(path string) (session, rest string, ok bool) {
+	after, found := strings.CutPrefix(path, sessionPathPrefix)
+	if !found {
+		return "", "", false
+	}
+	session, rest, _ = strings.Cut(after, "/")
+	if session == "" {
+		return "", "", false
+	}
+	return session, "/" + rest, true
+}
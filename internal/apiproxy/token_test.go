@@ -262,7 +262,7 @@ func TestRefreshFailureSynthesizes502(t *testing.T) {
 func TestCooldownWindowGatesRefreshRetries(t *testing.T) {
 	source := &fakeTokenSource{token: "stale", expired: true, refreshErr: errors.New("boom")}
 	current := time.Unix(1_000_000, 0)
-	cooled := newCooldownTokenSource(source, 5*time.Second, io.Discard)
+	cooled := newCooldownTokenSource(source, 5*time.Second, newEventLog(nil, LogFormatText))
 	cooled.now = func() time.Time { return current }
 
 	_, err := cooled.Token()
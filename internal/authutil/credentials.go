@@ -12,6 +12,8 @@ import (
 
 	customerrors "go.datum.net/datumctl/internal/errors"
 	"go.datum.net/datumctl/internal/keyring"
+	"go.datum.net/datumctl/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/oauth2"
 )
 
@@ -28,7 +30,7 @@ const KnownUsersKey = "known_users"
 var ErrNoActiveUser = customerrors.NewUserErrorWithHint(
 	"No active user found.",
 	"Please login first using: `datumctl login`",
-)
+).WithCode(customerrors.CodeAuthExpired)
 
 // IsNoActiveUser reports whether err wraps ErrNoActiveUser.
 func IsNoActiveUser(err error) bool {
@@ -93,7 +95,9 @@ func GetActiveCredentials() (*StoredCredentials, string, error) {
 
 // GetStoredCredentials retrieves and unmarshals credentials for a specific user key.
 func GetStoredCredentials(userKey string) (*StoredCredentials, error) {
+	_, span := telemetry.StartSpan(context.Background(), "keyring.get")
 	credsJSON, err := keyring.Get(ServiceName, userKey)
+	telemetry.End(span, err)
 	if err != nil {
 		if errors.Is(err, keyring.ErrNotFound) {
 			return nil, fmt.Errorf("credentials for user '%s' not found in keyring", userKey)
@@ -136,7 +140,7 @@ type persistingTokenSource struct {
 // guidance instead of silently refreshing — and re-persisting — a session the
 // user deliberately ended, and a replaced entry (re-login, or a refresh by
 // another datumctl process) is adopted without a restart.
-func (p *persistingTokenSource) Token() (*oauth2.Token, error) {
+func (p *persistingTokenSource) Token() (_ *oauth2.Token, err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -145,13 +149,16 @@ func (p *persistingTokenSource) Token() (*oauth2.Token, error) {
 		return p.creds.Token, nil
 	}
 
+	_, span := telemetry.StartSpan(p.ctx, "auth.token_refresh", attribute.String("auth.credential_type", "user"))
+	defer func() { telemetry.End(span, err) }()
+
 	stored, err := GetStoredCredentials(p.userKey)
 	if err != nil {
 		return nil, customerrors.WrapUserErrorWithHint(
 			fmt.Sprintf("The stored credentials for %s are no longer available — the session may have been logged out.", p.userKey),
 			"Run 'datumctl login' to re-authenticate.",
 			err,
-		)
+		).WithCode(customerrors.CodeAuthExpired)
 	}
 	p.creds = stored
 	if p.creds.Token.Valid() {
@@ -179,7 +186,7 @@ func (p *persistingTokenSource) Token() (*oauth2.Token, error) {
 					"Authentication session has expired or refresh token is no longer valid.",
 					"Please re-authenticate using: `datumctl login`",
 					err,
-				)
+				).WithCode(customerrors.CodeAuthExpired)
 			}
 		}
 		return nil, err
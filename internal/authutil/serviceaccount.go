@@ -21,6 +21,8 @@ import (
 	"github.com/google/uuid"
 	customerrors "go.datum.net/datumctl/internal/errors"
 	"go.datum.net/datumctl/internal/keyring"
+	"go.datum.net/datumctl/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/oauth2"
 )
 
@@ -185,7 +187,7 @@ type serviceAccountTokenSource struct {
 // Token implements oauth2.TokenSource. If the cached token is still valid it is
 // returned immediately. Otherwise a new JWT is minted, exchanged for an access
 // token, and the updated credentials are persisted to the keyring.
-func (m *serviceAccountTokenSource) Token() (*oauth2.Token, error) {
+func (m *serviceAccountTokenSource) Token() (_ *oauth2.Token, err error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -193,6 +195,9 @@ func (m *serviceAccountTokenSource) Token() (*oauth2.Token, error) {
 		return m.creds.Token, nil
 	}
 
+	_, span := telemetry.StartSpan(m.ctx, "auth.token_refresh", attribute.String("auth.credential_type", "service_account"))
+	defer func() { telemetry.End(span, err) }()
+
 	sa := m.creds.ServiceAccount
 
 	// Resolve the PEM key. New sessions store the key on disk (PrivateKeyPath)
@@ -207,7 +212,7 @@ func (m *serviceAccountTokenSource) Token() (*oauth2.Token, error) {
 				"failed to read service account private key from "+sa.PrivateKeyPath,
 				"re-run 'datumctl login --credentials <file>'; you may need to download a new service account credentials file from the Datum portal if the original is no longer available",
 				readErr,
-			)
+			).WithCode(customerrors.CodeAuthExpired)
 		}
 	}
 	if pemKey == "" {
@@ -215,7 +220,7 @@ func (m *serviceAccountTokenSource) Token() (*oauth2.Token, error) {
 			"service account session is missing its private key",
 			"re-run 'datumctl login --credentials <file>'; you may need to download a new service account credentials file from the Datum portal if the original is no longer available",
 			nil,
-		)
+		).WithCode(customerrors.CodeAuthExpired)
 	}
 
 	signedJWT, err := MintJWT(sa.ClientID, sa.PrivateKeyID, pemKey, sa.TokenURI)
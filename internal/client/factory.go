@@ -14,8 +14,11 @@ import (
 	customerrors "go.datum.net/datumctl/internal/errors"
 	"go.datum.net/datumctl/internal/miloapi"
 	"go.datum.net/datumctl/internal/onboarding"
+	"go.datum.net/datumctl/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/oauth2"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/discovery"
 	diskcached "k8s.io/client-go/discovery/cached/disk"
@@ -55,6 +58,10 @@ type CustomConfigFlags struct {
 	// skips org onboarding checks. Used for user-scoped discovery commands
 	// such as listing organization memberships.
 	ForceUserControlPlane bool
+	// Retries and RetryTimeout bound the automatic retry of reads and PUTs
+	// that failed transiently. See retryTransport.
+	Retries      *int
+	RetryTimeout *time.Duration
 }
 
 func (factory *DatumCloudFactory) AddFlags(flags *pflag.FlagSet) {
@@ -62,13 +69,18 @@ func (factory *DatumCloudFactory) AddFlags(flags *pflag.FlagSet) {
 	flags.StringVar(factory.ConfigFlags.Project, "project", "", "project name")
 	flags.StringVar(factory.ConfigFlags.Organization, "organization", "", "organization name")
 	flags.BoolVar(factory.ConfigFlags.PlatformWide, "platform-wide", false, "access the platform root instead of a project or organization control plane")
+	flags.IntVar(factory.ConfigFlags.Retries, "retries", DefaultRetries, "maximum retries of a read or PUT after a transient API failure (429, 502-504, dropped connection); deletes are never retried, since a retried delete could report NotFound; 0 disables retries")
+	flags.DurationVar(factory.ConfigFlags.RetryTimeout, "retry-timeout", DefaultRetryTimeout, "maximum total time to spend waiting between retries of one request")
 }
 
 func (factory *DatumCloudFactory) AddFlagMutualExclusions(cmd interface{ MarkFlagsMutuallyExclusive(...string) }) {
 	cmd.MarkFlagsMutuallyExclusive("project", "organization", "platform-wide")
 }
 
-func (c *CustomConfigFlags) ToRESTConfig() (*rest.Config, error) {
+func (c *CustomConfigFlags) ToRESTConfig() (_ *rest.Config, err error) {
+	ctx, span := telemetry.StartSpan(c.Context, "client.rest_config")
+	defer func() { telemetry.End(span, err) }()
+
 	config, err := c.ConfigFlags.ToRESTConfig()
 	if err != nil {
 		return nil, err
@@ -91,7 +103,9 @@ func (c *CustomConfigFlags) ToRESTConfig() (*rest.Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	_, tknSpan := telemetry.StartSpan(ctx, "auth.token_source")
 	tknSrc, err := authutil.GetTokenSourceForUser(c.Context, userKey)
+	telemetry.End(tknSpan, err)
 	if err != nil {
 		return nil, err
 	}
@@ -112,13 +126,18 @@ func (c *CustomConfigFlags) ToRESTConfig() (*rest.Config, error) {
 	// commands (for example listing organization memberships) still work when
 	// the active context points at an incomplete organization.
 	if !c.SkipOnboardingCheck && !platformWide && !c.ForceUserControlPlane {
-		if err := c.ensureOnboardingComplete(userKey, tknSrc, projectID, organizationID, ctxEntry); err != nil {
+		_, onboardingSpan := telemetry.StartSpan(ctx, "onboarding.check")
+		err := c.ensureOnboardingComplete(userKey, tknSrc, projectID, organizationID, ctxEntry)
+		telemetry.End(onboardingSpan, err)
+		if err != nil {
 			return nil, err
 		}
 	}
 
+	retries, retryTimeout := c.retryPolicy()
 	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
-		return &oauth2.Transport{Source: tknSrc, Base: rt}
+		base := &oauth2.Transport{Source: tknSrc, Base: telemetry.WrapTransport(rt)}
+		return newRetryTransport(base, retries, retryTimeout)
 	}
 
 	baseServer, err := c.resolveBaseServer(userKey, session)
@@ -161,6 +180,19 @@ func (c *CustomConfigFlags) ToRESTConfig() (*rest.Config, error) {
 	return config, nil
 }
 
+// retryPolicy returns the effective --retries and --retry-timeout values,
+// falling back to the defaults when the flags were never registered.
+func (c *CustomConfigFlags) retryPolicy() (int, time.Duration) {
+	retries, timeout := DefaultRetries, DefaultRetryTimeout
+	if c.Retries != nil {
+		retries = *c.Retries
+	}
+	if c.RetryTimeout != nil {
+		timeout = *c.RetryTimeout
+	}
+	return retries, timeout
+}
+
 func (c *CustomConfigFlags) ToRawKubeConfigLoader() clientcmd.ClientConfig {
 	restConfig, err := c.ToRESTConfig()
 	if err != nil {
@@ -240,7 +272,37 @@ func (c *CustomConfigFlags) ToDiscoveryClient() (discovery.CachedDiscoveryInterf
 	httpCacheDir := filepath.Join(cacheDir, "http")
 	discoveryCacheDir := filepath.Join(cacheDir, "discovery", config.Host)
 
-	return diskcached.NewCachedDiscoveryClientForConfig(config, discoveryCacheDir, httpCacheDir, 6*time.Hour)
+	cached, err := diskcached.NewCachedDiscoveryClientForConfig(config, discoveryCacheDir, httpCacheDir, 6*time.Hour)
+	if err != nil || !telemetry.Enabled() {
+		return cached, err
+	}
+	return &tracedDiscovery{CachedDiscoveryInterface: cached, ctx: c.Context}, nil
+}
+
+// tracedDiscovery records a span around each discovery call that can reach
+// the network, so discovery cost shows up separately from the request it
+// was done for. Cache hits appear as spans without HTTP children.
+type tracedDiscovery struct {
+	discovery.CachedDiscoveryInterface
+	ctx context.Context
+}
+
+func (d *tracedDiscovery) ServerGroups() (_ *metav1.APIGroupList, err error) {
+	_, span := telemetry.StartSpan(d.ctx, "discovery.groups")
+	defer func() { telemetry.End(span, err) }()
+	return d.CachedDiscoveryInterface.ServerGroups()
+}
+
+func (d *tracedDiscovery) ServerResourcesForGroupVersion(groupVersion string) (_ *metav1.APIResourceList, err error) {
+	_, span := telemetry.StartSpan(d.ctx, "discovery.resources", attribute.String("discovery.group_version", groupVersion))
+	defer func() { telemetry.End(span, err) }()
+	return d.CachedDiscoveryInterface.ServerResourcesForGroupVersion(groupVersion)
+}
+
+func (d *tracedDiscovery) ServerGroupsAndResources() (_ []*metav1.APIGroup, _ []*metav1.APIResourceList, err error) {
+	_, span := telemetry.StartSpan(d.ctx, "discovery.groups_and_resources")
+	defer func() { telemetry.End(span, err) }()
+	return d.CachedDiscoveryInterface.ServerGroupsAndResources()
 }
 
 // ToRESTMapper overrides the embedded ConfigFlags method for the same reason as
@@ -260,7 +322,10 @@ func (c *CustomConfigFlags) ToRESTMapper() (meta.RESTMapper, error) {
 // loadDatumContext resolves the active v1beta1 session and current context,
 // if any. Returns (nil, nil, nil) when no session exists, letting callers
 // fall back to the user-key path which bootstraps from keyring if needed.
-func (c *CustomConfigFlags) loadDatumContext() (*datumconfig.DiscoveredContext, *datumconfig.Session, error) {
+func (c *CustomConfigFlags) loadDatumContext() (_ *datumconfig.DiscoveredContext, _ *datumconfig.Session, err error) {
+	_, span := telemetry.StartSpan(c.Context, "config.load")
+	defer func() { telemetry.End(span, err) }()
+
 	cfg, err := datumconfig.LoadAuto()
 	if err != nil {
 		return nil, nil, err
@@ -433,6 +498,14 @@ func NewDatumFactory(ctx context.Context) (*DatumCloudFactory, error) {
 			b := false
 			return &b
 		}(),
+		Retries: func() *int {
+			n := DefaultRetries
+			return &n
+		}(),
+		RetryTimeout: func() *time.Duration {
+			d := DefaultRetryTimeout
+			return &d
+		}(),
 	}
 	f := util.NewFactory(configFlags)
 	return &DatumCloudFactory{
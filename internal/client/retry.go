@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/oauth2"
+	"k8s.io/klog/v2"
+)
+
+// Defaults for --retries and --retry-timeout.
+const (
+	DefaultRetries      = 3
+	DefaultRetryTimeout = 30 * time.Second
+)
+
+// Backoff bounds between attempts when the server gives no Retry-After.
+const (
+	retryBaseDelay = 250 * time.Millisecond
+	retryMaxDelay  = 8 * time.Second
+)
+
+// retryTransport retries requests that failed transiently: 429 and 502/503/504
+// responses, dropped connections, and token refresh failures that were not an
+// outright rejection. Only reads and PUT are retried, and never watches or
+// connection upgrades, whose semantics a replay would change. DELETE is not
+// retried either: it is idempotent in effect but not in response, so a replay
+// of a delete that succeeded before its response was lost reports NotFound.
+//
+// It sits outside the oauth2 transport so a retry also retries the token
+// refresh.
+type retryTransport struct {
+	base    http.RoundTripper
+	retries int
+	// timeout bounds the total time spent waiting between attempts; a wait
+	// that would exceed it ends the retries early.
+	timeout time.Duration
+
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+func newRetryTransport(base http.RoundTripper, retries int, timeout time.Duration) http.RoundTripper {
+	if retries <= 0 {
+		return base
+	}
+	return &retryTransport{
+		base:    base,
+		retries: retries,
+		timeout: timeout,
+		now:     time.Now,
+		sleep:   sleepContext,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryableRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	deadline := t.now().Add(t.timeout)
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		reason, retry := retryReason(resp, err)
+		if !retry || attempt >= t.retries {
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), t.now()); ok {
+				delay = after
+			}
+		}
+		if t.now().Add(delay).After(deadline) {
+			klog.V(2).Infof("Not retrying %s %s after %s: next attempt in %s would exceed --retry-timeout",
+				req.Method, req.URL.Path, reason, delay.Round(time.Millisecond))
+			return resp, err
+		}
+		if resp != nil {
+			// Drain so the connection can be reused for the next attempt.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+
+		klog.V(2).Infof("Retrying %s %s after %s (attempt %d of %d, waiting %s)",
+			req.Method, req.URL.Path, reason, attempt+1, t.retries, delay.Round(time.Millisecond))
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryableRequest reports whether req can safely be sent more than once.
+func retryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut:
+	default:
+		return false
+	}
+	if req.Header.Get("Upgrade") != "" {
+		return false
+	}
+	switch req.URL.Query().Get("watch") {
+	case "true", "1":
+		return false
+	}
+	// A body that cannot be rewound cannot be resent.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	return true
+}
+
+// retryReason classifies the outcome of one attempt, returning a short
+// description for the log and whether it is worth another attempt.
+func retryReason(resp *http.Response, err error) (string, bool) {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return "", false
+		}
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			// The auth server answered. Only its own overload is transient; a
+			// rejected refresh token needs a new login, not a retry.
+			if retrieveErr.Response != nil && retryableStatus(retrieveErr.Response.StatusCode) {
+				return "token refresh failure (" + retrieveErr.Response.Status + ")", true
+			}
+			return "", false
+		}
+		if transientNetError(err) {
+			return "connection error (" + err.Error() + ")", true
+		}
+		return "", false
+	}
+	if retryableStatus(resp.StatusCode) {
+		return resp.Status, true
+	}
+	return "", false
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// transientNetError reports whether err is a dropped or refused connection or
+// a network timeout, as opposed to, say, a TLS verification failure that no
+// number of retries will fix.
+func transientNetError(err error) bool {
+	switch {
+	case errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, io.EOF):
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// HTTP/2 stream resets surface only as text.
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset by peer") ||
+		strings.Contains(msg, "http2: server sent GOAWAY") ||
+		strings.Contains(msg, "INTERNAL_ERROR")
+}
+
+// backoff returns the wait before retry attempt+1: exponential from
+// retryBaseDelay, capped at retryMaxDelay, with full jitter so parallel
+// clients don't retry in lockstep.
+func backoff(attempt int) time.Duration {
+	ceiling := retryBaseDelay << attempt
+	if ceiling <= 0 || ceiling > retryMaxDelay {
+		ceiling = retryMaxDelay
+	}
+	return ceiling/2 + rand.N(ceiling/2+1)
+}
+
+// retryAfter parses a Retry-After header, either delay-seconds or an
+// HTTP-date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
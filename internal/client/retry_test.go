@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newTestRetryTransport returns a retryTransport that records waits instead
+// of sleeping.
+func newTestRetryTransport(base http.RoundTripper, retries int, timeout time.Duration) (*retryTransport, *[]time.Duration) {
+	var waits []time.Duration
+	t := newRetryTransport(base, retries, timeout).(*retryTransport)
+	t.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return t, &waits
+}
+
+// statusSequence serves the given statuses in order, then 200s.
+func statusSequence(t *testing.T, headers http.Header, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		body, _ := io.ReadAll(r.Body)
+		if n < len(statuses) {
+			for k, v := range headers {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(statuses[n])
+			return
+		}
+		w.Write(append([]byte("ok:"), body...))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetryTransport_retriesTransientStatus(t *testing.T) {
+	srv, calls := statusSequence(t, nil, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	rt, waits := newTestRetryTransport(http.DefaultTransport, 3, time.Minute)
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("payload"))
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK || string(body) != "ok:payload" {
+		t.Errorf("got %d %q, want 200 \"ok:payload\" (body must be replayed)", resp.StatusCode, body)
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 3", *calls)
+	}
+	if len(*waits) != 2 {
+		t.Errorf("waits = %v, want 2", *waits)
+	}
+}
+
+func TestRetryTransport_givesUpAfterRetries(t *testing.T) {
+	srv, calls := statusSequence(t, nil, 503, 503, 503, 503, 503)
+	rt, _ := newTestRetryTransport(http.DefaultTransport, 2, time.Minute)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want the last 503", resp.StatusCode)
+	}
+	if *calls != 3 {
+		t.Errorf("calls = %d, want 3 (1 + 2 retries)", *calls)
+	}
+}
+
+func TestRetryTransport_honorsRetryAfter(t *testing.T) {
+	srv, _ := statusSequence(t, http.Header{"Retry-After": {"7"}}, http.StatusTooManyRequests)
+	rt, waits := newTestRetryTransport(http.DefaultTransport, 3, time.Minute)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	resp.Body.Close()
+	if len(*waits) != 1 || (*waits)[0] != 7*time.Second {
+		t.Errorf("waits = %v, want [7s]", *waits)
+	}
+}
+
+func TestRetryTransport_retryAfterBeyondTimeout(t *testing.T) {
+	srv, calls := statusSequence(t, http.Header{"Retry-After": {"120"}}, http.StatusServiceUnavailable)
+	rt, waits := newTestRetryTransport(http.DefaultTransport, 3, 30*time.Second)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || *calls != 1 || len(*waits) != 0 {
+		t.Errorf("got status %d after %d calls, waits %v; want the 503 without retrying",
+			resp.StatusCode, *calls, *waits)
+	}
+}
+
+func TestRetryTransport_skipsUnsafeRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		header http.Header
+	}{
+		{"post", http.MethodPost, "/apis/x", nil},
+		{"patch", http.MethodPatch, "/apis/x", nil},
+		// A retried delete whose first attempt succeeded would report NotFound.
+		{"delete", http.MethodDelete, "/apis/x", nil},
+		{"watch", http.MethodGet, "/apis/x?watch=true", nil},
+		{"upgrade", http.MethodGet, "/exec", http.Header{"Upgrade": {"websocket"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := statusSequence(t, nil, http.StatusServiceUnavailable)
+			rt, _ := newTestRetryTransport(http.DefaultTransport, 3, time.Minute)
+
+			req, _ := http.NewRequest(tt.method, srv.URL+tt.url, nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			resp.Body.Close()
+			if *calls != 1 {
+				t.Errorf("calls = %d, want 1", *calls)
+			}
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestRetryTransport_errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"connection reset", syscall.ECONNRESET, 2},
+		{"token refresh 503", &oauth2.RetrieveError{Response: &http.Response{StatusCode: 503, Status: "503 Service Unavailable"}}, 2},
+		{"token refresh rejected", &oauth2.RetrieveError{Response: &http.Response{StatusCode: 400}, ErrorCode: "invalid_grant"}, 1},
+		{"canceled", context.Canceled, 1},
+		{"other", errors.New("x509: certificate signed by unknown authority"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			base := roundTripFunc(func(*http.Request) (*http.Response, error) {
+				calls++
+				if calls == 1 {
+					return nil, tt.err
+				}
+				return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+			})
+			rt, _ := newTestRetryTransport(base, 3, time.Minute)
+			req, _ := http.NewRequest(http.MethodGet, "https://api.example.test/x", nil)
+			_, _ = rt.RoundTrip(req)
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		d := backoff(attempt)
+		ceiling := min(retryBaseDelay<<attempt, retryMaxDelay)
+		if d < ceiling/2 || d > ceiling {
+			t.Errorf("backoff(%d) = %v, want within [%v, %v]", attempt, d, ceiling/2, ceiling)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"fmt"
 
@@ -18,6 +20,31 @@ type EndpointTLS struct {
 	CAData                []byte
 }
 
+// ClientConfig converts the endpoint's TLS settings into a *tls.Config for
+// clients that talk to the endpoint directly rather than through a
+// rest.Config. Returns nil when the endpoint declares nothing, keeping Go's
+// defaults.
+func (t EndpointTLS) ClientConfig() (*tls.Config, error) {
+	if t.ServerName == "" && !t.InsecureSkipTLSVerify && len(t.CAData) == 0 {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipTLSVerify,
+	}
+	if len(t.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(t.CAData) {
+			return nil, customerrors.NewUserErrorWithHint(
+				"No certificates could be parsed from the session's certificate authority data.",
+				"Run 'datumctl login' again to refresh the session's endpoint settings.",
+			)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
 // SessionEndpoint is the resolved connection identity for one datumctl
 // session: the keyring user key that owns its credentials, the base API
 // server URL (scheme included, no trailing slash), and the endpoint's TLS
@@ -222,3 +222,48 @@ func TestResolveSessionEndpoint_ActiveSessionFromContext(t *testing.T) {
 		t.Errorf("BaseServer = %q, want %q", endpoint.BaseServer, "https://api.datum.net")
 	}
 }
+
+func TestEndpointTLS_ClientConfig(t *testing.T) {
+	t.Run("empty settings keep defaults", func(t *testing.T) {
+		cfg, err := EndpointTLS{}.ClientConfig()
+		if err != nil {
+			t.Fatalf("ClientConfig: %v", err)
+		}
+		if cfg != nil {
+			t.Errorf("config = %+v, want nil for default TLS", cfg)
+		}
+	})
+
+	t.Run("server name and insecure carry over", func(t *testing.T) {
+		cfg, err := EndpointTLS{
+			ServerName:            "internal.example",
+			InsecureSkipTLSVerify: true,
+		}.ClientConfig()
+		if err != nil {
+			t.Fatalf("ClientConfig: %v", err)
+		}
+		if cfg == nil {
+			t.Fatal("config = nil, want TLS settings applied")
+		}
+		if cfg.ServerName != "internal.example" {
+			t.Errorf("ServerName = %q, want %q", cfg.ServerName, "internal.example")
+		}
+		if !cfg.InsecureSkipVerify {
+			t.Error("InsecureSkipVerify = false, want true")
+		}
+	})
+
+	t.Run("invalid CA data errors", func(t *testing.T) {
+		_, err := EndpointTLS{CAData: []byte("not a pem")}.ClientConfig()
+		if err == nil {
+			t.Fatal("expected an error for unparseable CA data")
+		}
+		userErr, ok := customerrors.IsUserError(err)
+		if !ok {
+			t.Fatalf("error = %v (%T), want a UserError with actionable guidance", err, err)
+		}
+		if !strings.Contains(userErr.Hint, "datumctl login") {
+			t.Errorf("hint = %q, want it to point at 'datumctl login'", userErr.Hint)
+		}
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"go.datum.net/datumctl/internal/authutil"
 	"go.datum.net/datumctl/internal/datumconfig"
 	"go.datum.net/datumctl/internal/miloapi"
+	"go.datum.net/datumctl/internal/telemetry"
 )
 
 // NewUserContextualClient creates a new controller-runtime client configured for the current user's context.
@@ -66,7 +67,7 @@ func NewRestConfig(ctx context.Context) (*rest.Config, error) {
 		WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
 			return &oauth2.Transport{
 				Source: tknSrc,
-				Base:   rt,
+				Base:   telemetry.WrapTransport(rt),
 			}
 		},
 	}
@@ -14,6 +14,7 @@ import (
 	datumai "go.datum.net/datumctl/internal/ai"
 	"go.datum.net/datumctl/internal/ai/llm"
 	"go.datum.net/datumctl/internal/client"
+	"go.datum.net/datumctl/internal/console/chatstorage"
 )
 
 // Command returns the cobra.Command for `datumctl ai`.
@@ -25,6 +26,9 @@ func Command() *cobra.Command {
 		model        string
 		maxIter      int
 		platformWide bool
+		yesFor       []string
+		output       string
+		resume       string
 	)
 
 	cmd := &cobra.Command{
@@ -35,11 +39,27 @@ Datum Cloud operations.
 
 In interactive mode (no query argument), the assistant maintains conversation
 context so you can ask follow-up questions. Read operations execute immediately;
-write operations always show a preview and ask for confirmation.
+write operations show a preview and ask for confirmation, unless the policy in
+the config file decides them first. In pipe mode nobody can be asked, so only
+changes the policy or --yes-for approves are applied.
+
+With --output json or ndjson, a single query is answered for scripts: the
+answer, every tool call with its arguments and outcome, the model, and the
+number of model calls are written to stdout as JSON (ndjson streams each step
+as one line as it happens). Changes are applied only when the policy or
+--yes-for approves them, as in pipe mode.
+
+Interactive sessions are saved, with every tool call and result, to
+~/.datumctl/conversations, which the console's AI chat shares. Continue one
+with --resume <id> or --resume last; it runs in the context it was saved in
+unless flags say otherwise. See 'datumctl ai history' to list, read and
+delete them.
 
 Configuration is read from the ai config file (see 'datumctl ai config show').
 Flag values override config file values. API keys in the config file are
-overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_API_KEY).`,
+overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_API_KEY,
+OPENAI_COMPATIBLE_API_KEY). To use a model hosted in your own network, set
+provider to openai-compatible and base_url to its OpenAI-compatible API root.`,
 		Example: `  # First-time setup (store API key and default org)
   datumctl ai config set anthropic_api_key sk-ant-...
   datumctl ai config set organization my-org-id
@@ -51,17 +71,58 @@ overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_A
   datumctl ai "list projects" --organization other-org-id
 
   # Interactive session
-  datumctl ai --project my-project-id`,
+  datumctl ai --project my-project-id
+
+  # Automation: apply DNS record changes without asking, nothing else
+  echo "point www at 203.0.113.10" | datumctl ai --project my-project-id --yes-for kind=DNSRecord
+
+  # Scripting: parse the answer and the tool calls as JSON
+  datumctl ai "which DNS zones are not ready?" --output json | jq -r .answer
+
+  # Pick up the most recent conversation where it left off
+  datumctl ai --resume last`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Load config file first — flags override below.
+			if err := validateOutput(output); err != nil {
+				return err
+			}
+			structured := output != ""
+
+			// Load config file first — flags override below. A config that
+			// fails to load is fatal rather than ignored: carrying on with an
+			// empty one would drop the policy's deny rules while --yes-for
+			// still approves.
 			aiCfg, err := datumai.LoadConfig()
 			if err != nil {
-				fmt.Fprintf(cmd.ErrOrStderr(), "[ai] warning: could not load config: %v\n", err)
+				return fmt.Errorf("%w\n\nFix the file, or check it with 'datumctl ai config show'", err)
 			}
 
 			// Environment variables override config file API keys.
 			aiCfg.ApplyEnvOverrides()
 
+			// A resumed conversation runs in the context it was saved in, unless
+			// flags choose another.
+			var (
+				store *chatstorage.Store
+				conv  *chatstorage.Conversation
+			)
+			platformWideSet := cmd.Flags().Changed("platform-wide")
+			if resume != "" {
+				if store, err = openStore(); err != nil {
+					return err
+				}
+				if conv, err = store.Resolve(resume); err != nil {
+					return err
+				}
+				if organization == "" && project == "" && !platformWideSet {
+					organization, project, platformWide = conv.OrgID, conv.ProjectID, conv.PlatformWide
+					platformWideSet = true
+					aiCfg.Organization, aiCfg.Project = "", ""
+				}
+				if namespace == "" {
+					namespace = conv.Namespace
+				}
+			}
+
 			// Flags override config file context.
 			if organization != "" {
 				aiCfg.Organization = organization
@@ -75,7 +136,7 @@ overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_A
 			if model != "" {
 				aiCfg.Model = model
 			}
-			if cmd.Flags().Changed("platform-wide") {
+			if platformWideSet {
 				aiCfg.PlatformWide = platformWide
 			}
 			if cmd.Flags().Changed("max-iterations") {
@@ -87,6 +148,17 @@ overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_A
 			if aiCfg.Namespace == "" {
 				aiCfg.Namespace = "default"
 			}
+			policy := &datumai.Policy{}
+			if aiCfg.Policy != nil {
+				policy = aiCfg.Policy
+			}
+			for _, scope := range yesFor {
+				rule, err := datumai.ParseYesFor(scope)
+				if err != nil {
+					return err
+				}
+				policy.YesFor = append(policy.YesFor, rule)
+			}
 
 			// Validate mutual exclusions.
 			if aiCfg.PlatformWide && (aiCfg.Organization != "" || aiCfg.Project != "") {
@@ -99,22 +171,25 @@ overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_A
 			// Determine terminal/interactive mode.
 			isTTY := term.IsTerminal(int(os.Stdin.Fd()))
 			isInteractive := isTTY && len(args) == 0
+			if structured && isInteractive {
+				return fmt.Errorf("--output %s needs a query, as an argument or on stdin", output)
+			}
 
-			// Resolve the initial query.
-			query, err := resolveQuery(args, isTTY)
-			if err != nil {
-				return err
+			// Resolve the initial query. A resumed session waits for the next
+			// question instead of greeting.
+			query := ""
+			if conv == nil || !isInteractive {
+				if query, err = resolveQuery(args, isTTY); err != nil {
+					return err
+				}
 			}
 
 			// Construct the LLM client.
-			llmClient, err := llm.NewClient(llm.Config{
-				Provider:        aiCfg.Provider,
-				Model:           aiCfg.Model,
-				AnthropicAPIKey: aiCfg.AnthropicAPIKey,
-				OpenAIAPIKey:    aiCfg.OpenAIAPIKey,
-				GeminiAPIKey:    aiCfg.GeminiAPIKey,
-			})
+			llmClient, err := llm.NewClient(aiCfg.LLMConfig())
 			if err != nil {
+				if aiCfg.BaseURL != "" || aiCfg.Provider == llm.ProviderOpenAICompatible {
+					return fmt.Errorf("initialize LLM: %w", err)
+				}
 				return fmt.Errorf("initialize LLM: %w\n\nRun 'datumctl ai config set anthropic_api_key <key>' to save your key", err)
 			}
 			fmt.Fprintf(cmd.ErrOrStderr(), "[ai] using %s/%s\n", llmClient.Provider(), llmClient.Model())
@@ -136,7 +211,7 @@ overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_A
 			if project != "" {
 				*factory.ConfigFlags.Project = project
 			}
-			if cmd.Flags().Changed("platform-wide") {
+			if platformWideSet {
 				*factory.ConfigFlags.PlatformWide = platformWide
 			}
 
@@ -174,7 +249,7 @@ overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_A
 				if _, err := factory.ConfigFlags.ToRESTConfig(); err != nil {
 					return fmt.Errorf("connect to Datum Cloud: %w\n\nRun 'datumctl login' to authenticate", err)
 				}
-				registry = datumai.NewRegistry(factory)
+				registry = datumai.NewRegistry(factory, datumai.UserPluginsDir())
 			} else {
 				fmt.Fprintf(cmd.ErrOrStderr(), "[ai] no organization or project set — running without resource tools\n")
 				fmt.Fprintf(cmd.ErrOrStderr(), "[ai] tip: run 'datumctl ai config set organization <id>' to set a default\n")
@@ -184,23 +259,54 @@ overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_A
 			systemPrompt := datumai.BuildSystemPrompt(resolvedOrg, resolvedProject, aiCfg.Namespace, resolvedPlatformWide)
 
 			var gate datumai.ConfirmGate
-			if isTTY {
-				gate = datumai.StdinGate{In: cmd.InOrStdin(), Out: cmd.ErrOrStderr()}
+			if isTTY && !structured {
+				gate = datumai.StdinGate{In: cmd.InOrStdin(), Out: cmd.ErrOrStderr(), Color: term.IsTerminal(int(os.Stderr.Fd()))}
 			} else {
 				gate = datumai.AutoDeclineGate{ErrOut: cmd.ErrOrStderr()}
 			}
 
+			// Interactive and resumed sessions are saved after every turn.
+			if conv == nil && isInteractive {
+				if store, err = openStore(); err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "[ai] warning: conversation will not be saved: %v\n", err)
+				} else {
+					conv = chatstorage.NewConversation(resolvedOrg, resolvedProject)
+				}
+			}
+			var saveTranscript func([]llm.Message)
+			if conv != nil {
+				saveTranscript = func(transcript []llm.Message) {
+					conv.SetTranscript(transcript)
+					conv.Namespace = *factory.ConfigFlags.Namespace
+					// change_context may have moved the session since it started.
+					if p, o, pw, err := factory.ConfigFlags.ResolvedScope(); err == nil {
+						conv.ProjectID, conv.OrgID, conv.PlatformWide = p, o, pw
+					}
+					if err := store.Save(conv); err != nil {
+						fmt.Fprintf(cmd.ErrOrStderr(), "[ai] warning: could not save conversation: %v\n", err)
+					}
+				}
+			}
+
 			agent := datumai.NewAgent(datumai.AgentOptions{
-				LLM:           llmClient,
-				Registry:      registry,
-				SystemPrompt:  systemPrompt,
-				MaxIterations: aiCfg.MaxIterations,
-				In:            cmd.InOrStdin(),
-				Out:           cmd.OutOrStdout(),
-				ErrOut:        cmd.ErrOrStderr(),
-				Interactive:   isInteractive,
-				IsTerminal:    isTTY,
-				Gate:          gate,
+				LLM:              llmClient,
+				Registry:         registry,
+				SystemPrompt:     systemPrompt,
+				MaxIterations:    aiCfg.MaxIterations,
+				ContextTokens:    aiCfg.ContextTokens,
+				MaxSessionTokens: aiCfg.MaxTokensPerSession,
+				In:               cmd.InOrStdin(),
+				Out:              cmd.OutOrStdout(),
+				ErrOut:           cmd.ErrOrStderr(),
+				Interactive:      isInteractive,
+				IsTerminal:       isTTY && !structured,
+				Gate:             gate,
+				Policy:           policy,
+				AfterTurn:        saveTranscript,
+				Project: func() string {
+					project, _, _, _ := factory.ConfigFlags.ResolvedScope()
+					return project
+				},
 			})
 
 			if isInteractive {
@@ -215,7 +321,20 @@ overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_A
 					fmt.Fprintf(cmd.OutOrStdout(), "No context set — add --organization, --project, or --platform-wide to manage resources.\n\n")
 				}
 			}
+			if conv != nil {
+				agent.SetHistory(conv.History())
+				if resume != "" {
+					fmt.Fprintf(cmd.ErrOrStderr(), "[ai] resuming conversation %s (%d messages)\n", conv.ID, len(conv.Messages))
+				}
+			}
 
+			if structured {
+				err := runStructured(cmd.Context(), agent, query, output, llmClient, cmd.OutOrStdout())
+				if saveTranscript != nil {
+					saveTranscript(agent.Transcript())
+				}
+				return err
+			}
 			return agent.Run(cmd.Context(), query)
 		},
 	}
@@ -226,9 +345,12 @@ overridden by environment variables (ANTHROPIC_API_KEY, OPENAI_API_KEY, GEMINI_A
 	cmd.Flags().StringVar(&model, "model", "", "Model override, e.g. claude-sonnet-4-6, gpt-4o, gemini-2.0-flash")
 	cmd.Flags().IntVar(&maxIter, "max-iterations", 20, "Agentic loop iteration cap")
 	cmd.Flags().BoolVar(&platformWide, "platform-wide", false, "Access platform root (staff portal) instead of an org or project control plane")
+	cmd.Flags().StringArrayVar(&yesFor, "yes-for", nil, "Approve changes matching a scope without asking, e.g. kind=DNSRecord or tool=apply_manifest,project=staging (repeatable; policy deny rules still win)")
+	cmd.Flags().StringVar(&resume, "resume", "", "Continue a saved conversation: its ID, or last for the most recent (see 'datumctl ai history')")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Answer a single query as JSON for scripts: json (one document) or ndjson (one event per line)")
 	cmd.MarkFlagsMutuallyExclusive("organization", "project", "platform-wide")
 
-	cmd.AddCommand(configCommand())
+	cmd.AddCommand(configCommand(), historyCommand())
 
 	return cmd
 }
@@ -0,0 +1,41 @@
+package ai
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	datumai "go.datum.net/datumctl/internal/ai"
+)
+
+// TestInvalidConfigIsFatal verifies a config file that fails to load stops
+// the command, so its deny rules cannot silently drop out while --yes-for
+// still approves.
+func TestInvalidConfigIsFatal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	path, err := datumai.ConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	policy := "policy:\n  rules:\n  - action: Deny\n    kinds: [Project]\n"
+	if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := Command()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"delete project p", "--yes-for", "tool=delete_resource"})
+	err = cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), `action "Deny" must be approve, deny, or ask`) {
+		t.Errorf("Execute() = %v, want the policy validation error", err)
+	}
+}
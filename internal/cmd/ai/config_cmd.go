@@ -2,6 +2,7 @@ package ai
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -14,12 +15,32 @@ var validKeys = map[string]string{
 	"organization":      "Default organization ID (overridden by --organization flag)",
 	"project":           "Default project ID (overridden by --project flag)",
 	"namespace":         "Default namespace (overridden by --namespace flag)",
-	"provider":          "LLM provider: anthropic, openai, or gemini",
+	"provider":          "LLM provider: anthropic, openai, gemini, or openai-compatible",
 	"model":             "LLM model override (e.g. claude-sonnet-4-6, gpt-4o)",
 	"max_iterations":    "Agentic loop iteration cap (default 20)",
+	"context_tokens":    "Model context window in tokens (default: the model's own)",
 	"anthropic_api_key": "Anthropic API key (overridden by ANTHROPIC_API_KEY env var)",
 	"openai_api_key":    "OpenAI API key (overridden by OPENAI_API_KEY env var)",
 	"gemini_api_key":    "Gemini API key (overridden by GEMINI_API_KEY env var)",
+	"base_url":          "OpenAI-compatible API root, e.g. http://localhost:11434/v1",
+	"deployment":        "Azure OpenAI deployment name (uses Azure-style URLs and api-key auth)",
+	"api_version":       "api-version query parameter, e.g. 2024-10-21 for Azure OpenAI",
+
+	"max_tokens_per_session":    "Stop a session after this many tokens (default: no limit)",
+	"openai_compatible_api_key": "API key for base_url, if it needs one (overridden by OPENAI_COMPATIBLE_API_KEY env var)",
+}
+
+// headerKeyPrefix prefixes the keys that set extra request headers for an
+// OpenAI-compatible endpoint, e.g. header.X-Team.
+const headerKeyPrefix = "header."
+
+// validKey reports whether key can be set or unset.
+func validKey(key string) bool {
+	if name, ok := strings.CutPrefix(key, headerKeyPrefix); ok {
+		return name != ""
+	}
+	_, ok := validKeys[key]
+	return ok
 }
 
 func configCommand() *cobra.Command {
@@ -46,19 +67,44 @@ Valid keys:
   organization      Default organization ID
   project           Default project ID
   namespace         Default namespace (default: "default")
-  provider          LLM provider: anthropic, openai, or gemini
+  provider          LLM provider: anthropic, openai, gemini, or openai-compatible
   model             LLM model (e.g. claude-sonnet-4-6, gpt-4o, gemini-2.0-flash)
   max_iterations    Agentic loop iteration cap (default 20)
+  context_tokens    Model context window in tokens; older turns are summarized
+                    near it (default: the model's window, 32768 for
+                    OpenAI-compatible endpoints)
+  max_tokens_per_session
+                    Stop a session once its model calls have used this many
+                    tokens, cached ones included (default: no limit)
   anthropic_api_key Anthropic API key
   openai_api_key    OpenAI API key
-  gemini_api_key    Gemini API key`,
+  gemini_api_key    Gemini API key
+
+OpenAI-compatible endpoints (Ollama, vLLM, LiteLLM, Azure OpenAI):
+  base_url                  API root; requests go to <base_url>/chat/completions
+  deployment                Azure OpenAI deployment name
+  api_version               api-version query parameter
+  openai_compatible_api_key API key, if the endpoint needs one
+  header.<Name>             Extra request header, e.g. header.X-Team`,
 		Example: `  datumctl ai config set organization datum-demos-iy50km
   datumctl ai config set anthropic_api_key sk-ant-...
-  datumctl ai config set model claude-sonnet-4-6`,
+  datumctl ai config set model claude-sonnet-4-6
+
+  # A model served inside your network by Ollama (no API key needed)
+  datumctl ai config set provider openai-compatible
+  datumctl ai config set base_url http://ollama.internal:11434/v1
+  datumctl ai config set model qwen2.5:32b
+
+  # An Azure OpenAI deployment
+  datumctl ai config set provider openai-compatible
+  datumctl ai config set base_url https://my-resource.openai.azure.com
+  datumctl ai config set deployment gpt-4o-prod
+  datumctl ai config set api_version 2024-10-21
+  datumctl ai config set openai_compatible_api_key <key>`,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			key, value := args[0], args[1]
-			if _, ok := validKeys[key]; !ok {
+			if !validKey(key) {
 				return fmt.Errorf("unknown config key %q; valid keys: %s",
 					key, strings.Join(sortedKeys(validKeys), ", "))
 			}
@@ -92,7 +138,7 @@ func configUnsetCommand() *cobra.Command {
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			key := args[0]
-			if _, ok := validKeys[key]; !ok {
+			if !validKey(key) {
 				return fmt.Errorf("unknown config key %q", key)
 			}
 
@@ -136,9 +182,9 @@ func configShowCommand() *cobra.Command {
 			w := cmd.OutOrStdout()
 			row := func(key, value string) {
 				if value == "" {
-					fmt.Fprintf(w, "  %-22s (not set)\n", key)
+					fmt.Fprintf(w, "  %-26s (not set)\n", key)
 				} else {
-					fmt.Fprintf(w, "  %-22s %s\n", key, value)
+					fmt.Fprintf(w, "  %-26s %s\n", key, value)
 				}
 			}
 
@@ -164,12 +210,49 @@ func configShowCommand() *cobra.Command {
 				iters = "20 (default)"
 			}
 			row("max_iterations", iters)
+			contextTokens := "model default"
+			if cfg.ContextTokens > 0 {
+				contextTokens = fmt.Sprintf("%d", cfg.ContextTokens)
+			}
+			row("context_tokens", contextTokens)
+			sessionTokens := "no limit"
+			if cfg.MaxTokensPerSession > 0 {
+				sessionTokens = fmt.Sprintf("%d", cfg.MaxTokensPerSession)
+			}
+			row("max_tokens_per_session", sessionTokens)
 
 			fmt.Fprintf(w, "\nAPI KEYS\n")
 			row("anthropic_api_key", redact(cfg.AnthropicAPIKey))
 			row("openai_api_key", redact(cfg.OpenAIAPIKey))
 			row("gemini_api_key", redact(cfg.GeminiAPIKey))
 
+			fmt.Fprintf(w, "\nOPENAI-COMPATIBLE ENDPOINT\n")
+			row("base_url", cfg.BaseURL)
+			row("deployment", cfg.Deployment)
+			row("api_version", cfg.APIVersion)
+			row("openai_compatible_api_key", redact(cfg.OpenAICompatibleAPIKey))
+			for _, name := range sortedKeys(cfg.Headers) {
+				// Header values are often credentials.
+				row(headerKeyPrefix+name, redact(cfg.Headers[name]))
+			}
+
+			fmt.Fprintf(w, "\nCONFIRMATION POLICY (edit the file to change)\n")
+			policy := cfg.Policy
+			if policy == nil {
+				policy = &datumai.Policy{}
+			}
+			dryRun := "no"
+			if policy.AutoApproveDryRun {
+				dryRun = "yes"
+			}
+			row("auto_approve_dry_run", dryRun)
+			row("mutation_projects", strings.Join(policy.MutationProjects, ", "))
+			rules := ""
+			if len(policy.Rules) > 0 {
+				rules = fmt.Sprintf("%d", len(policy.Rules))
+			}
+			row("rules", rules)
+
 			fmt.Fprintln(w)
 			return nil
 		},
@@ -198,12 +281,53 @@ func setConfigKey(cfg *datumai.Config, key, value string) error {
 			return fmt.Errorf("max_iterations must be an integer, got %q", value)
 		}
 		cfg.MaxIterations = n
+	case "context_tokens":
+		if value == "" {
+			cfg.ContextTokens = 0
+			return nil
+		}
+		var n int
+		if _, err := fmt.Sscanf(value, "%d", &n); err != nil || n <= 0 {
+			return fmt.Errorf("context_tokens must be a positive integer, got %q", value)
+		}
+		cfg.ContextTokens = n
+	case "max_tokens_per_session":
+		if value == "" {
+			cfg.MaxTokensPerSession = 0
+			return nil
+		}
+		var n int
+		if _, err := fmt.Sscanf(value, "%d", &n); err != nil || n <= 0 {
+			return fmt.Errorf("max_tokens_per_session must be a positive integer, got %q", value)
+		}
+		cfg.MaxTokensPerSession = n
 	case "anthropic_api_key":
 		cfg.AnthropicAPIKey = value
 	case "openai_api_key":
 		cfg.OpenAIAPIKey = value
 	case "gemini_api_key":
 		cfg.GeminiAPIKey = value
+	case "base_url":
+		cfg.BaseURL = value
+	case "deployment":
+		cfg.Deployment = value
+	case "api_version":
+		cfg.APIVersion = value
+	case "openai_compatible_api_key":
+		cfg.OpenAICompatibleAPIKey = value
+	default:
+		name, ok := strings.CutPrefix(key, headerKeyPrefix)
+		if !ok {
+			return nil
+		}
+		if value == "" {
+			delete(cfg.Headers, name)
+			return nil
+		}
+		if cfg.Headers == nil {
+			cfg.Headers = map[string]string{}
+		}
+		cfg.Headers[name] = value
 	}
 	return nil
 }
@@ -220,5 +344,6 @@ func sortedKeys(m map[string]string) []string {
 	for k := range m {
 		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 	return keys
 }
@@ -0,0 +1,212 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+	"go.datum.net/datumctl/internal/console/chatstorage"
+	"go.datum.net/datumctl/internal/output"
+)
+
+// maxShownResult caps how much of a tool result 'history show' prints.
+const maxShownResult = 200
+
+func historyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List, show and delete saved AI conversations",
+		Long: `Manage the conversations saved by 'datumctl ai' and the console's AI chat.
+
+Interactive sessions and resumed conversations are saved as they go, with every
+tool call and result, to ~/.datumctl/conversations. Continue one with
+'datumctl ai --resume <id>', or 'datumctl ai --resume last' for the most recent.`,
+	}
+	cmd.AddCommand(historyListCommand(), historyShowCommand(), historyDeleteCommand())
+	return cmd
+}
+
+func historyListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List saved conversations, newest first",
+		Long: `List saved AI conversations, newest first.
+
+Use -o json or -o yaml for a machine-readable ConversationList document.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output.OutputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			metas, err := store.List()
+			if err != nil {
+				return err
+			}
+			doc := output.ConversationList{
+				TypeMeta:      output.NewTypeMeta("ConversationList"),
+				Conversations: make([]output.ConversationSummary, 0, len(metas)),
+			}
+			for _, m := range metas {
+				doc.Conversations = append(doc.Conversations, output.ConversationSummary{
+					ID:             m.ID,
+					StartedAt:      m.StartedAt,
+					UpdatedAt:      m.UpdatedAt,
+					OrganizationID: m.OrgID,
+					ProjectID:      m.ProjectID,
+					Namespace:      m.Namespace,
+					PlatformWide:   m.PlatformWide,
+					Questions:      m.Questions,
+					Preview:        m.Preview,
+				})
+			}
+			if format != "" {
+				return output.PrintStructured(cmd.OutOrStdout(), format, doc)
+			}
+			if len(doc.Conversations) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No saved conversations. Start one with 'datumctl ai'.")
+				return nil
+			}
+			tbl := table.New("ID", "Updated", "Context", "Questions", "Preview")
+			tbl.WithWriter(cmd.OutOrStdout())
+			for _, c := range doc.Conversations {
+				tbl.AddRow(c.ID, duration.HumanDuration(time.Since(c.UpdatedAt))+" ago",
+					scopeLabel(c.OrganizationID, c.ProjectID, c.PlatformWide), c.Questions, c.Preview)
+			}
+			tbl.Print()
+			return nil
+		},
+	}
+	output.AddOutputFlag(cmd)
+	return cmd
+}
+
+func historyShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id|last>",
+		Short: "Print a saved conversation, including its tool calls",
+		Example: `  datumctl ai history show last
+  datumctl ai history show 20261018-093012-3fa2c1`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			conv, err := store.Resolve(args[0])
+			if err != nil {
+				return err
+			}
+			printConversation(cmd.OutOrStdout(), conv)
+			return nil
+		},
+	}
+}
+
+func historyDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <id>...",
+		Aliases: []string{"rm"},
+		Short:   "Delete saved conversations",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			for _, id := range args {
+				if _, err := store.Load(id); err != nil {
+					return err
+				}
+				if err := store.Delete(id); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Deleted conversation %s\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+// openStore opens the conversation store shared with the console.
+func openStore() (*chatstorage.Store, error) {
+	dir, err := chatstorage.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return chatstorage.NewStore(dir)
+}
+
+// scopeLabel describes the context a conversation ran in.
+func scopeLabel(org, project string, platformWide bool) string {
+	switch {
+	case platformWide:
+		return "platform-wide"
+	case project != "":
+		return "project " + project
+	case org != "":
+		return "organization " + org
+	default:
+		return "none"
+	}
+}
+
+// printConversation writes conv as a readable transcript: the questions and
+// answers, with each tool call and an excerpt of its result in between.
+func printConversation(w io.Writer, conv *chatstorage.Conversation) {
+	fmt.Fprintf(w, "Conversation: %s\n", conv.ID)
+	fmt.Fprintf(w, "Started:      %s\n", conv.StartedAt.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "Updated:      %s\n", conv.UpdatedAt.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "Context:      %s\n", scopeLabel(conv.OrgID, conv.ProjectID, conv.PlatformWide))
+	if conv.Namespace != "" {
+		fmt.Fprintf(w, "Namespace:    %s\n", conv.Namespace)
+	}
+	if conv.Version < 2 {
+		fmt.Fprintln(w, "(saved before tool calls were recorded)")
+	}
+
+	for _, m := range conv.Messages {
+		switch llm.Role(m.Role) {
+		case llm.RoleUser:
+			fmt.Fprintf(w, "\n> %s\n", m.Content)
+		case llm.RoleAssistant:
+			if text := strings.TrimSpace(m.Content); text != "" {
+				fmt.Fprintf(w, "\n%s\n", text)
+			}
+			for _, tc := range m.ToolCalls {
+				args, _ := json.Marshal(tc.Arguments)
+				fmt.Fprintf(w, "  [tool] %s %s\n", tc.ToolName, args)
+			}
+		case llm.RoleToolResult:
+			if m.ToolResult == nil {
+				continue
+			}
+			label := "result"
+			if m.ToolResult.IsError {
+				label = "error"
+			}
+			fmt.Fprintf(w, "  [%s] %s\n", label, excerpt(m.ToolResult.Content, maxShownResult))
+		}
+	}
+}
+
+// excerpt flattens s to one line and truncates it to n bytes.
+func excerpt(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > n {
+		return s[:n-1] + "…"
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.datum.net/datumctl/internal/console/chatstorage"
+	"go.datum.net/datumctl/internal/output"
+)
+
+// saveConversation stores a one-question conversation in the store under
+// the test's HOME.
+func saveConversation(t *testing.T, question string) *chatstorage.Conversation {
+	t.Helper()
+	store, err := openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := chatstorage.NewConversation("org", "proj")
+	c.AddMessage("user", question)
+	c.AddMessage("assistant", "No zones.")
+	if err := store.Save(c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func runHistory(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := historyCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestHistoryListJSON(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c := saveConversation(t, "list zones")
+
+	out, err := runHistory(t, "list", "-o", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc output.ConversationList
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("parse %q: %v", out, err)
+	}
+	if doc.TypeMeta != output.NewTypeMeta("ConversationList") {
+		t.Errorf("type = %+v, want a ConversationList", doc.TypeMeta)
+	}
+	if len(doc.Conversations) != 1 {
+		t.Fatalf("conversations = %+v, want one", doc.Conversations)
+	}
+	got := doc.Conversations[0]
+	if got.ID != c.ID || got.ProjectID != "proj" || got.Questions != 1 || got.Preview != "list zones" {
+		t.Errorf("conversation = %+v, want the saved one", got)
+	}
+}
+
+func TestHistoryShowAndDelete(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c := saveConversation(t, "list zones")
+
+	out, err := runHistory(t, "show", "last")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "> list zones") || !strings.Contains(out, "No zones.") {
+		t.Errorf("show = %q, want the question and answer", out)
+	}
+
+	if _, err := runHistory(t, "delete", c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runHistory(t, "show", c.ID); err == nil {
+		t.Error("show after delete succeeded, want an error")
+	}
+}
+
+func TestHistoryDeleteInvalidID(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	saveConversation(t, "list zones")
+
+	for _, id := range []string{"../config", "missing"} {
+		if _, err := runHistory(t, "delete", id); err == nil {
+			t.Errorf("delete %q succeeded, want an error", id)
+		}
+	}
+	out, err := runHistory(t, "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "list zones") {
+		t.Errorf("list after failed deletes = %q, want the conversation kept", out)
+	}
+}
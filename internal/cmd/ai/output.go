@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	datumai "go.datum.net/datumctl/internal/ai"
+	"go.datum.net/datumctl/internal/ai/llm"
+	"go.datum.net/datumctl/internal/output"
+)
+
+// Structured output formats for --output.
+const (
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+// resultEvent is the last --output ndjson line: the AITurn document.
+type resultEvent struct {
+	Type string `json:"type"`
+	output.AITurn
+}
+
+// streamEvent is one --output ndjson line reported while the turn runs.
+type streamEvent struct {
+	Type      string `json:"type"`
+	Iteration int    `json:"iteration"`
+	Text      string `json:"text,omitempty"`
+	*datumai.ToolCallRecord
+}
+
+// validateOutput checks an --output value.
+func validateOutput(format string) error {
+	switch format {
+	case "", outputJSON, outputNDJSON:
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %q; use %s or %s", format, outputJSON, outputNDJSON)
+}
+
+// runStructured runs one turn and writes it to out as JSON: a single indented
+// document for json, or one event per line as they happen for ndjson, ending
+// with the same document typed "result". The document is written even when
+// the turn fails, with the error in it; the error is returned as well so the
+// exit status reflects it.
+func runStructured(ctx context.Context, agent *datumai.Agent, query, format string, client llm.LLMClient, out io.Writer) error {
+	enc := json.NewEncoder(out)
+
+	var result datumai.TurnResult
+	if format == outputNDJSON {
+		events := make(chan datumai.Event)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for ev := range events {
+				_ = enc.Encode(streamEvent{Type: string(ev.Kind), Iteration: ev.Iteration, Text: ev.Text, ToolCallRecord: ev.Call})
+			}
+		}()
+		result = agent.RunTurnEvents(ctx, query, events)
+		close(events)
+		<-done
+	} else {
+		result = agent.RunTurn(ctx, query)
+		enc.SetIndent("", "  ")
+	}
+
+	doc := newTurnDocument(result, client)
+	var err error
+	if format == outputNDJSON {
+		err = enc.Encode(resultEvent{Type: "result", AITurn: doc})
+	} else {
+		err = enc.Encode(doc)
+	}
+	if err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	return result.Err
+}
+
+// newTurnDocument renders a turn as an AITurn document.
+func newTurnDocument(result datumai.TurnResult, client llm.LLMClient) output.AITurn {
+	doc := output.AITurn{
+		TypeMeta:   output.NewTypeMeta("AITurn"),
+		Provider:   client.Provider(),
+		Model:      client.Model(),
+		Answer:     strings.TrimSpace(result.Response),
+		ToolCalls:  make([]output.AIToolCall, 0, len(result.ToolCalls)),
+		Iterations: result.Iterations,
+	}
+	for _, tc := range result.ToolCalls {
+		doc.ToolCalls = append(doc.ToolCalls, output.AIToolCall{
+			ID:        tc.ID,
+			Tool:      tc.Tool,
+			Arguments: tc.Arguments,
+			Result:    tc.Result,
+			Error:     tc.Error,
+			Skipped:   tc.Skipped,
+		})
+	}
+	if u := result.Usage; u.Total() > 0 {
+		doc.Usage = &output.AIUsage{
+			InputTokens:      u.InputTokens,
+			OutputTokens:     u.OutputTokens,
+			CacheReadTokens:  u.CacheReadTokens,
+			CacheWriteTokens: u.CacheWriteTokens,
+		}
+		if cost, ok := llm.EstimateCost(client.Provider(), client.Model(), u); ok {
+			doc.Usage.EstimatedCostUSD = &cost
+		}
+	}
+	if result.Err != nil {
+		doc.Error = result.Err.Error()
+	}
+	return doc
+}
@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	datumai "go.datum.net/datumctl/internal/ai"
+	"go.datum.net/datumctl/internal/ai/llm"
+	"go.datum.net/datumctl/internal/output"
+)
+
+// listingLLM lists zones once, then answers.
+type listingLLM struct{ calls int }
+
+func (l *listingLLM) Chat(ctx context.Context, systemPrompt string, messages []llm.Message, tools []llm.ToolDef) (llm.Message, error) {
+	return l.StreamChat(ctx, systemPrompt, messages, tools, nil)
+}
+
+func (l *listingLLM) StreamChat(ctx context.Context, systemPrompt string, messages []llm.Message, tools []llm.ToolDef, textOut io.Writer) (llm.Message, error) {
+	l.calls++
+	if l.calls == 1 {
+		return llm.Message{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{
+			{ID: "call-1", ToolName: "list_resources", Arguments: map[string]any{"kind": "DNSZone"}},
+		}, Usage: &llm.Usage{InputTokens: 100, OutputTokens: 20, CacheReadTokens: 900}}, nil
+	}
+	if textOut != nil {
+		io.WriteString(textOut, "No zones.")
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: "No zones.", Usage: &llm.Usage{InputTokens: 150, OutputTokens: 5, CacheReadTokens: 900}}, nil
+}
+
+func (l *listingLLM) Provider() string { return "test" }
+func (l *listingLLM) Model() string    { return "test-model" }
+
+func newListingAgent(client llm.LLMClient) *datumai.Agent {
+	return datumai.NewAgent(datumai.AgentOptions{
+		LLM: client,
+		Registry: datumai.NewRegistryOf(datumai.Tool{
+			Def: llm.ToolDef{Name: "list_resources"},
+			Execute: func(ctx context.Context, args map[string]any) (string, error) {
+				return `{"items":[]}`, nil
+			},
+		}),
+		Out:    io.Discard,
+		ErrOut: io.Discard,
+	})
+}
+
+func TestRunStructuredJSON(t *testing.T) {
+	client := &listingLLM{}
+	var out bytes.Buffer
+	if err := runStructured(context.Background(), newListingAgent(client), "list zones", outputJSON, client, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc output.AITurn
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not one JSON document: %v\n%s", err, out.String())
+	}
+	if doc.TypeMeta != output.NewTypeMeta("AITurn") {
+		t.Errorf("type = %+v, want an AITurn", doc.TypeMeta)
+	}
+	if !strings.Contains(out.String(), `"toolCalls"`) || !strings.Contains(out.String(), `"cacheReadTokens"`) {
+		t.Errorf("keys are not camelCase:\n%s", out.String())
+	}
+	if doc.Answer != "No zones." || doc.Provider != "test" || doc.Model != "test-model" || doc.Iterations != 2 {
+		t.Errorf("document = %+v", doc)
+	}
+	if len(doc.ToolCalls) != 1 || doc.ToolCalls[0].Tool != "list_resources" || doc.ToolCalls[0].Result != `{"items":[]}` ||
+		doc.ToolCalls[0].Arguments["kind"] != "DNSZone" {
+		t.Errorf("tool calls = %+v", doc.ToolCalls)
+	}
+	want := output.AIUsage{InputTokens: 250, OutputTokens: 25, CacheReadTokens: 1800}
+	if doc.Usage == nil || *doc.Usage != want {
+		t.Errorf("usage = %+v, want %+v summed over both calls and no cost for an unpriced model", doc.Usage, want)
+	}
+}
+
+func TestRunStructuredNDJSON(t *testing.T) {
+	client := &listingLLM{}
+	var out bytes.Buffer
+	if err := runStructured(context.Background(), newListingAgent(client), "list zones", outputNDJSON, client, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		types []string
+		last  map[string]any
+	)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var ev map[string]any
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("line is not JSON: %v\n%s", err, line)
+		}
+		types = append(types, ev["type"].(string))
+		last = ev
+	}
+	if got := strings.Join(types, ","); got != "tool_call,tool_result,text,text,result" {
+		t.Errorf("event types = %s", got)
+	}
+	if last["kind"] != "AITurn" || last["apiVersion"] != output.SchemaAPIVersion || last["answer"] != "No zones." {
+		t.Errorf("result line = %v, want the AITurn document", last)
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	for _, ok := range []string{"", "json", "ndjson"} {
+		if err := validateOutput(ok); err != nil {
+			t.Errorf("validateOutput(%q) = %v", ok, err)
+		}
+	}
+	if err := validateOutput("yaml"); err == nil {
+		t.Error("validateOutput accepted yaml")
+	}
+}
@@ -0,0 +1,486 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	"go.datum.net/datumctl/internal/client"
+	"go.datum.net/datumctl/internal/datumconfig"
+	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/output"
+	"go.datum.net/datumctl/internal/updatecheck"
+)
+
+const (
+	// startTimeout bounds how long 'start' waits for the background proxy to
+	// report its URL.
+	startTimeout = 30 * time.Second
+	// stopTimeout is how long 'stop' waits after asking a proxy to shut down
+	// before killing it: the proxy's own grace period plus some slack.
+	stopTimeout = shutdownGrace + 3*time.Second
+)
+
+func startCommand(factory *client.DatumCloudFactory) *cobra.Command {
+	var (
+		opts proxyOptions
+		name string
+	)
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start an API proxy in the background",
+		Long: templates.LongDesc(`
+			Start an API proxy in the background and return once it is ready.
+
+			Takes the same flags as 'datumctl api proxy'. The proxy keeps running
+			after this command exits, logging to ~/.datumctl/proxies/<name>.log.
+			Like the foreground proxy, it prints its URL as the first stdout line
+			(and with --require-token, its token as the second).
+
+			Give each background proxy its own --name to run several; manage them
+			with 'datumctl api proxy status', 'list', 'env', and 'stop'.`),
+		Example: templates.Examples(`
+			# Start a proxy for a project in the background
+			datumctl api proxy start --name dev --project my-project --port 8001
+
+			# Point the current shell at it
+			eval "$(datumctl api proxy env dev)"
+
+			# Stop it
+			datumctl api proxy stop dev`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateProxyName(name); err != nil {
+				return err
+			}
+			if err := opts.validate(cmd); err != nil {
+				return err
+			}
+			return runStart(cmd, factory, name, opts)
+		},
+	}
+	addProxyFlags(cmd, &opts)
+	cmd.Flags().StringVar(&name, "name", defaultProxyName, "Name of the background proxy, for status, env, and stop")
+	return cmd
+}
+
+func runStart(cmd *cobra.Command, factory *client.DatumCloudFactory, name string, opts proxyOptions) error {
+	dir, err := proxyStateDir()
+	if err != nil {
+		return err
+	}
+	if existing, err := readDaemonState(stateFilePath(dir, name)); err == nil {
+		if existing.status() != daemonStopped {
+			return customerrors.NewUserErrorWithHint(
+				fmt.Sprintf("A background API proxy named %q is already running at %s.", name, existing.URL),
+				fmt.Sprintf("Stop it first with 'datumctl api proxy stop %s', or pick another --name.", name),
+			)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate the datumctl binary: %w", err)
+	}
+	args := append([]string{"api", "proxy"}, forwardedFlags(cmd.Flags(), "name")...)
+
+	logPath := filepath.Join(dir, name+".log")
+	readyPath := filepath.Join(dir, name+".out")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	readyFile, err := os.OpenFile(readyPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(readyPath)
+	defer readyFile.Close()
+
+	child := exec.Command(exe, args...)
+	// The readiness lines go to a file rather than a pipe, so the proxy never
+	// writes to a closed pipe once this command has exited.
+	child.Stdout = readyFile
+	child.Stderr = logFile
+	// A background process must never replace itself with an update.
+	child.Env = append(os.Environ(), updatecheck.EnvDisable+"=1")
+	detach(child)
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("start background proxy: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+
+	wantLines := 1
+	if opts.requireToken {
+		wantLines = 2
+	}
+	lines, err := waitForReady(readyPath, wantLines, exited, startTimeout)
+	if err != nil {
+		_ = killProcess(child.Process.Pid)
+		return customerrors.WrapUserErrorWithHint(
+			fmt.Sprintf("The background proxy did not start: %v%s", err, logTail(logPath)),
+			"See the full log at "+logPath+".",
+			err,
+		)
+	}
+
+	session, scope := describeBackgroundTarget(factory, opts)
+	state := &daemonState{
+		Name:      name,
+		PID:       child.Process.Pid,
+		URL:       lines[0],
+		Session:   session,
+		Scope:     scope,
+		Args:      args[2:],
+		LogFile:   logPath,
+		StartedAt: time.Now().UTC(),
+	}
+	if started, err := processStartTime(state.PID); err == nil {
+		state.ProcessStart = started.UTC()
+	}
+	if opts.requireToken {
+		state.Token = lines[1]
+	}
+	if err := state.save(dir); err != nil {
+		_ = terminateProcess(state.PID)
+		return err
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Started background API proxy %q (pid %d) at %s\n", name, state.PID, state.URL)
+	fmt.Fprintf(cmd.ErrOrStderr(), "Logs: %s — stop with 'datumctl api proxy stop %s'\n", logPath, name)
+	for _, line := range lines {
+		fmt.Fprintln(cmd.OutOrStdout(), line)
+	}
+	return nil
+}
+
+// forwardedFlags renders every flag set on the command line — including
+// inherited global flags such as --project — as arguments for the background
+// 'datumctl api proxy', except those named in skip.
+func forwardedFlags(flags *pflag.FlagSet, skip ...string) []string {
+	var args []string
+	flags.Visit(func(f *pflag.Flag) {
+		for _, s := range skip {
+			if f.Name == s {
+				return
+			}
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range slice.GetSlice() {
+				args = append(args, "--"+f.Name+"="+v)
+			}
+			return
+		}
+		args = append(args, "--"+f.Name+"="+f.Value.String())
+	})
+	return args
+}
+
+// waitForReady polls path until it holds n complete lines — the readiness
+// contract of 'datumctl api proxy' — the process exits, or timeout passes.
+func waitForReady(path string, n int, exited <-chan error, timeout time.Duration) ([]string, error) {
+	deadline := time.After(timeout)
+	tick := time.NewTicker(50 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		if data, err := os.ReadFile(path); err == nil {
+			if lines := strings.Split(string(data), "\n"); len(lines) > n {
+				return lines[:n], nil
+			}
+		}
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("it exited")
+			}
+			return nil, fmt.Errorf("the proxy exited during startup (%v)", err)
+		case <-deadline:
+			return nil, fmt.Errorf("the proxy was not ready after %s", timeout)
+		case <-tick.C:
+		}
+	}
+}
+
+// logTail returns the last lines of a background proxy's log, formatted to
+// follow an error message, or "" if there are none.
+func logTail(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil || len(bytes.TrimSpace(data)) == 0 {
+		return ""
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > 10 {
+		lines = lines[len(lines)-10:]
+	}
+	return "\n\n  " + strings.Join(lines, "\n  ")
+}
+
+// describeBackgroundTarget summarizes, for status and list, which session
+// and scope a background proxy serves.
+func describeBackgroundTarget(factory *client.DatumCloudFactory, opts proxyOptions) (session, scope string) {
+	if opts.replayDir != "" {
+		return "", "replay " + opts.replayDir
+	}
+	session = strings.Join(opts.sessions, ", ")
+	if session == "" {
+		if cfg, err := datumconfig.LoadAuto(); err == nil {
+			session = cfg.ActiveSession
+		}
+	}
+	flags := factory.ConfigFlags
+	switch {
+	case len(opts.mounts) > 0:
+		scope = "mounts " + strings.Join(opts.mounts, ", ")
+	case boolValue(flags.PlatformWide):
+		scope = "platform-wide"
+	case stringValue(flags.Organization) != "":
+		scope = "organization " + stringValue(flags.Organization)
+	case stringValue(flags.Project) != "":
+		scope = "project " + stringValue(flags.Project)
+	default:
+		scope = "full endpoint"
+	}
+	return session, scope
+}
+
+func stopCommand() *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "stop [NAME]",
+		Short: "Stop a background API proxy",
+		Long: templates.LongDesc(`
+			Stop a background API proxy started with 'datumctl api proxy start'.
+
+			The proxy gets the same graceful shutdown as a foreground proxy on
+			Ctrl+C, and is killed if it has not exited shortly after. NAME
+			defaults to "default".`),
+		Example: templates.Examples(`
+			# Stop the proxy named dev
+			datumctl api proxy stop dev
+
+			# Stop every background proxy
+			datumctl api proxy stop --all`),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var states []*daemonState
+			switch {
+			case all && len(args) > 0:
+				return customerrors.NewUserError("pass a proxy name or --all, not both")
+			case all:
+				var err error
+				if states, err = listDaemonStates(); err != nil {
+					return err
+				}
+			default:
+				state, err := loadDaemonState(nameArg(args))
+				if err != nil {
+					return err
+				}
+				states = []*daemonState{state}
+			}
+			for _, state := range states {
+				if err := stopDaemon(state); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Stopped background API proxy %q.\n", state.Name)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Stop every background proxy")
+	return cmd
+}
+
+// stopDaemon shuts a background proxy down and removes its state file. A
+// proxy that already exited just has its stale state removed; its PID is
+// never signalled, since another process may have it now.
+func stopDaemon(state *daemonState) error {
+	if state.alive() {
+		if err := terminateProcess(state.PID); err != nil {
+			return fmt.Errorf("stop proxy %q (pid %d): %w", state.Name, state.PID, err)
+		}
+		deadline := time.Now().Add(stopTimeout)
+		for processAlive(state.PID) && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if processAlive(state.PID) {
+			if err := killProcess(state.PID); err != nil {
+				return fmt.Errorf("kill proxy %q (pid %d): %w", state.Name, state.PID, err)
+			}
+		}
+	}
+	return state.remove()
+}
+
+func statusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status [NAME]",
+		Short: "Show the status of a background API proxy",
+		Long: templates.LongDesc(`
+			Show whether a background API proxy is running, and where.
+
+			Exits non-zero if the proxy is not running. NAME defaults to "default".
+			Use -o json or -o yaml for a machine-readable ProxyStatus document.`),
+		Example: templates.Examples(`
+			# Check on the proxy named dev
+			datumctl api proxy status dev`),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output.OutputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			state, err := loadDaemonState(nameArg(args))
+			if err != nil {
+				return err
+			}
+			summary := state.summary(state.status())
+			if format != "" {
+				if err := output.PrintStructured(cmd.OutOrStdout(), format, output.ProxyStatus{
+					TypeMeta:     output.NewTypeMeta("ProxyStatus"),
+					ProxySummary: summary,
+				}); err != nil {
+					return err
+				}
+			} else {
+				printProxyStatus(cmd.OutOrStdout(), summary)
+			}
+			return notRunningError(summary)
+		},
+	}
+	output.AddOutputFlag(cmd)
+	return cmd
+}
+
+func printProxyStatus(w io.Writer, s output.ProxySummary) {
+	fmt.Fprintf(w, "Name:     %s\n", s.Name)
+	fmt.Fprintf(w, "Status:   %s\n", s.Status)
+	fmt.Fprintf(w, "PID:      %d\n", s.PID)
+	fmt.Fprintf(w, "URL:      %s\n", s.URL)
+	if s.Session != "" {
+		fmt.Fprintf(w, "Session:  %s\n", s.Session)
+	}
+	fmt.Fprintf(w, "Scope:    %s\n", s.Scope)
+	if s.TokenRequired {
+		fmt.Fprintln(w, "Token:    required (see 'datumctl api proxy env')")
+	}
+	fmt.Fprintf(w, "Started:  %s (%s ago)\n", s.StartedAt.Local().Format(time.DateTime), duration.HumanDuration(time.Since(s.StartedAt)))
+	fmt.Fprintf(w, "Log:      %s\n", s.LogFile)
+}
+
+func notRunningError(s output.ProxySummary) error {
+	switch s.Status {
+	case daemonRunning:
+		return nil
+	case daemonUnresponsive:
+		return customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("Background API proxy %q (pid %d) is not answering at %s.", s.Name, s.PID, s.URL),
+			fmt.Sprintf("Check %s, then restart it with 'datumctl api proxy stop %s' and 'datumctl api proxy start'.", s.LogFile, s.Name),
+		)
+	default:
+		return customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("Background API proxy %q is not running; it exited without being stopped.", s.Name),
+			fmt.Sprintf("Check %s, then start it again or clear it with 'datumctl api proxy stop %s'.", s.LogFile, s.Name),
+		)
+	}
+}
+
+func listCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List background API proxies",
+		Long: templates.LongDesc(`
+			List the background API proxies started with 'datumctl api proxy start'.
+
+			Use -o json or -o yaml for a machine-readable ProxyList document.`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output.OutputFormat(cmd)
+			if err != nil {
+				return err
+			}
+			states, err := listDaemonStates()
+			if err != nil {
+				return err
+			}
+			doc := output.ProxyList{
+				TypeMeta: output.NewTypeMeta("ProxyList"),
+				Proxies:  make([]output.ProxySummary, 0, len(states)),
+			}
+			for _, state := range states {
+				doc.Proxies = append(doc.Proxies, state.summary(state.status()))
+			}
+			if format != "" {
+				return output.PrintStructured(cmd.OutOrStdout(), format, doc)
+			}
+			if len(doc.Proxies) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No background API proxies. Start one with 'datumctl api proxy start'.")
+				return nil
+			}
+			tbl := table.New("Name", "Status", "PID", "URL", "Scope", "Age")
+			tbl.WithWriter(cmd.OutOrStdout())
+			for _, p := range doc.Proxies {
+				tbl.AddRow(p.Name, p.Status, p.PID, p.URL, p.Scope, duration.HumanDuration(time.Since(p.StartedAt)))
+			}
+			tbl.Print()
+			return nil
+		},
+	}
+	output.AddOutputFlag(cmd)
+	return cmd
+}
+
+func envCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "env [NAME]",
+		Short: "Print shell exports for a background API proxy",
+		Long: templates.LongDesc(`
+			Print shell commands that export the URL of a background API proxy as
+			DATUM_API_URL, and with --require-token its token as DATUM_API_TOKEN.
+			NAME defaults to "default".`),
+		Example: templates.Examples(`
+			# Point the current shell at the proxy named dev
+			eval "$(datumctl api proxy env dev)"
+			curl -H "Authorization: Bearer $DATUM_API_TOKEN" "$DATUM_API_URL/apis"`),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state, err := loadDaemonState(nameArg(args))
+			if err != nil {
+				return err
+			}
+			if err := notRunningError(state.summary(state.status())); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "export DATUM_API_URL=%s\n", shellQuote(state.URL))
+			if state.Token != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "export DATUM_API_TOKEN=%s\n", shellQuote(state.Token))
+			}
+			return nil
+		},
+	}
+}
+
+func nameArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return defaultProxyName
+}
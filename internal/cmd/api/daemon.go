@@ -0,0 +1,225 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/output"
+)
+
+// defaultProxyName names a background proxy started without --name.
+const defaultProxyName = "default"
+
+// daemonState is what 'api proxy start' records about a background proxy, in
+// ~/.datumctl/proxies/<name>.json (mode 0600: it holds the local token).
+type daemonState struct {
+	Name      string    `json:"name"`
+	PID       int       `json:"pid"`
+	URL       string    `json:"url"`
+	Token     string    `json:"token,omitempty"`
+	Session   string    `json:"session,omitempty"`
+	Scope     string    `json:"scope,omitempty"`
+	Args      []string  `json:"args"`
+	LogFile   string    `json:"logFile"`
+	StartedAt time.Time `json:"startedAt"`
+	// ProcessStart is when the proxy's process was created, as the system
+	// reports it, to tell the proxy from a later process that reuses its PID.
+	// It is zero where the platform does not report it.
+	ProcessStart time.Time `json:"processStart,omitempty"`
+}
+
+// Background proxy statuses, as shown by status and list.
+const (
+	daemonRunning      = "running"
+	daemonUnresponsive = "unresponsive" // the process lives but its listener does not answer
+	daemonStopped      = "stopped"      // the process is gone; the state file is stale
+)
+
+// processStartSlack absorbs the rounding in how systems report a process's
+// start time: Linux derives it from a boot time in whole seconds.
+const processStartSlack = 2 * time.Second
+
+var proxyNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func validateProxyName(name string) error {
+	if !proxyNamePattern.MatchString(name) {
+		return customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("invalid proxy name %q", name),
+			"Use letters, digits, '.', '_', and '-', starting with a letter or digit.",
+		)
+	}
+	return nil
+}
+
+// proxyStateDir returns ~/.datumctl/proxies, next to the config and
+// credentials.
+func proxyStateDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".datumctl", "proxies"), nil
+}
+
+func stateFilePath(dir, name string) string { return filepath.Join(dir, name+".json") }
+
+// loadDaemonState reads the state of the named proxy, or reports a UserError
+// if there is none or the name is invalid.
+func loadDaemonState(name string) (*daemonState, error) {
+	if err := validateProxyName(name); err != nil {
+		return nil, err
+	}
+	dir, err := proxyStateDir()
+	if err != nil {
+		return nil, err
+	}
+	state, err := readDaemonState(stateFilePath(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("No background API proxy named %q.", name),
+			"List proxies with 'datumctl api proxy list', or start one with 'datumctl api proxy start --name "+name+"'.",
+		)
+	}
+	return state, err
+}
+
+func readDaemonState(path string) (*daemonState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state daemonState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// listDaemonStates returns every recorded proxy, sorted by name.
+func listDaemonStates() ([]*daemonState, error) {
+	dir, err := proxyStateDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*daemonState, 0, len(paths))
+	for _, path := range paths {
+		state, err := readDaemonState(path)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states, nil
+}
+
+func (s *daemonState) save(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := stateFilePath(dir, "."+s.Name+".tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, stateFilePath(dir, s.Name))
+}
+
+func (s *daemonState) remove() error {
+	dir, err := proxyStateDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(stateFilePath(dir, s.Name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// status reports whether the proxy's process is alive and its listener
+// answers.
+func (s *daemonState) status() string {
+	if !s.alive() {
+		return daemonStopped
+	}
+	if !listenerAnswers(s.URL) {
+		return daemonUnresponsive
+	}
+	return daemonRunning
+}
+
+// alive reports whether the proxy's process is still running. The PID alone
+// does not tell: after the proxy exits or the machine reboots, it may belong
+// to an unrelated process, which must never be reported or signalled as the
+// proxy. So the process must also have started when the proxy did, or, where
+// that is unknown, be listening at the proxy's URL.
+func (s *daemonState) alive() bool {
+	if !processAlive(s.PID) {
+		return false
+	}
+	if s.ProcessStart.IsZero() {
+		return listenerAnswers(s.URL)
+	}
+	started, err := processStartTime(s.PID)
+	if err != nil {
+		return false
+	}
+	drift := started.Sub(s.ProcessStart)
+	return drift > -processStartSlack && drift < processStartSlack
+}
+
+// listenerAnswers reports whether something accepts connections at a proxy
+// URL (http://host:port or unix:///path).
+func listenerAnswers(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	network, address := "tcp", u.Host
+	if u.Scheme == "unix" {
+		network, address = "unix", u.Path
+	}
+	conn, err := net.DialTimeout(network, address, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// summary renders s for structured output. The local token is left out.
+func (s *daemonState) summary(status string) output.ProxySummary {
+	return output.ProxySummary{
+		Name:          s.Name,
+		Status:        status,
+		PID:           s.PID,
+		URL:           s.URL,
+		Session:       s.Session,
+		Scope:         s.Scope,
+		TokenRequired: s.Token != "",
+		LogFile:       s.LogFile,
+		StartedAt:     s.StartedAt,
+	}
+}
+
+// shellQuote single-quotes v for POSIX shells.
+func shellQuote(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	customerrors "go.datum.net/datumctl/internal/errors"
+)
+
+func TestValidateProxyName(t *testing.T) {
+	for _, name := range []string{"default", "dev", "my-proxy.2", "a_b"} {
+		if err := validateProxyName(name); err != nil {
+			t.Errorf("validateProxyName(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"", "-dev", ".hidden", "a/b", "../x", "a b"} {
+		var userErr *customerrors.UserError
+		if err := validateProxyName(name); !errors.As(err, &userErr) {
+			t.Errorf("validateProxyName(%q) = %v, want a UserError", name, err)
+		}
+	}
+}
+
+func TestDaemonStateRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir, err := proxyStateDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener := httptest.NewServer(nil)
+	defer listener.Close()
+	started := selfStartTime(t)
+	states := []*daemonState{
+		{Name: "web", PID: os.Getpid(), URL: listener.URL, Token: "secret", StartedAt: time.Now().UTC(), ProcessStart: started},
+		{Name: "dev", PID: os.Getpid(), URL: "http://127.0.0.1:1", StartedAt: time.Now().UTC(), ProcessStart: started},
+	}
+	for _, s := range states {
+		if err := s.save(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := os.Stat(stateFilePath(dir, "web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("state file mode = %o, want 600", perm)
+	}
+
+	got, err := loadDaemonState("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Token != "secret" || got.URL != listener.URL {
+		t.Errorf("loaded %+v, want the saved state", got)
+	}
+	if status := got.status(); status != daemonRunning {
+		t.Errorf("status = %q, want %q", status, daemonRunning)
+	}
+	if summary := got.summary(daemonRunning); !summary.TokenRequired {
+		t.Error("summary.TokenRequired = false for a proxy with a token")
+	}
+
+	list, err := listDaemonStates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "dev" || list[1].Name != "web" {
+		t.Fatalf("listDaemonStates = %+v, want dev then web", list)
+	}
+	if status := list[0].status(); status != daemonUnresponsive {
+		t.Errorf("status with no listener = %q, want %q", status, daemonUnresponsive)
+	}
+
+	if err := got.remove(); err != nil {
+		t.Fatal(err)
+	}
+	var userErr *customerrors.UserError
+	if _, err := loadDaemonState("web"); !errors.As(err, &userErr) {
+		t.Errorf("loadDaemonState after remove = %v, want a UserError", err)
+	}
+}
+
+func TestDaemonStatusStopped(t *testing.T) {
+	s := &daemonState{Name: "gone", PID: -1, URL: "http://127.0.0.1:1"}
+	if status := s.status(); status != daemonStopped {
+		t.Errorf("status = %q, want %q", status, daemonStopped)
+	}
+	if err := notRunningError(s.summary(daemonStopped)); err == nil {
+		t.Error("notRunningError(stopped) = nil, want an error")
+	}
+}
+
+func TestDaemonStatusReusedPID(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir, err := proxyStateDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sleeper := exec.Command("sleep", "60")
+	if err := sleeper.Start(); err != nil {
+		t.Skipf("no sleep command: %v", err)
+	}
+	defer func() {
+		_ = sleeper.Process.Kill()
+		_ = sleeper.Wait()
+	}()
+
+	// A proxy that exited long ago, whose PID now belongs to sleep.
+	listener := httptest.NewServer(nil)
+	defer listener.Close()
+	s := &daemonState{
+		Name:         "old",
+		PID:          sleeper.Process.Pid,
+		URL:          listener.URL,
+		ProcessStart: selfStartTime(t).Add(-time.Hour),
+	}
+	if err := s.save(dir); err != nil {
+		t.Fatal(err)
+	}
+	if status := s.status(); status != daemonStopped {
+		t.Errorf("status = %q, want %q", status, daemonStopped)
+	}
+	if err := stopDaemon(s); err != nil {
+		t.Fatal(err)
+	}
+	if !processAlive(sleeper.Process.Pid) {
+		t.Error("stopDaemon signalled a process that is not the proxy")
+	}
+	if _, err := os.Stat(stateFilePath(dir, "old")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("state file after stop: %v, want it removed", err)
+	}
+}
+
+func TestLoadDaemonStateRejectsInvalidName(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var userErr *customerrors.UserError
+	if _, err := loadDaemonState("../../x"); !errors.As(err, &userErr) {
+		t.Errorf("loadDaemonState(../../x) = %v, want a UserError", err)
+	}
+}
+
+// selfStartTime returns the test process's start time, skipping the test
+// where the platform does not report it.
+func selfStartTime(t *testing.T) time.Time {
+	t.Helper()
+	started, err := processStartTime(os.Getpid())
+	if err != nil {
+		t.Skipf("process start time unavailable: %v", err)
+	}
+	return started.UTC()
+}
+
+func TestForwardedFlags(t *testing.T) {
+	var opts proxyOptions
+	var name string
+	cmd := &cobra.Command{Use: "start"}
+	addProxyFlags(cmd, &opts)
+	cmd.Flags().StringVar(&name, "name", defaultProxyName, "")
+	cmd.Flags().String("project", "", "")
+	if err := cmd.ParseFlags([]string{
+		"--name", "dev", "--project", "p1", "--port", "8001", "--read-only",
+		"--mount", "/a=project:a", "--mount", "/b=project:b",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := forwardedFlags(cmd.Flags(), "name")
+	want := []string{
+		"--mount=/a=project:a", "--mount=/b=project:b",
+		"--port=8001", "--project=p1", "--read-only=true",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("forwardedFlags = %q, want %q", got, want)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got, want := shellQuote("it's"), `'it'\''s'`; got != want {
+		t.Errorf("shellQuote = %s, want %s", got, want)
+	}
+}
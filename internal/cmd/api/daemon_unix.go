@@ -0,0 +1,28 @@
+//go:build !windows
+
+package api
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the background proxy in a session of its own, so it survives
+// the terminal that started it and never receives its Ctrl+C.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// terminateProcess asks the proxy to shut down gracefully, as Ctrl+C would.
+func terminateProcess(pid int) error { return syscall.Kill(pid, syscall.SIGTERM) }
+
+func killProcess(pid int) error { return syscall.Kill(pid, syscall.SIGKILL) }
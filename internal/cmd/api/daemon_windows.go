@@ -0,0 +1,64 @@
+//go:build windows
+
+package api
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+const (
+	detachedProcess                = 0x00000008
+	processQueryLimitedInformation = 0x1000
+	stillActive                    = 259
+)
+
+// detach starts the background proxy without a console, in a process group
+// of its own, so it survives the terminal that started it.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// processStartTime returns when the process pid was created.
+func processStartTime(pid int) (time.Time, error) {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer syscall.CloseHandle(h)
+	var created, exited, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, created.Nanoseconds()), nil
+}
+
+// terminateProcess stops the proxy. Windows has no SIGTERM for a detached
+// process, so there is no graceful shutdown: open connections are cut.
+func terminateProcess(pid int) error { return killProcess(pid) }
+
+func killProcess(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
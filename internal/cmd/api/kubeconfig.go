@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	customerrors "go.datum.net/datumctl/internal/errors"
+)
+
+// proxyKubeconfigName names the cluster, user, and default context of a
+// --write-kubeconfig file. Other contexts append the local path they serve.
+const proxyKubeconfigName = "datum-proxy"
+
+// buildProxyKubeconfig returns a kubeconfig that points kube clients at the
+// proxy instead of the API: plain HTTP to the local URL, no Datum
+// credentials, and only the local token if the proxy requires one.
+//
+// There is one context per path a client can use: the root, or each --mount,
+// for the default session, and the same under /as/<session>/ for every
+// further --session. The current context is the first of them.
+func buildProxyKubeconfig(localURL, localToken string, sessions []*proxySession) *clientcmdapi.Config {
+	var prefixes []string
+	if len(sessions) > 0 {
+		for _, m := range sessions[0].mounts {
+			prefixes = append(prefixes, strings.TrimSuffix(m.prefix, "/"))
+		}
+	}
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+	paths := append([]string(nil), prefixes...)
+	for i, sess := range sessions {
+		if i == 0 {
+			continue
+		}
+		for _, prefix := range prefixes {
+			paths = append(paths, "/as/"+url.PathEscape(sess.name)+prefix)
+		}
+	}
+
+	cfg := clientcmdapi.NewConfig()
+	cfg.AuthInfos[proxyKubeconfigName] = &clientcmdapi.AuthInfo{Token: localToken}
+	for _, path := range paths {
+		name := proxyKubeconfigName + path
+		cfg.Clusters[name] = &clientcmdapi.Cluster{Server: localURL + path}
+		cfg.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: proxyKubeconfigName}
+		if cfg.CurrentContext == "" {
+			cfg.CurrentContext = name
+		}
+	}
+	return cfg
+}
+
+// writeProxyKubeconfig writes cfg to path (mode 0600). It refuses to replace
+// a file it did not write itself — one whose clusters are not all proxy
+// clusters — so a mistyped path can never destroy a real kubeconfig.
+func writeProxyKubeconfig(cfg *clientcmdapi.Config, path string) error {
+	existing, err := clientcmd.LoadFromFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil || !isProxyKubeconfig(existing):
+		return customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("--write-kubeconfig %s: the file exists and was not written by the API proxy", path),
+			"The proxy deletes its kubeconfig on shutdown, so it never reuses your own. Pick a path that does not exist yet.",
+		)
+	}
+	if err := clientcmd.WriteToFile(*cfg, path); err != nil {
+		return customerrors.WrapUserError(fmt.Sprintf("Could not write kubeconfig to %s.", path), err)
+	}
+	return nil
+}
+
+// isProxyKubeconfig reports whether cfg looks like a file left behind by a
+// proxy that did not shut down cleanly.
+func isProxyKubeconfig(cfg *clientcmdapi.Config) bool {
+	if len(cfg.Clusters) == 0 {
+		return false
+	}
+	for name := range cfg.Clusters {
+		if name != proxyKubeconfigName && !strings.HasPrefix(name, proxyKubeconfigName+"/") {
+			return false
+		}
+	}
+	return true
+}
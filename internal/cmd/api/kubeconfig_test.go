@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	customerrors "go.datum.net/datumctl/internal/errors"
+)
+
+func TestBuildProxyKubeconfig(t *testing.T) {
+	const localURL = "http://127.0.0.1:8001"
+
+	cfg := buildProxyKubeconfig(localURL, "", []*proxySession{{name: "maya"}})
+	if cfg.CurrentContext != "datum-proxy" || len(cfg.Contexts) != 1 {
+		t.Fatalf("contexts = %v (current %q), want only datum-proxy", cfg.Contexts, cfg.CurrentContext)
+	}
+	if server := cfg.Clusters["datum-proxy"].Server; server != localURL {
+		t.Errorf("server = %q, want %q", server, localURL)
+	}
+	if auth := cfg.AuthInfos["datum-proxy"]; auth.Token != "" || auth.Exec != nil {
+		t.Errorf("auth info = %+v, want no credentials", auth)
+	}
+
+	mounts := []proxyMount{{prefix: "/p/web"}, {prefix: "/p/data"}}
+	cfg = buildProxyKubeconfig(localURL, "tok", []*proxySession{
+		{name: "maya", mounts: mounts},
+		{name: "ci-bot", mounts: mounts},
+	})
+	want := map[string]string{
+		"datum-proxy/p/web":            localURL + "/p/web",
+		"datum-proxy/p/data":           localURL + "/p/data",
+		"datum-proxy/as/ci-bot/p/web":  localURL + "/as/ci-bot/p/web",
+		"datum-proxy/as/ci-bot/p/data": localURL + "/as/ci-bot/p/data",
+	}
+	if len(cfg.Clusters) != len(want) {
+		t.Errorf("clusters = %v, want %d", cfg.Clusters, len(want))
+	}
+	for name, server := range want {
+		if c := cfg.Clusters[name]; c == nil || c.Server != server {
+			t.Errorf("cluster %s = %+v, want server %s", name, c, server)
+		}
+		if ctx := cfg.Contexts[name]; ctx == nil || ctx.Cluster != name {
+			t.Errorf("context %s = %+v, want cluster %s", name, ctx, name)
+		}
+	}
+	if cfg.CurrentContext != "datum-proxy/p/web" {
+		t.Errorf("current context = %q, want the first mount", cfg.CurrentContext)
+	}
+	if token := cfg.AuthInfos["datum-proxy"].Token; token != "tok" {
+		t.Errorf("token = %q, want the local token", token)
+	}
+}
+
+func TestWriteProxyKubeconfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kubeconfig")
+	cfg := buildProxyKubeconfig("http://127.0.0.1:8001", "", nil)
+
+	if err := writeProxyKubeconfig(cfg, path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Clusters["datum-proxy"].Server != "http://127.0.0.1:8001" {
+		t.Errorf("written kubeconfig = %+v", loaded.Clusters)
+	}
+	// A file left behind by an earlier proxy is replaced.
+	if err := writeProxyKubeconfig(buildProxyKubeconfig("http://127.0.0.1:9001", "", nil), path); err != nil {
+		t.Errorf("replacing a stale proxy kubeconfig: %v", err)
+	}
+
+	// Anything else is left alone.
+	own := filepath.Join(dir, "config")
+	original := "apiVersion: v1\nkind: Config\nclusters:\n- name: prod\n  cluster:\n    server: https://prod.example.com\n"
+	if err := os.WriteFile(own, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var userErr *customerrors.UserError
+	if err := writeProxyKubeconfig(cfg, own); !errors.As(err, &userErr) {
+		t.Errorf("writing over a real kubeconfig = %v, want a UserError", err)
+	}
+	if data, _ := os.ReadFile(own); string(data) != original {
+		t.Errorf("real kubeconfig was modified:\n%s", data)
+	}
+}
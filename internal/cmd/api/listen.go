@@ -0,0 +1,118 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	customerrors "go.datum.net/datumctl/internal/errors"
+)
+
+// localTokenBytes is the entropy of a --require-token token.
+const localTokenBytes = 32
+
+// listenLoopback binds the proxy's TCP listener. Loopback only, by design:
+// there is no flag to bind other addresses.
+func listenLoopback(port int) (net.Listener, string, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		if port != 0 {
+			return nil, "", customerrors.WrapUserErrorWithHint(
+				fmt.Sprintf("Could not listen on 127.0.0.1:%d.", port),
+				"The port may already be in use — pass a different --port, or omit --port to pick a random free port.",
+				err,
+			)
+		}
+		return nil, "", customerrors.WrapUserError("Could not open a local listener on 127.0.0.1.", err)
+	}
+	return listener, "http://" + listener.Addr().String(), nil
+}
+
+// listenMetrics binds the --metrics-addr listener. Unlike the proxy itself it
+// may listen beyond loopback, so a sidecar can be scraped: metrics carry no
+// credentials and no request paths.
+func listenMetrics(addr string) (net.Listener, string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", customerrors.WrapUserErrorWithHint(
+			fmt.Sprintf("Could not listen for metrics on %s.", addr),
+			"Pass a free host:port to --metrics-addr, e.g. 127.0.0.1:9464.",
+			err,
+		)
+	}
+	return listener, "http://" + listener.Addr().String() + "/metrics", nil
+}
+
+// listenUnix binds the proxy to a unix domain socket at path, readable and
+// writable by the owner only. A stale socket left by a proxy that did not
+// shut down cleanly is replaced; a live one, or any other file, is not.
+func listenUnix(path string) (net.Listener, string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, "", customerrors.WrapUserError(fmt.Sprintf("Invalid socket path %q.", path), err)
+	}
+	if err := removeStaleSocket(abs); err != nil {
+		return nil, "", err
+	}
+
+	// Create the socket with owner-only permissions from the start, so there
+	// is no window in which another user could connect.
+	var listener net.Listener
+	err = withOwnerOnlyUmask(func() error {
+		var listenErr error
+		listener, listenErr = net.Listen("unix", abs)
+		return listenErr
+	})
+	if err != nil {
+		return nil, "", customerrors.WrapUserErrorWithHint(
+			fmt.Sprintf("Could not listen on unix socket %s.", abs),
+			"Check that the directory exists and is writable, and that the path is short enough for a socket.",
+			err,
+		)
+	}
+	if err := os.Chmod(abs, 0o600); err != nil {
+		listener.Close()
+		return nil, "", customerrors.WrapUserError(fmt.Sprintf("Could not restrict permissions on %s.", abs), err)
+	}
+	return listener, "unix://" + abs, nil
+}
+
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return customerrors.WrapUserError(fmt.Sprintf("Could not inspect %s.", path), err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("%s already exists and is not a socket.", path),
+			"Choose a different --socket path.",
+		)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("Another process is already listening on %s.", path),
+			"Stop the other proxy, or choose a different --socket path.",
+		)
+	}
+	if err := os.Remove(path); err != nil {
+		return customerrors.WrapUserError(fmt.Sprintf("Could not remove stale socket %s.", path), err)
+	}
+	return nil
+}
+
+// newLocalToken returns a random token for --require-token.
+func newLocalToken() (string, error) {
+	b := make([]byte, localTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", customerrors.WrapUserError("Could not generate a proxy token.", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"go.datum.net/datumctl/internal/apiproxy"
+	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/miloapi"
+)
+
+// proxyMount is one resolved --mount: a local prefix, the control plane it
+// serves, and that control plane's URL.
+type proxyMount struct {
+	prefix   string
+	scope    string
+	upstream *url.URL
+}
+
+const mountFormatHint = "Use --mount PREFIX=project:<id>, PREFIX=organization:<id>, or PREFIX=endpoint, e.g. --mount /p/web=project:web."
+
+// resolveMounts parses --mount specs against the session's endpoint. Like
+// --project and --organization, mounts are explicit: nothing is inherited
+// from the active context.
+func resolveMounts(baseServer string, specs []string) ([]proxyMount, error) {
+	mounts := make([]proxyMount, 0, len(specs))
+	seen := map[string]string{}
+	for _, spec := range specs {
+		m, err := parseMount(baseServer, spec)
+		if err != nil {
+			return nil, err
+		}
+		if previous, dup := seen[m.prefix]; dup {
+			return nil, customerrors.NewUserErrorWithHint(
+				fmt.Sprintf("--mount %q and --mount %q use the same prefix %s", previous, spec, m.prefix),
+				"Give each mount a distinct prefix.",
+			)
+		}
+		seen[m.prefix] = spec
+		mounts = append(mounts, m)
+	}
+	return mounts, nil
+}
+
+func parseMount(baseServer, spec string) (proxyMount, error) {
+	prefix, target, ok := strings.Cut(spec, "=")
+	if !ok {
+		return proxyMount{}, customerrors.NewUserErrorWithHint(fmt.Sprintf("invalid --mount %q: missing '='", spec), mountFormatHint)
+	}
+	prefix, err := apiproxy.NormalizeMountPrefix(prefix)
+	if err != nil {
+		return proxyMount{}, customerrors.NewUserErrorWithHint(fmt.Sprintf("invalid --mount %q: %v", spec, err), mountFormatHint)
+	}
+
+	kind, id, _ := strings.Cut(target, ":")
+	var upstream, scope string
+	switch kind {
+	case "project":
+		upstream, scope = miloapi.ProjectControlPlaneURL(baseServer, id), "project "+id
+	case "organization", "org":
+		upstream, scope = miloapi.OrgControlPlaneURL(baseServer, id), "organization "+id
+	case "endpoint":
+		if id != "" {
+			return proxyMount{}, customerrors.NewUserErrorWithHint(fmt.Sprintf("invalid --mount %q: endpoint takes no ID", spec), mountFormatHint)
+		}
+		upstream, scope = baseServer, "full endpoint"
+	default:
+		return proxyMount{}, customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("invalid --mount %q: unknown target %q", spec, target), mountFormatHint)
+	}
+	if kind != "endpoint" && id == "" {
+		return proxyMount{}, customerrors.NewUserErrorWithHint(fmt.Sprintf("invalid --mount %q: missing %s ID", spec, kind), mountFormatHint)
+	}
+
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		return proxyMount{}, customerrors.WrapUserErrorWithHint(
+			fmt.Sprintf("--mount %q produced an invalid upstream URL (%q).", spec, upstream),
+			"Check the ID, or run 'datumctl login' again to refresh the session's endpoint.",
+			err,
+		)
+	}
+	return proxyMount{prefix: prefix, scope: scope, upstream: upstreamURL}, nil
+}
+
+// printMounts lists the mounts in the startup banner, in flag order, with
+// their upstream URLs when showUpstream is set.
+func printMounts(w io.Writer, mounts []proxyMount, showUpstream bool) {
+	width := 0
+	for _, m := range mounts {
+		width = max(width, len(m.prefix))
+	}
+	for i, m := range mounts {
+		label := "  Mounts:    "
+		if i > 0 {
+			label = "             "
+		}
+		if showUpstream {
+			fmt.Fprintf(w, "%s %-*s  %s (%s)\n", label, width, m.prefix, m.scope, m.upstream)
+		} else {
+			fmt.Fprintf(w, "%s %-*s  %s\n", label, width, m.prefix, m.scope)
+		}
+	}
+}
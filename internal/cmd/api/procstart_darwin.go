@@ -0,0 +1,17 @@
+package api
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// processStartTime returns when the process pid started.
+func processStartTime(pid int) (time.Time, error) {
+	info, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	start := info.Proc.P_starttime
+	return time.Unix(int64(start.Sec), int64(start.Usec)*int64(time.Microsecond)), nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+
+package api
+
+import (
+	"errors"
+	"time"
+)
+
+// processStartTime is unknown on this platform; background proxies are
+// identified by their listener instead.
+func processStartTime(pid int) (time.Time, error) {
+	return time.Time{}, errors.ErrUnsupported
+}
@@ -2,15 +2,16 @@ package api
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -30,11 +31,7 @@ import (
 const shutdownGrace = 2 * time.Second
 
 func proxyCommand(factory *client.DatumCloudFactory) *cobra.Command {
-	var (
-		port        int
-		sessionName string
-		quiet       bool
-	)
+	var opts proxyOptions
 
 	cmd := &cobra.Command{
 		Use:   "proxy",
@@ -47,12 +44,64 @@ func proxyCommand(factory *client.DatumCloudFactory) *cobra.Command {
 			and refreshing them as needed. Point any local tool at the printed URL —
 			no tokens to copy, no expiry to manage.
 
+			On a shared machine, pass --socket to serve on a unix domain socket only
+			your user can open, and/or --require-token to make local clients present
+			a random bearer token generated for this run.
+
+			To hand the proxy to a tool that should not change anything, pass
+			--read-only: only GET and HEAD requests (including watches) are
+			forwarded. For finer control, --policy takes a YAML file of allow and
+			deny rules on verb, API group, resource, and namespace. Requests either
+			flag rejects get a 403 without ever reaching the API.
+
+			When running the proxy as a long-lived sidecar, --log-format json writes
+			one JSON object per log line, and --metrics-addr serves Prometheus
+			metrics: request counts, latencies, open streams, proxy-synthesized
+			errors, and token refresh failures.
+
+			For read-heavy local tools that poll the same endpoints, --cache-ttl
+			serves repeated GETs from memory for that long and merges identical
+			concurrent GETs into one upstream request. Any create, update, patch, or
+			delete through the proxy empties the cache, and watches are never
+			cached.
+
 			By default the proxy serves the full API endpoint, so requests use the
 			same paths as the real API. Pass --project or --organization to serve a
-			single control plane instead, with shorter paths.
+			single control plane instead, with shorter paths. To serve several
+			control planes from one port, repeat --mount PREFIX=TARGET, where TARGET
+			is project:<id>, organization:<id>, or endpoint; each control plane is
+			then reachable under its own local path prefix.
 
 			The session and scope are pinned when the proxy starts. Switching your
-			active account or context does not affect a running proxy.`),
+			active account or context does not affect a running proxy.
+
+			Repeat --session to serve several sessions from one proxy, for example
+			an admin and a limited service account. Each request selects a session
+			with an X-Datum-Session header or an /as/<session>/ path prefix;
+			requests that name none use the first --session.
+
+			To build hermetic test fixtures, run with --record DIR while your tests
+			talk to the real API: every request and response, including watch event
+			streams and their timing, is saved to DIR with credentials left out.
+			Later, --replay DIR serves those responses back with no session, no
+			credentials, and no network, matching requests on method, path, query,
+			and body.
+
+			Kube clients that cannot run the exec credential plugin set up by
+			'datumctl auth update-kubeconfig' (older client-go versions, some GUIs)
+			can use the proxy instead: --write-kubeconfig PATH writes a kubeconfig
+			whose server is the proxy URL, with no Datum credentials in it, and
+			deletes it when the proxy stops.
+
+			To test how a client copes with a slow or failing API, --faults takes a
+			YAML file of rules that delay requests, answer them with error
+			statuses (with Retry-After) or conflicts, or drop watches after a
+			number of events. Every injected fault is logged and marked with an
+			X-Datum-Proxy-Fault response header.
+
+			To keep a proxy running after the terminal closes, use 'datumctl api
+			proxy start' with the same flags and a --name, then manage it with
+			'status', 'list', 'env', and 'stop'.`),
 		Example: templates.Examples(`
 			# Start a proxy on a fixed port for a dev server
 			datumctl api proxy --port 8001
@@ -71,19 +120,143 @@ func proxyCommand(factory *client.DatumCloudFactory) *cobra.Command {
 			curl "http://127.0.0.1:8001/apis/networking.datumapis.com/v1alpha/dnszones?watch=true"
 
 			# Pin a non-active session
-			datumctl api proxy --session sam@datum.net@api.staging.env.datum.net`),
+			datumctl api proxy --session sam@datum.net@api.staging.env.datum.net
+
+			# Act as two sessions from one proxy, chosen per request
+			datumctl api proxy --port 8001 --project my-project \
+			  --session maya@datum.net@api.datum.net --session ci-bot@datum.net@api.datum.net
+			curl -H "X-Datum-Session: ci-bot@datum.net@api.datum.net" http://127.0.0.1:8001/apis/networking.datumapis.com/v1alpha/dnszones
+			curl http://127.0.0.1:8001/as/ci-bot@datum.net@api.datum.net/apis/networking.datumapis.com/v1alpha/dnszones
+
+			# Serve on an owner-only unix socket instead of a TCP port
+			datumctl api proxy --socket ~/.datumctl/proxy.sock
+			curl --unix-socket ~/.datumctl/proxy.sock http://localhost/apis/resourcemanager.miloapis.com/v1alpha1/organizations
+
+			# Require a per-run bearer token from local clients
+			datumctl api proxy --port 8001 --require-token
+
+			# Let a dashboard read, but never change, anything
+			datumctl api proxy --read-only
+
+			# Run as a sidecar: JSON logs and a Prometheus endpoint
+			datumctl api proxy --port 8001 --log-format json --metrics-addr 127.0.0.1:9464
+
+			# Absorb a dashboard's polling with a short cache
+			datumctl api proxy --port 8001 --cache-ttl 5s
+
+			# Restrict clients with allow/deny rules
+			datumctl api proxy --policy proxy-policy.yaml
+
+			# Serve two projects and an organization from one port
+			datumctl api proxy --port 8001 \
+			  --mount /p/web=project:web --mount /p/data=project:data \
+			  --mount /org=organization:acme
+			curl http://127.0.0.1:8001/p/web/apis/networking.datumapis.com/v1alpha/dnszones
+
+			# Record fixtures against the real API, then replay them offline in CI
+			datumctl api proxy --port 8001 --project my-project --record testdata/api
+			datumctl api proxy --port 8001 --replay testdata/api
+
+			# Test a controller against a flaky API
+			datumctl api proxy --port 8001 --project my-project --faults faults.yaml
+
+			# Give kubectl a kubeconfig that goes through the proxy
+			datumctl api proxy --port 8001 --project my-project --write-kubeconfig /tmp/datum-proxy.kubeconfig
+			kubectl --kubeconfig /tmp/datum-proxy.kubeconfig get dnszones
+
+			# Run in the background and point the current shell at it
+			datumctl api proxy start --name dev --project my-project
+			eval "$(datumctl api proxy env dev)"`),
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runProxy(cmd, factory, port, sessionName, quiet)
+			if err := opts.validate(cmd); err != nil {
+				return err
+			}
+			return runProxy(cmd, factory, opts)
 		},
 	}
 
-	cmd.Flags().IntVar(&port, "port", 0, "Local port to listen on (default: a random free port)")
-	cmd.Flags().StringVar(&sessionName, "session", "", "Pin a specific session by name (defaults to the active session; see 'datumctl auth list')")
-	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Suppress per-request log lines")
+	addProxyFlags(cmd, &opts)
+	cmd.AddCommand(
+		startCommand(factory),
+		stopCommand(),
+		statusCommand(),
+		listCommand(),
+		envCommand(),
+	)
 	return cmd
 }
 
+// addProxyFlags registers the flags that configure a proxy, shared by
+// 'api proxy' and 'api proxy start'.
+func addProxyFlags(cmd *cobra.Command, opts *proxyOptions) {
+	cmd.Flags().IntVar(&opts.port, "port", 0, "Local port to listen on (default: a random free port)")
+	cmd.Flags().StringVar(&opts.socket, "socket", "", "Listen on a unix domain socket at this path (mode 0600) instead of a TCP port")
+	cmd.Flags().BoolVar(&opts.requireToken, "require-token", false, "Generate a random bearer token that local clients must send; printed at startup")
+	cmd.Flags().BoolVar(&opts.readOnly, "read-only", false, "Reject every request except GET and HEAD (reads and watches)")
+	cmd.Flags().StringVar(&opts.policyFile, "policy", "", "YAML file of allow/deny rules on verb, API group, resource, and namespace")
+	cmd.Flags().StringVar(&opts.faultsFile, "faults", "", "YAML file of fault rules: inject latency, error statuses, conflicts, and dropped watches, for testing clients")
+	cmd.Flags().StringArrayVar(&opts.mounts, "mount", nil, "Serve a control plane under a local path prefix, as PREFIX=project:<id>, PREFIX=organization:<id>, or PREFIX=endpoint (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.sessions, "session", nil, "Pin a specific session by name (defaults to the active session; see 'datumctl auth list'). Repeat to serve several sessions, selected per request")
+	cmd.Flags().DurationVar(&opts.cacheTTL, "cache-ttl", 0, "Cache GET responses for this long and coalesce identical concurrent GETs (e.g. 5s; watches are never cached)")
+	cmd.Flags().StringVar(&opts.recordDir, "record", "", "Record every request and response (including watch streams, with timing) as fixtures in this directory")
+	cmd.Flags().StringVar(&opts.replayDir, "replay", "", "Serve recorded fixtures from this directory instead of the API; needs no session or network")
+	cmd.Flags().StringVar(&opts.logFormat, "log-format", "text", "Log format on stderr: text, or json for one object per line")
+	cmd.Flags().StringVar(&opts.metricsAddr, "metrics-addr", "", "Serve Prometheus metrics at http://ADDR/metrics, e.g. 127.0.0.1:9464")
+	cmd.Flags().StringVar(&opts.kubeconfig, "write-kubeconfig", "", "Write a kubeconfig pointing at the proxy to this path, for kube clients that cannot run exec plugins; removed on shutdown")
+	cmd.Flags().BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress per-request log lines")
+}
+
+// proxyOptions holds the flags of 'datumctl api proxy'.
+type proxyOptions struct {
+	port         int
+	socket       string
+	requireToken bool
+	readOnly     bool
+	policyFile   string
+	faultsFile   string
+	mounts       []string
+	sessions     []string
+	cacheTTL     time.Duration
+	recordDir    string
+	replayDir    string
+	logFormat    string
+	metricsAddr  string
+	kubeconfig   string
+	quiet        bool
+}
+
+// validate checks flag combinations that need no config or network.
+func (opts proxyOptions) validate(cmd *cobra.Command) error {
+	if opts.socket != "" && cmd.Flags().Changed("port") {
+		return customerrors.NewUserError("only one of --port or --socket may be set")
+	}
+	switch apiproxy.LogFormat(opts.logFormat) {
+	case apiproxy.LogFormatText, apiproxy.LogFormatJSON:
+	default:
+		return customerrors.NewUserError(fmt.Sprintf("unknown --log-format %q (want text or json)", opts.logFormat))
+	}
+	if opts.cacheTTL < 0 {
+		return customerrors.NewUserError("--cache-ttl cannot be negative")
+	}
+	if opts.recordDir != "" && opts.replayDir != "" {
+		return customerrors.NewUserError("only one of --record or --replay may be set")
+	}
+	if opts.faultsFile != "" && opts.recordDir != "" {
+		return customerrors.NewUserErrorWithHint(
+			"--faults cannot be used with --record",
+			"Record fixtures without faults, then inject faults while replaying them with --replay.",
+		)
+	}
+	if opts.kubeconfig != "" && opts.socket != "" {
+		return customerrors.NewUserErrorWithHint(
+			"--write-kubeconfig cannot be used with --socket",
+			"Kube clients cannot connect to a unix socket; serve on a TCP --port instead.",
+		)
+	}
+	return nil
+}
+
 // proxyTarget is everything about the upstream that gets pinned when the
 // proxy starts: the session it serves, the resolved endpoint identity, the
 // upstream root, and the human-readable scope shown in the banner.
@@ -150,30 +323,6 @@ func resolveProxyTarget(cfg *datumconfig.ConfigV1Beta1, sessionName, project, or
 	}, nil
 }
 
-// tlsClientConfig converts the session endpoint's TLS settings into a
-// *tls.Config for the upstream transport. Returns nil when the endpoint
-// declares nothing, keeping Go's defaults.
-func tlsClientConfig(epTLS client.EndpointTLS) (*tls.Config, error) {
-	if epTLS.ServerName == "" && !epTLS.InsecureSkipTLSVerify && len(epTLS.CAData) == 0 {
-		return nil, nil
-	}
-	cfg := &tls.Config{
-		ServerName:         epTLS.ServerName,
-		InsecureSkipVerify: epTLS.InsecureSkipTLSVerify,
-	}
-	if len(epTLS.CAData) > 0 {
-		pool := x509.NewCertPool()
-		if !pool.AppendCertsFromPEM(epTLS.CAData) {
-			return nil, customerrors.NewUserErrorWithHint(
-				"No certificates could be parsed from the session's certificate authority data.",
-				"Run 'datumctl login' again to refresh the session's endpoint settings.",
-			)
-		}
-		cfg.RootCAs = pool
-	}
-	return cfg, nil
-}
-
 // sessionLabel names the identity the proxy serves, for the startup banner.
 func sessionLabel(target *proxyTarget) string {
 	if target.session != nil {
@@ -182,64 +331,91 @@ func sessionLabel(target *proxyTarget) string {
 	return target.endpoint.UserKey
 }
 
-func runProxy(cmd *cobra.Command, factory *client.DatumCloudFactory, port int, sessionName string, quiet bool) error {
-	cfg, err := datumconfig.LoadAuto()
-	if err != nil {
-		return err
-	}
-	if err := authutil.EnsureUserKeysMigrated(cfg); err != nil {
-		return err
+func runProxy(cmd *cobra.Command, factory *client.DatumCloudFactory, opts proxyOptions) error {
+	var err error
+	var policy *apiproxy.Policy
+	if opts.policyFile != "" {
+		if policy, err = loadPolicy(opts.policyFile); err != nil {
+			return err
+		}
 	}
 
-	flags := factory.ConfigFlags
-	target, err := resolveProxyTarget(cfg, sessionName,
-		stringValue(flags.Project), stringValue(flags.Organization), boolValue(flags.PlatformWide))
-	if err != nil {
-		return err
+	var faults *apiproxy.Faults
+	if opts.faultsFile != "" {
+		if faults, err = loadFaults(opts.faultsFile); err != nil {
+			return err
+		}
 	}
 
-	tokenSource, err := authutil.GetTokenSourceForUser(cmd.Context(), target.endpoint.UserKey)
-	if err != nil {
-		if _, isUser := customerrors.IsUserError(err); isUser {
+	var localToken string
+	if opts.requireToken {
+		if localToken, err = newLocalToken(); err != nil {
 			return err
 		}
-		return customerrors.WrapUserErrorWithHint(
-			fmt.Sprintf("No stored credentials found for %s.", sessionLabel(target)),
-			"Run 'datumctl login' to authenticate, then start the proxy again.",
-			err,
-		)
 	}
 
-	tlsConfig, err := tlsClientConfig(target.endpoint.TLS)
-	if err != nil {
-		return err
+	errOut := cmd.ErrOrStderr()
+	proxyConfig := apiproxy.Config{
+		LogWriter:  errOut,
+		LogFormat:  apiproxy.LogFormat(opts.logFormat),
+		Quiet:      opts.quiet,
+		LocalToken: localToken,
+		ReadOnly:   opts.readOnly,
+		Policy:     policy,
+		Faults:     faults,
+		CacheTTL:   opts.cacheTTL,
 	}
 
-	errOut := cmd.ErrOrStderr()
-	server, err := apiproxy.New(apiproxy.Config{
-		Upstream:        target.upstream,
-		TokenSource:     tokenSource,
-		TLSClientConfig: tlsConfig,
-		LogWriter:       errOut,
-		Quiet:           quiet,
-	})
+	var sessions []*proxySession
+	if opts.replayDir != "" {
+		if proxyConfig.Replay, err = loadReplay(factory, opts); err != nil {
+			return err
+		}
+	} else {
+		if sessions, err = prepareSessions(cmd.Context(), factory, opts); err != nil {
+			return err
+		}
+		configureSessions(&proxyConfig, sessions)
+		if opts.recordDir != "" {
+			if proxyConfig.Recorder, err = apiproxy.NewRecorder(opts.recordDir); err != nil {
+				return customerrors.WrapUserError(fmt.Sprintf("Could not create record directory %s.", opts.recordDir), err)
+			}
+		}
+	}
+	if opts.metricsAddr != "" {
+		proxyConfig.Metrics = apiproxy.NewMetrics()
+	}
+	server, err := apiproxy.New(proxyConfig)
 	if err != nil {
 		return err
 	}
 
-	// Loopback only, by design: there is no flag to bind other addresses.
-	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
-	if err != nil {
-		if port != 0 {
-			return customerrors.WrapUserErrorWithHint(
-				fmt.Sprintf("Could not listen on 127.0.0.1:%d.", port),
-				"The port may already be in use — pass a different --port, or omit --port to pick a random free port.",
-				err,
-			)
+	var metricsURL string
+	if proxyConfig.Metrics != nil {
+		metricsListener, u, err := listenMetrics(opts.metricsAddr)
+		if err != nil {
+			return err
 		}
-		return customerrors.WrapUserError("Could not open a local listener on 127.0.0.1.", err)
+		metricsURL = u
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", proxyConfig.Metrics.Handler())
+		metricsServer := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() { _ = metricsServer.Serve(metricsListener) }()
+		defer metricsServer.Close()
+	}
+
+	var (
+		listener net.Listener
+		localURL string
+	)
+	if opts.socket != "" {
+		listener, localURL, err = listenUnix(opts.socket)
+	} else {
+		listener, localURL, err = listenLoopback(opts.port)
+	}
+	if err != nil {
+		return err
 	}
-	localURL := "http://" + listener.Addr().String()
 
 	// Register the signal handler before advertising readiness, so a harness
 	// that reads the URL line and later interrupts the proxy can never signal
@@ -248,21 +424,60 @@ func runProxy(cmd *cobra.Command, factory *client.DatumCloudFactory, port int, s
 	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(signals)
 
-	fmt.Fprintf(errOut, "  Session:    %s\n", sessionLabel(target))
-	fmt.Fprintf(errOut, "  Upstream:   %s\n", target.upstream)
-	fmt.Fprintf(errOut, "  Scope:      %s\n", target.scope)
-	fmt.Fprintf(errOut, "  Listening:  %s\n", localURL)
-	fmt.Fprintln(errOut)
-	if quiet {
-		fmt.Fprintln(errOut, "  Press Ctrl+C to stop.")
+	// The kubeconfig is written before readiness is advertised, so it is in
+	// place by the time a harness sees the URL, and removed however the
+	// proxy stops short of a second Ctrl+C or a kill.
+	if opts.kubeconfig != "" {
+		if err := writeProxyKubeconfig(buildProxyKubeconfig(localURL, localToken, sessions), opts.kubeconfig); err != nil {
+			listener.Close()
+			return err
+		}
+		defer os.Remove(opts.kubeconfig)
+	}
+
+	jsonLog := proxyConfig.LogFormat == apiproxy.LogFormatJSON
+	if jsonLog {
+		logStartup(errOut, localURL, metricsURL, localToken != "", sessions, opts)
 	} else {
-		fmt.Fprintln(errOut, "  Press Ctrl+C to stop. Requests are logged below (silence with --quiet).")
+		if proxyConfig.Replay != nil {
+			fmt.Fprintf(errOut, "  Replaying:  %s (%d recorded responses; no upstream, no credentials)\n", opts.replayDir, proxyConfig.Replay.Len())
+			if access := accessLabel(opts); access != "" {
+				fmt.Fprintf(errOut, "  Access:     %s\n", access)
+			}
+		} else {
+			printBanner(errOut, sessions, opts)
+		}
+		if faults != nil {
+			fmt.Fprintf(errOut, "  Faults:     %d rules from %s (affected responses carry %s)\n", len(faults.Rules), opts.faultsFile, apiproxy.FaultHeader)
+		}
+		fmt.Fprintf(errOut, "  Listening:  %s\n", localURL)
+		if localToken != "" {
+			fmt.Fprintf(errOut, "  Token:      %s\n", localToken)
+			fmt.Fprintln(errOut, "              (send as 'Authorization: Bearer <token>'; valid until the proxy stops)")
+		}
+		if metricsURL != "" {
+			fmt.Fprintf(errOut, "  Metrics:    %s\n", metricsURL)
+		}
+		if opts.kubeconfig != "" {
+			fmt.Fprintf(errOut, "  Kubeconfig: %s (removed on shutdown)\n", opts.kubeconfig)
+		}
+		fmt.Fprintln(errOut)
+		if opts.quiet {
+			fmt.Fprintln(errOut, "  Press Ctrl+C to stop.")
+		} else {
+			fmt.Fprintln(errOut, "  Press Ctrl+C to stop. Requests are logged below (silence with --quiet).")
+		}
+		fmt.Fprintln(errOut)
 	}
-	fmt.Fprintln(errOut)
 
-	// Machine-readable readiness contract: the bare URL is the first and only
-	// stdout line, printed only after the listener is bound.
+	// Machine-readable readiness contract: the bare URL is the first stdout
+	// line, printed only after the listener is bound. With --require-token
+	// the token follows as the second and last line; otherwise the URL is
+	// the only one.
 	fmt.Fprintln(cmd.OutOrStdout(), localURL)
+	if localToken != "" {
+		fmt.Fprintln(cmd.OutOrStdout(), localToken)
+	}
 
 	serveErr := make(chan error, 1)
 	go func() { serveErr <- server.Serve(listener) }()
@@ -279,7 +494,11 @@ func runProxy(cmd *cobra.Command, factory *client.DatumCloudFactory, port int, s
 	// Graceful shutdown: the listener closes and in-flight requests get the
 	// grace period before their connections are cut. A second signal exits
 	// immediately.
-	fmt.Fprintln(errOut, "Shutting down...")
+	if jsonLog {
+		writeJSONLog(errOut, "shutting_down", nil)
+	} else {
+		fmt.Fprintln(errOut, "Shutting down...")
+	}
 	shutdownDone := make(chan struct{})
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
@@ -295,6 +514,191 @@ func runProxy(cmd *cobra.Command, factory *client.DatumCloudFactory, port int, s
 	return nil
 }
 
+// logStartup is the --log-format json counterpart of the startup banner: one
+// "listening" event. The local token is deliberately left out — logs get
+// shipped; stdout carries the token for whoever started the proxy.
+func logStartup(w io.Writer, localURL, metricsURL string, tokenRequired bool, sessions []*proxySession, opts proxyOptions) {
+	fields := map[string]any{"url": localURL, "tokenRequired": tokenRequired}
+	if metricsURL != "" {
+		fields["metrics"] = metricsURL
+	}
+	if opts.replayDir != "" {
+		fields["replay"] = opts.replayDir
+	}
+	if opts.recordDir != "" {
+		fields["record"] = opts.recordDir
+	}
+	if access := accessLabel(opts); access != "" {
+		fields["access"] = access
+	}
+	if opts.cacheTTL > 0 {
+		fields["cacheTTL"] = opts.cacheTTL.String()
+	}
+	if opts.kubeconfig != "" {
+		fields["kubeconfig"] = opts.kubeconfig
+	}
+	if opts.faultsFile != "" {
+		fields["faults"] = opts.faultsFile
+	}
+	if len(sessions) > 0 {
+		names := make([]string, 0, len(sessions))
+		for _, sess := range sessions {
+			names = append(names, sess.name)
+		}
+		fields["sessions"] = names
+		if len(sessions[0].mounts) == 0 {
+			fields["upstream"] = sessions[0].target.upstream.String()
+			fields["scope"] = sessions[0].target.scope
+		} else {
+			mounts := map[string]string{}
+			for _, m := range sessions[0].mounts {
+				mounts[m.prefix] = m.scope
+			}
+			fields["mounts"] = mounts
+		}
+	}
+	writeJSONLog(w, "listening", fields)
+}
+
+// writeJSONLog writes one line in the proxy's JSON log format.
+func writeJSONLog(w io.Writer, event string, fields map[string]any) {
+	entry := map[string]any{"time": time.Now().UTC().Format(time.RFC3339Nano), "event": event}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	line, _ := json.Marshal(entry)
+	fmt.Fprintf(w, "%s\n", line)
+}
+
+// prepareSessions resolves every session the proxy serves, pinning each one's
+// target, credentials, and TLS settings at startup.
+func prepareSessions(ctx context.Context, factory *client.DatumCloudFactory, opts proxyOptions) ([]*proxySession, error) {
+	cfg, err := datumconfig.LoadAuto()
+	if err != nil {
+		return nil, err
+	}
+	if err := authutil.EnsureUserKeysMigrated(cfg); err != nil {
+		return nil, err
+	}
+
+	flags := factory.ConfigFlags
+	project, organization, platformWide := stringValue(flags.Project), stringValue(flags.Organization), boolValue(flags.PlatformWide)
+	if len(opts.mounts) > 0 && (project != "" || organization != "" || platformWide) {
+		return nil, customerrors.NewUserErrorWithHint(
+			"--mount cannot be used with --project, --organization, or --platform-wide",
+			"Give each control plane its own --mount instead, e.g. --mount /p/web=project:web.",
+		)
+	}
+	sessionNames := opts.sessions
+	if len(sessionNames) == 0 {
+		sessionNames = []string{""} // the active session
+	}
+	var sessions []*proxySession
+	seen := map[string]bool{}
+	for _, name := range sessionNames {
+		sess, err := prepareSession(ctx, cfg, name, project, organization, platformWide, opts.mounts)
+		if err != nil {
+			return nil, err
+		}
+		if seen[sess.name] {
+			return nil, customerrors.NewUserError(fmt.Sprintf("--session %s given more than once", sess.name))
+		}
+		seen[sess.name] = true
+		sessions = append(sessions, sess)
+	}
+	return sessions, nil
+}
+
+// configureSessions routes proxyConfig to sessions: the single-session
+// fields for one, Sessions for several.
+func configureSessions(proxyConfig *apiproxy.Config, sessions []*proxySession) {
+	if len(sessions) == 1 {
+		sess := sessions[0]
+		proxyConfig.Upstream, proxyConfig.Mounts = sess.upstream()
+		proxyConfig.TokenSource = sess.tokenSource
+		proxyConfig.TLSClientConfig = sess.tlsConfig
+		return
+	}
+	for _, sess := range sessions {
+		upstream, mounts := sess.upstream()
+		proxyConfig.Sessions = append(proxyConfig.Sessions, apiproxy.Session{
+			Name:            sess.name,
+			Upstream:        upstream,
+			Mounts:          mounts,
+			TokenSource:     sess.tokenSource,
+			TLSClientConfig: sess.tlsConfig,
+		})
+	}
+}
+
+// loadReplay loads a --replay fixture directory. A replaying proxy has no
+// upstream, so every flag that picks one is rejected rather than ignored.
+func loadReplay(factory *client.DatumCloudFactory, opts proxyOptions) (*apiproxy.Replayer, error) {
+	flags := factory.ConfigFlags
+	if len(opts.sessions) > 0 || len(opts.mounts) > 0 ||
+		stringValue(flags.Project) != "" || stringValue(flags.Organization) != "" || boolValue(flags.PlatformWide) {
+		return nil, customerrors.NewUserErrorWithHint(
+			"--replay cannot be used with --session, --mount, --project, --organization, or --platform-wide",
+			"Recorded paths already carry any mount or /as/<session>/ prefix; start the replaying proxy with no upstream flags.",
+		)
+	}
+	replayer, err := apiproxy.NewReplayer(opts.replayDir)
+	if err != nil {
+		return nil, customerrors.WrapUserErrorWithHint(
+			fmt.Sprintf("Could not load recorded responses from %s: %v", opts.replayDir, err),
+			"Record fixtures first with 'datumctl api proxy --record <dir>'.",
+			err,
+		)
+	}
+	return replayer, nil
+}
+
+// loadPolicy reads and parses a --policy file.
+func loadPolicy(path string) (*apiproxy.Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, customerrors.WrapUserError(fmt.Sprintf("Could not read policy file %s.", path), err)
+	}
+	policy, err := apiproxy.ParsePolicy(data)
+	if err != nil {
+		return nil, customerrors.WrapUserErrorWithHint(
+			fmt.Sprintf("Invalid policy file %s: %v", path, err),
+			"See 'datumctl api proxy --help' and the API proxy guide for the policy format.",
+			err,
+		)
+	}
+	return policy, nil
+}
+
+func loadFaults(path string) (*apiproxy.Faults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, customerrors.WrapUserError(fmt.Sprintf("Could not read faults file %s.", path), err)
+	}
+	faults, err := apiproxy.ParseFaults(data)
+	if err != nil {
+		return nil, customerrors.WrapUserErrorWithHint(
+			fmt.Sprintf("Invalid faults file %s: %v", path, err),
+			"See 'datumctl api proxy --help' and the API proxy guide for the faults format.",
+			err,
+		)
+	}
+	return faults, nil
+}
+
+// accessLabel describes request restrictions for the banner; empty when
+// every request is forwarded.
+func accessLabel(opts proxyOptions) string {
+	var parts []string
+	if opts.readOnly {
+		parts = append(parts, "read-only")
+	}
+	if opts.policyFile != "" {
+		parts = append(parts, "policy "+opts.policyFile)
+	}
+	return strings.Join(parts, ", ")
+}
+
 func stringValue(p *string) string {
 	if p == nil {
 		return ""
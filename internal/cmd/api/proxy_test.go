@@ -1,10 +1,12 @@
 package api
 
 import (
+	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
-	"go.datum.net/datumctl/internal/client"
 	"go.datum.net/datumctl/internal/datumconfig"
 	customerrors "go.datum.net/datumctl/internal/errors"
 )
@@ -185,47 +187,82 @@ func TestResolveProxyTarget_ConflictingScopeFlags(t *testing.T) {
 	}
 }
 
-func TestTLSClientConfig(t *testing.T) {
-	t.Run("empty settings keep defaults", func(t *testing.T) {
-		cfg, err := tlsClientConfig(client.EndpointTLS{})
-		if err != nil {
-			t.Fatalf("tlsClientConfig: %v", err)
-		}
-		if cfg != nil {
-			t.Errorf("config = %+v, want nil for default TLS", cfg)
-		}
-	})
+func TestListenUnix(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "proxy.sock")
 
-	t.Run("server name and insecure carry over", func(t *testing.T) {
-		cfg, err := tlsClientConfig(client.EndpointTLS{
-			ServerName:            "internal.example",
-			InsecureSkipTLSVerify: true,
-		})
-		if err != nil {
-			t.Fatalf("tlsClientConfig: %v", err)
-		}
-		if cfg == nil {
-			t.Fatal("config = nil, want TLS settings applied")
-		}
-		if cfg.ServerName != "internal.example" {
-			t.Errorf("ServerName = %q, want %q", cfg.ServerName, "internal.example")
-		}
-		if !cfg.InsecureSkipVerify {
-			t.Error("InsecureSkipVerify = false, want true")
-		}
-	})
+	l, url, err := listenUnix(sock)
+	if err != nil {
+		t.Fatalf("listenUnix: %v", err)
+	}
+	if url != "unix://"+sock {
+		t.Errorf("url = %q, want unix://%s", url, sock)
+	}
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
 
-	t.Run("invalid CA data errors", func(t *testing.T) {
-		_, err := tlsClientConfig(client.EndpointTLS{CAData: []byte("not a pem")})
-		if err == nil {
-			t.Fatal("expected an error for unparseable CA data")
-		}
-		userErr, ok := customerrors.IsUserError(err)
-		if !ok {
-			t.Fatalf("error = %v (%T), want a UserError with actionable guidance", err, err)
+	// A live socket is never replaced.
+	if _, _, err := listenUnix(sock); err == nil {
+		t.Error("listenUnix replaced a socket another listener is serving")
+	}
+
+	// A stale socket left behind is.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	l2, _, err := listenUnix(sock)
+	if err != nil {
+		t.Fatalf("listenUnix over a stale socket: %v", err)
+	}
+	l2.Close()
+}
+
+func TestListenUnix_refusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := listenUnix(path); err == nil {
+		t.Fatal("listenUnix should refuse to replace a regular file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep me" {
+		t.Error("listenUnix modified a regular file")
+	}
+}
+
+func TestResolveMounts(t *testing.T) {
+	mounts, err := resolveMounts("https://api.datum.net", []string{
+		"/p/web/=project:web",
+		"/org=organization:acme",
+		"/raw=endpoint",
+	})
+	if err != nil {
+		t.Fatalf("resolveMounts: %v", err)
+	}
+	want := []struct{ prefix, upstream string }{
+		{"/p/web", "https://api.datum.net/apis/resourcemanager.miloapis.com/v1alpha1/projects/web/control-plane"},
+		{"/org", "https://api.datum.net/apis/resourcemanager.miloapis.com/v1alpha1/organizations/acme/control-plane"},
+		{"/raw", "https://api.datum.net"},
+	}
+	for i, w := range want {
+		if mounts[i].prefix != w.prefix || mounts[i].upstream.String() != w.upstream {
+			t.Errorf("mount %d = %s → %s, want %s → %s", i, mounts[i].prefix, mounts[i].upstream, w.prefix, w.upstream)
 		}
-		if !strings.Contains(userErr.Hint, "datumctl login") {
-			t.Errorf("hint = %q, want it to point at 'datumctl login'", userErr.Hint)
+	}
+
+	for _, bad := range [][]string{
+		{"p/web=project:web"},
+		{"/p/web"},
+		{"/p/web=project:"},
+		{"/p/web=cluster:x"},
+		{"/raw=endpoint:x"},
+		{"/p=project:a", "/p/=project:b"},
+	} {
+		if _, err := resolveMounts("https://api.datum.net", bad); err == nil {
+			t.Errorf("resolveMounts(%q) succeeded, want an error", bad)
 		}
-	})
+	}
 }
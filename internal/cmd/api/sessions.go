@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/url"
+
+	"golang.org/x/oauth2"
+
+	"go.datum.net/datumctl/internal/apiproxy"
+	"go.datum.net/datumctl/internal/authutil"
+	"go.datum.net/datumctl/internal/datumconfig"
+	customerrors "go.datum.net/datumctl/internal/errors"
+)
+
+// proxySession is one session the proxy serves, fully resolved at startup:
+// its target, mounts, credentials, and TLS settings.
+type proxySession struct {
+	// name selects the session per request in a multi-session proxy.
+	name        string
+	target      *proxyTarget
+	mounts      []proxyMount
+	tokenSource oauth2.TokenSource
+	tlsConfig   *tls.Config
+}
+
+// prepareSession resolves everything the proxy needs to act as one session.
+// The scope flags and mounts apply to every session alike.
+func prepareSession(ctx context.Context, cfg *datumconfig.ConfigV1Beta1, sessionName, project, organization string, platformWide bool, mountSpecs []string) (*proxySession, error) {
+	target, err := resolveProxyTarget(cfg, sessionName, project, organization, platformWide)
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := resolveMounts(target.endpoint.BaseServer, mountSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenSource, err := authutil.GetTokenSourceForUser(ctx, target.endpoint.UserKey)
+	if err != nil {
+		if _, isUser := customerrors.IsUserError(err); isUser {
+			return nil, err
+		}
+		return nil, customerrors.WrapUserErrorWithHint(
+			fmt.Sprintf("No stored credentials found for %s.", sessionLabel(target)),
+			"Run 'datumctl login' to authenticate, then start the proxy again.",
+			err,
+		)
+	}
+
+	tlsConfig, err := target.endpoint.TLS.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	name := target.endpoint.UserKey
+	if target.session != nil {
+		name = target.session.Name
+	}
+	return &proxySession{
+		name:        name,
+		target:      target,
+		mounts:      mounts,
+		tokenSource: tokenSource,
+		tlsConfig:   tlsConfig,
+	}, nil
+}
+
+// upstream returns the session's routing for apiproxy: mounts when --mount
+// was given, the single pinned upstream otherwise.
+func (s *proxySession) upstream() (*url.URL, []apiproxy.Mount) {
+	if len(s.mounts) == 0 {
+		return s.target.upstream, nil
+	}
+	mounts := make([]apiproxy.Mount, 0, len(s.mounts))
+	for _, m := range s.mounts {
+		mounts = append(mounts, apiproxy.Mount{Prefix: m.prefix, Upstream: m.upstream})
+	}
+	return nil, mounts
+}
+
+// printBanner writes the identity and routing part of the startup banner.
+// With several sessions the upstream URLs differ per session, so only the
+// shared scope or mount prefixes are shown.
+func printBanner(w io.Writer, sessions []*proxySession, opts proxyOptions) {
+	first := sessions[0]
+	if len(sessions) == 1 {
+		fmt.Fprintf(w, "  Session:    %s\n", sessionLabel(first.target))
+	} else {
+		for i, sess := range sessions {
+			label := "  Sessions:   "
+			if i > 0 {
+				label = "              "
+			}
+			suffix := ""
+			if i == 0 {
+				suffix = "  (default)"
+			}
+			fmt.Fprintf(w, "%s%s — %s%s\n", label, sess.name, sessionLabel(sess.target), suffix)
+		}
+		fmt.Fprintf(w, "  Select:     %s: <session> header, or an /as/<session>/ path prefix\n", apiproxy.SessionHeader)
+	}
+
+	switch {
+	case len(first.mounts) > 0:
+		printMounts(w, first.mounts, len(sessions) == 1)
+	case len(sessions) == 1:
+		fmt.Fprintf(w, "  Upstream:   %s\n", first.target.upstream)
+		fmt.Fprintf(w, "  Scope:      %s\n", first.target.scope)
+	default:
+		fmt.Fprintf(w, "  Scope:      %s\n", first.target.scope)
+	}
+	if access := accessLabel(opts); access != "" {
+		fmt.Fprintf(w, "  Access:     %s\n", access)
+	}
+	if opts.cacheTTL > 0 {
+		fmt.Fprintf(w, "  Cache:      GET responses for %s; writes through the proxy clear it\n", opts.cacheTTL)
+	}
+	if opts.recordDir != "" {
+		fmt.Fprintf(w, "  Recording:  %s\n", opts.recordDir)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package api
+
+import "syscall"
+
+// withOwnerOnlyUmask runs fn with the process umask set so that files it
+// creates are accessible to the owner only. The umask is process-wide, so
+// this is only used during proxy startup, before any other goroutine creates
+// files.
+func withOwnerOnlyUmask(fn func() error) error {
+	old := syscall.Umask(0o077)
+	defer syscall.Umask(old)
+	return fn()
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package api
+
+// withOwnerOnlyUmask runs fn. Windows has no umask; the socket file inherits
+// its directory's ACL.
+func withOwnerOnlyUmask(fn func() error) error {
+	return fn()
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"go.datum.net/datumctl/internal/datumconfig"
+	"go.datum.net/datumctl/internal/output"
 )
 
 var listCmd = &cobra.Command{
@@ -22,22 +23,47 @@ Columns:
              or 'datumctl logout' to act on a specific account.
   Endpoint   Shown only when sessions span more than one API endpoint.
   Status     "Active" marks the account whose credentials are used by default
-             for all subsequent datumctl commands.`,
+             for all subsequent datumctl commands.
+
+Use -o json or -o yaml for a machine-readable SessionList document.`,
 	Example: `  # Show all logged-in users
   datumctl auth list
 
   # Alias
-  datumctl auth ls`,
+  datumctl auth ls
+
+  # Machine-readable output
+  datumctl auth list -o json`,
 	Args: cobra.NoArgs,
 	RunE: runList,
 }
 
-func runList(_ *cobra.Command, _ []string) error {
+func init() {
+	output.AddOutputFlag(listCmd)
+}
+
+func runList(cmd *cobra.Command, _ []string) error {
+	format, err := output.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
 	cfg, err := datumconfig.LoadAuto()
 	if err != nil {
 		return err
 	}
 
+	if format != "" {
+		doc := output.SessionList{
+			TypeMeta: output.NewTypeMeta("SessionList"),
+			Sessions: make([]output.SessionSummary, 0, len(cfg.Sessions)),
+		}
+		for i := range cfg.Sessions {
+			doc.Sessions = append(doc.Sessions, output.NewSessionSummary(&cfg.Sessions[i], cfg.ActiveSession))
+		}
+		return output.PrintStructured(cmd.OutOrStdout(), format, doc)
+	}
+
 	if len(cfg.Sessions) == 0 {
 		fmt.Println("No authenticated users. Run 'datumctl login' to get started.")
 		return nil
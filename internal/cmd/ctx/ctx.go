@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 	"go.datum.net/datumctl/internal/datumconfig"
 	"go.datum.net/datumctl/internal/discovery"
+	"go.datum.net/datumctl/internal/output"
 )
 
 // Command returns the "ctx" command group. Running "datumctl ctx" without a
@@ -21,9 +22,13 @@ func Command() *cobra.Command {
 
 Running 'datumctl ctx' without a subcommand lists the active session's
 contexts. Use --all to list every session's contexts grouped by account and
-endpoint, or --refresh to update the context cache from the API.`,
+endpoint, or --refresh to update the context cache from the API. Use -o json
+or -o yaml for machine-readable output.`,
 		Aliases: []string{"context"},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := output.OutputFormat(cmd); err != nil {
+				return err
+			}
 			if refresh {
 				if err := runRefresh(cmd); err != nil {
 					return err
@@ -42,6 +47,7 @@ endpoint, or --refresh to update the context cache from the API.`,
 
 	cmd.Flags().BoolVar(&refresh, "refresh", false, "Refresh the context cache from the API before listing")
 	cmd.Flags().Bool("all", false, "List contexts from every session, grouped by account and endpoint")
+	output.AddOutputFlag(cmd)
 
 	cmd.AddCommand(listCmd())
 	cmd.AddCommand(useCmd())
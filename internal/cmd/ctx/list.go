@@ -9,6 +9,7 @@ import (
 	"github.com/rodaine/table"
 	"github.com/spf13/cobra"
 	"go.datum.net/datumctl/internal/datumconfig"
+	"go.datum.net/datumctl/internal/output"
 )
 
 func listCmd() *cobra.Command {
@@ -19,27 +20,39 @@ func listCmd() *cobra.Command {
 
 By default only the active session's contexts are shown. Use --all to list
 every session's contexts grouped by account and endpoint — useful when the
-same organization or project name exists in more than one environment.`,
+same organization or project name exists in more than one environment.
+
+Use -o json or -o yaml for a machine-readable ContextList document.`,
 		Aliases: []string{"ls"},
 		Args:    cobra.NoArgs,
 		RunE:    runList,
 	}
 	cmd.Flags().Bool("all", false, "List contexts from every session, grouped by account and endpoint")
+	output.AddOutputFlag(cmd)
 	return cmd
 }
 
 func runList(cmd *cobra.Command, _ []string) error {
+	format, err := output.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
 	cfg, err := datumconfig.LoadAuto()
 	if err != nil {
 		return err
 	}
 
+	all, _ := cmd.Flags().GetBool("all")
+	if format != "" {
+		return output.PrintStructured(cmd.OutOrStdout(), format, contextListDocument(cfg, all))
+	}
+
 	if len(cfg.Contexts) == 0 {
 		fmt.Println("No contexts available. Run 'datumctl login' to get started.")
 		return nil
 	}
 
-	all, _ := cmd.Flags().GetBool("all")
 	if all {
 		printAllContexts(os.Stdout, cfg)
 		return nil
@@ -135,3 +148,36 @@ func printContextTree(w io.Writer, cfg *datumconfig.ConfigV1Beta1, sessionName s
 
 	tbl.Print()
 }
+
+// contextListDocument builds the structured ContextList for cfg. Only the
+// active session's sessions and contexts are included unless all is set,
+// mirroring the human output.
+func contextListDocument(cfg *datumconfig.ConfigV1Beta1, all bool) output.ContextList {
+	activeSession := ""
+	if s := cfg.ActiveSessionEntry(); s != nil {
+		activeSession = s.Name
+	}
+
+	doc := output.ContextList{
+		TypeMeta:       output.NewTypeMeta("ContextList"),
+		CurrentContext: cfg.CurrentContext,
+		ActiveSession:  activeSession,
+		Sessions:       []output.SessionSummary{},
+		Contexts:       []output.ContextSummary{},
+	}
+	for i := range cfg.Sessions {
+		s := &cfg.Sessions[i]
+		if !all && s.Name != activeSession {
+			continue
+		}
+		doc.Sessions = append(doc.Sessions, output.NewSessionSummary(s, activeSession))
+	}
+	for i := range cfg.Contexts {
+		c := &cfg.Contexts[i]
+		if !all && c.Session != activeSession {
+			continue
+		}
+		doc.Contexts = append(doc.Contexts, output.NewContextSummary(cfg, c))
+	}
+	return doc
+}
@@ -0,0 +1,193 @@
+package doctor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"go.datum.net/datumctl/internal/datumconfig"
+	"go.datum.net/datumctl/internal/output"
+	"go.datum.net/datumctl/internal/pluginstore"
+)
+
+const redacted = "REDACTED"
+
+// bundleEnvPrefixes selects the environment variables worth attaching: those
+// that change how datumctl behaves.
+var bundleEnvPrefixes = []string{"DATUM", "OTEL_", "KUBECONFIG"}
+
+// bundleProxyVars are included with any credentials in their URLs removed.
+var bundleProxyVars = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"}
+
+// secretNameParts marks environment variables whose values are dropped
+// outright.
+var secretNameParts = []string{"TOKEN", "SECRET", "PASSWORD", "KEY", "HEADERS", "CREDENTIAL"}
+
+// writeBundle writes a .tar.gz of diagnostics to path. It reads only files
+// that never hold credentials (the config and the plugin install record) and
+// redacts what could identify the machine beyond what support needs.
+func writeBundle(path string, report output.DoctorReport) error {
+	files := map[string][]byte{}
+
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	files["report.json"] = reportJSON
+
+	if cfg, err := datumconfig.LoadAuto(); err == nil {
+		data, err := yaml.Marshal(redactConfig(cfg))
+		if err != nil {
+			return err
+		}
+		files["config.yaml"] = data
+	}
+
+	if pluginsDir, err := pluginstore.PluginsDir(""); err == nil {
+		if manifest, err := pluginstore.Load(pluginsDir); err == nil {
+			data, err := json.MarshalIndent(manifest, "", "  ")
+			if err != nil {
+				return err
+			}
+			files["plugins.json"] = data
+		}
+	}
+
+	files["environment.txt"] = []byte(strings.Join(bundleEnv(os.Environ()), "\n") + "\n")
+
+	home, _ := os.UserHomeDir()
+	for name, data := range files {
+		files[name] = redactHome(data, home)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := writeTarGz(f, files); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+func writeTarGz(f *os.File, files map[string][]byte) error {
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range sortedKeys(files) {
+		data := files[name]
+		hdr := &tar.Header{
+			Name:    "datumctl-doctor/" + name,
+			Mode:    0o600,
+			Size:    int64(len(data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// redactConfig returns a copy of cfg without certificate data. The config
+// holds no tokens; those live in the keyring.
+func redactConfig(cfg *datumconfig.ConfigV1Beta1) *datumconfig.ConfigV1Beta1 {
+	out := *cfg
+	out.Sessions = make([]datumconfig.Session, len(cfg.Sessions))
+	for i, s := range cfg.Sessions {
+		if s.Endpoint.CertificateAuthorityData != "" {
+			s.Endpoint.CertificateAuthorityData = redacted
+		}
+		out.Sessions[i] = s
+	}
+	return &out
+}
+
+// bundleEnv filters env down to the variables that affect datumctl,
+// redacting secrets and proxy credentials.
+func bundleEnv(env []string) []string {
+	var out []string
+	for _, kv := range env {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		switch {
+		case isProxyVar(name):
+			value = redactURLUserinfo(value)
+		case hasAnyPrefix(name, bundleEnvPrefixes):
+			if isSecretName(name) {
+				value = redacted
+			}
+		default:
+			continue
+		}
+		out = append(out, name+"="+value)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func isProxyVar(name string) bool {
+	for _, v := range bundleProxyVars {
+		if name == v {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecretName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, part := range secretNameParts {
+		if strings.Contains(upper, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURLUserinfo strips credentials from a proxy URL. Values that do not
+// parse are dropped entirely rather than risk leaking them.
+func redactURLUserinfo(value string) string {
+	if !strings.Contains(value, "@") {
+		return value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return redacted
+	}
+	u.User = url.User(redacted)
+	return u.String()
+}
+
+func redactHome(data []byte, home string) []byte {
+	if home == "" || home == "/" {
+		return data
+	}
+	return []byte(strings.ReplaceAll(string(data), home, "~"))
+}
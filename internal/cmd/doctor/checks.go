@@ -0,0 +1,380 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/oauth2"
+	componentversion "k8s.io/component-base/version"
+
+	"go.datum.net/datumctl/internal/authutil"
+	"go.datum.net/datumctl/internal/client"
+	"go.datum.net/datumctl/internal/datumconfig"
+	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/keyring"
+	"go.datum.net/datumctl/internal/onboarding"
+	"go.datum.net/datumctl/internal/plugindispatch"
+	"go.datum.net/datumctl/internal/pluginstore"
+	"go.datum.net/datumctl/internal/updatecheck"
+)
+
+// Check statuses, in increasing order of severity (skip aside).
+const (
+	statusPass = "pass"
+	statusWarn = "warn"
+	statusFail = "fail"
+	statusSkip = "skip"
+)
+
+// networkTimeout bounds each network probe, so doctor finishes promptly even
+// when an endpoint is black-holed.
+const networkTimeout = 5 * time.Second
+
+// result is the outcome of one check.
+type result struct {
+	status  string
+	message string
+	hint    string
+}
+
+func pass(format string, args ...any) result {
+	return result{status: statusPass, message: fmt.Sprintf(format, args...)}
+}
+
+func skip(format string, args ...any) result {
+	return result{status: statusSkip, message: fmt.Sprintf(format, args...)}
+}
+
+func warn(hint, format string, args ...any) result {
+	return result{status: statusWarn, message: fmt.Sprintf(format, args...), hint: hint}
+}
+
+func fail(hint, format string, args ...any) result {
+	return result{status: statusFail, message: fmt.Sprintf(format, args...), hint: hint}
+}
+
+// failFromError reports err, preferring a UserError's own message and hint.
+func failFromError(err error, fallbackHint string) result {
+	if userErr, ok := customerrors.IsUserError(err); ok {
+		hint := userErr.Hint
+		if hint == "" {
+			hint = fallbackHint
+		}
+		return result{status: statusFail, message: userErr.Message, hint: hint}
+	}
+	return result{status: statusFail, message: err.Error(), hint: fallbackHint}
+}
+
+// state is shared between checks: later checks build on what earlier ones
+// established and skip themselves when a prerequisite failed.
+type state struct {
+	cfg         *datumconfig.ConfigV1Beta1
+	session     *datumconfig.Session
+	endpoint    *client.SessionEndpoint
+	creds       *authutil.StoredCredentials
+	tokenSource oauth2.TokenSource
+	tokenValid  bool
+}
+
+// check is one diagnostic. Checks run in order.
+type check struct {
+	name string
+	run  func(ctx context.Context, s *state) result
+}
+
+var checks = []check{
+	{"version", checkVersion},
+	{"config", checkConfig},
+	{"session", checkSession},
+	{"keyring", checkKeyring},
+	{"token", checkToken},
+	{"auth-endpoint", checkAuthEndpoint},
+	{"api-endpoint", checkAPIEndpoint},
+	{"onboarding", checkOnboarding},
+	{"plugins", checkPlugins},
+}
+
+func checkVersion(ctx context.Context, _ *state) result {
+	current := componentversion.Get().GitVersion
+	if !semver.IsValid(current) {
+		return pass("%s (development build; update check skipped)", current)
+	}
+	if v := os.Getenv(updatecheck.EnvDisable); v != "" && v != "0" && strings.ToLower(v) != "false" {
+		return pass("%s (update check disabled by %s)", current, updatecheck.EnvDisable)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, networkTimeout)
+	defer cancel()
+	latest, err := updatecheck.FetchLatestVersion(ctx)
+	if err != nil {
+		return warn("Check your network connection; this does not affect other commands.",
+			"%s (could not check for a newer release: %v)", current, err)
+	}
+	if semver.IsValid(latest) && semver.Compare(latest, current) > 0 {
+		return warn("Run 'datumctl auto-update' to upgrade.", "%s (%s is available)", current, latest)
+	}
+	return pass("%s (latest)", current)
+}
+
+func checkConfig(_ context.Context, s *state) result {
+	path, err := datumconfig.DefaultPath()
+	if err != nil {
+		return fail("", "could not locate the config file: %v", err)
+	}
+	cfg, err := datumconfig.LoadAuto()
+	if err != nil {
+		return fail(fmt.Sprintf("Fix or move aside %s, then run 'datumctl login'.", path),
+			"%s could not be read: %v", path, err)
+	}
+	if err := authutil.EnsureUserKeysMigrated(cfg); err != nil {
+		return fail("Run 'datumctl login' to re-create the session.", "could not migrate stored sessions: %v", err)
+	}
+	s.cfg = cfg
+
+	if problems := configProblems(cfg); len(problems) > 0 {
+		return warn("Run 'datumctl ctx use' to pick a valid context, or 'datumctl login' to re-create the session.",
+			"%s: %s", path, strings.Join(problems, "; "))
+	}
+	return pass("%s (%d session(s), %d context(s))", path, len(cfg.Sessions), len(cfg.Contexts))
+}
+
+// configProblems reports references in cfg that point at nothing: entries
+// datumctl tolerates at load time but that make commands fail or act on the
+// wrong account later.
+func configProblems(cfg *datumconfig.ConfigV1Beta1) []string {
+	var problems []string
+	if cfg.ActiveSession != "" && cfg.SessionByName(cfg.ActiveSession) == nil {
+		problems = append(problems, fmt.Sprintf("active session %q does not exist", cfg.ActiveSession))
+	}
+	if cfg.CurrentContext != "" && cfg.ContextByName(cfg.CurrentContext) == nil {
+		problems = append(problems, fmt.Sprintf("current context %q does not exist", cfg.CurrentContext))
+	}
+	for _, sess := range cfg.Sessions {
+		if sess.UserKey == "" {
+			problems = append(problems, fmt.Sprintf("session %q has no credentials key", sess.Name))
+		}
+		if sess.Endpoint.Server == "" {
+			problems = append(problems, fmt.Sprintf("session %q has no API endpoint", sess.Name))
+		}
+	}
+	orphaned := map[string]bool{}
+	for _, c := range cfg.Contexts {
+		if cfg.SessionByName(c.Session) == nil {
+			orphaned[c.Session] = true
+		}
+	}
+	for _, name := range sortedKeys(orphaned) {
+		problems = append(problems, fmt.Sprintf("contexts refer to missing session %q", name))
+	}
+	return problems
+}
+
+func checkSession(_ context.Context, s *state) result {
+	if s.cfg == nil {
+		return skip("config unavailable")
+	}
+	session, endpoint, err := client.ResolveSessionEndpoint(s.cfg, "")
+	if err != nil {
+		if authutil.IsNoActiveUser(err) {
+			return fail("Run 'datumctl login' to sign in.", "no active session")
+		}
+		return failFromError(err, "Run 'datumctl auth list' to check your sessions, or 'datumctl login' to sign in again.")
+	}
+	s.session, s.endpoint = session, endpoint
+
+	if session == nil {
+		return pass("%s (legacy keyring login, no session in config)", endpoint.UserKey)
+	}
+	return pass("%s (%s)", session.Name, datumconfig.StripScheme(endpoint.BaseServer))
+}
+
+func checkKeyring(_ context.Context, s *state) result {
+	if s.endpoint == nil {
+		return skip("no session")
+	}
+	creds, err := authutil.GetStoredCredentials(s.endpoint.UserKey)
+	if err != nil {
+		var timeoutErr *keyring.TimeoutError
+		if errors.As(err, &timeoutErr) {
+			return fail("Unlock the system keyring (or its agent) and retry.", "the system keyring did not respond: %v", err)
+		}
+		return fail("Run 'datumctl login' to store fresh credentials.", "stored credentials could not be read: %v", err)
+	}
+	s.creds = creds
+
+	var fallback bytes.Buffer
+	keyring.WarnIfFallbackActive(&fallback)
+	if msg := strings.TrimSpace(fallback.String()); msg != "" {
+		return warn("Credentials are stored unencrypted on disk. Enable a system keyring (for example gnome-keyring or KWallet) and run 'datumctl login' again.",
+			"%s", strings.TrimPrefix(msg, "warning: "))
+	}
+	return pass("credentials read from the system keyring")
+}
+
+func checkToken(ctx context.Context, s *state) result {
+	if s.creds == nil {
+		return skip("no stored credentials")
+	}
+	tokenSource, err := authutil.GetTokenSourceForUser(ctx, s.endpoint.UserKey)
+	if err != nil {
+		return failFromError(err, "Run 'datumctl login' to sign in again.")
+	}
+	token, err := tokenSource.Token()
+	if err != nil {
+		return failFromError(err, "Run 'datumctl login' to sign in again.")
+	}
+	s.tokenSource, s.tokenValid = tokenSource, true
+
+	kind := "user"
+	if s.creds.ServiceAccount != nil {
+		kind = "service account"
+	}
+	if token.Expiry.IsZero() {
+		return pass("%s access token is valid", kind)
+	}
+	return pass("%s access token valid for %s; refreshed automatically", kind,
+		time.Until(token.Expiry).Round(time.Minute))
+}
+
+func checkAuthEndpoint(ctx context.Context, s *state) result {
+	host := ""
+	if s.session != nil {
+		host = s.session.Endpoint.AuthHostname
+	}
+	if host == "" && s.creds != nil {
+		host = s.creds.Hostname
+	}
+	if host == "" {
+		return skip("no auth server recorded for the session")
+	}
+	return probe(ctx, "https://"+datumconfig.StripScheme(host)+"/.well-known/openid-configuration", nil)
+}
+
+func checkAPIEndpoint(ctx context.Context, s *state) result {
+	if s.endpoint == nil {
+		return skip("no session")
+	}
+	tlsConfig, err := s.endpoint.TLS.ClientConfig()
+	if err != nil {
+		return failFromError(err, "")
+	}
+	return probe(ctx, s.endpoint.BaseServer+"/version", tlsConfig)
+}
+
+// probe reports whether url answers at all. Any HTTP response proves the
+// network path and TLS work, so only transport errors fail and 5xx warns;
+// authorization is the token and onboarding checks' business.
+func probe(ctx context.Context, url string, tlsConfig *tls.Config) result {
+	ctx, cancel := context.WithTimeout(ctx, networkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fail("", "invalid endpoint URL %q: %v", url, err)
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fail("Check your network connection, DNS, firewall, and any HTTPS_PROXY setting.",
+			"%s is unreachable: %v", req.URL.Host, err)
+	}
+	resp.Body.Close()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if resp.StatusCode >= 500 {
+		return warn("The service may be degraded; retry shortly.", "%s answered %s (%s)", req.URL.Host, resp.Status, elapsed)
+	}
+	return pass("%s reachable (HTTP %d, %s)", req.URL.Host, resp.StatusCode, elapsed)
+}
+
+func checkOnboarding(ctx context.Context, s *state) result {
+	if s.cfg == nil || !s.tokenValid {
+		return skip("no valid access token")
+	}
+	orgID := onboarding.ResolveEffectiveOrgID(s.cfg, os.Getenv("DATUM_PROJECT"), os.Getenv("DATUM_ORGANIZATION"))
+	if orgID == "" {
+		return skip("no organization selected")
+	}
+	userID, err := authutil.GetUserIDFromTokenForUser(s.endpoint.UserKey)
+	if err != nil {
+		return failFromError(err, "Run 'datumctl login' to sign in again.")
+	}
+	apiHostname, err := authutil.GetAPIHostnameForUser(s.endpoint.UserKey)
+	if err != nil {
+		return failFromError(err, "Run 'datumctl login' to sign in again.")
+	}
+
+	sessionName := ""
+	if s.session != nil {
+		sessionName = s.session.Name
+	}
+	ctx, cancel := context.WithTimeout(ctx, networkTimeout)
+	defer cancel()
+	res, err := onboarding.CheckOrg(ctx, apiHostname, s.tokenSource, userID, orgID, s.cfg.OrgDisplayName(sessionName, orgID))
+	if err != nil {
+		return warn("If you just finished setup in the portal, wait a moment and try again.",
+			"could not check organization %s: %v", orgID, err)
+	}
+	if res.State != onboarding.Complete {
+		return fail("Finish setup at "+res.ActionURL, "%s: %s",
+			datumconfig.FormatWithID(res.OrgDisplayName, orgID), onboarding.StatusLabel(res))
+	}
+	return pass("%s is ready", datumconfig.FormatWithID(res.OrgDisplayName, orgID))
+}
+
+func checkPlugins(_ context.Context, _ *state) result {
+	pluginsDir, err := pluginstore.PluginsDir("")
+	if err != nil {
+		return warn("", "could not locate the plugins directory: %v", err)
+	}
+	manifest, err := pluginstore.Load(pluginsDir)
+	if err != nil {
+		return fail("Reinstall your plugins with 'datumctl plugin install <name>'.",
+			"the plugin install record could not be read: %v", err)
+	}
+	if len(manifest.Plugins) == 0 {
+		return pass("no managed plugins installed")
+	}
+
+	var broken []string
+	for _, name := range sortedKeys(manifest.Plugins) {
+		binaryPath, managed, err := plugindispatch.FindPlugin(name, pluginsDir)
+		if err != nil || !managed {
+			broken = append(broken, name+" (binary missing)")
+			continue
+		}
+		if err := plugindispatch.VerifyManagedPluginIntegrity(pluginsDir, name, binaryPath); err != nil {
+			broken = append(broken, name+" (checksum mismatch)")
+		}
+	}
+	if len(broken) > 0 {
+		return fail("Reinstall each with 'datumctl plugin install <name>'. If you did not expect a binary to change, investigate before running it.",
+			"%d of %d plugin(s) failed verification: %s", len(broken), len(manifest.Plugins), strings.Join(broken, ", "))
+	}
+	return pass("%d managed plugin(s) verified", len(manifest.Plugins))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,149 @@
+// Package doctor provides the "datumctl doctor" command, which diagnoses a
+// datumctl installation end to end: version, config, credentials, network
+// reachability, onboarding, and installed plugins.
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	componentversion "k8s.io/component-base/version"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/output"
+)
+
+// Command returns the "doctor" command.
+func Command() *cobra.Command {
+	var bundlePath string
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose problems with your datumctl setup",
+		Long: templates.LongDesc(`
+			Run a series of checks against your datumctl installation and report
+			each as pass, warn, fail, or skip, with a hint on how to fix anything
+			that is wrong.
+
+			The checks cover, in order: whether a newer datumctl is available, the
+			config file, the active session, the system keyring, the access token,
+			reachability of the auth and API servers, onboarding of the current
+			organization, and the integrity of managed plugins. Checks that depend
+			on an earlier failure are skipped.
+
+			Use --bundle to also write a .tar.gz with the report, a copy of your
+			config, the plugin install record, and relevant environment variables,
+			ready to attach to a support ticket. Access tokens, refresh tokens,
+			and other credentials are never included, and certificate data and
+			your home directory are redacted.
+
+			The command exits non-zero when any check fails.`),
+		Example: templates.Examples(`
+			# Check your setup
+			datumctl doctor
+
+			# Write a diagnostics bundle for a support ticket
+			datumctl doctor --bundle datumctl-doctor.tar.gz
+
+			# Report as JSON
+			datumctl doctor -o json`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output.OutputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			report := runChecks(cmd)
+
+			if bundlePath != "" {
+				if err := writeBundle(bundlePath, report); err != nil {
+					return customerrors.WrapUserError(fmt.Sprintf("could not write diagnostics bundle %s", bundlePath), err)
+				}
+			}
+
+			if format != "" {
+				if err := output.PrintStructured(cmd.OutOrStdout(), format, report); err != nil {
+					return err
+				}
+			} else {
+				printReport(cmd.OutOrStdout(), report)
+			}
+			if bundlePath != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Wrote diagnostics bundle to %s\n", bundlePath)
+			}
+
+			if failed := count(report, statusFail); failed > 0 {
+				return customerrors.NewUserErrorWithHint(
+					fmt.Sprintf("%d of %d checks failed", failed, len(report.Checks)),
+					"Follow the hints above, or attach a --bundle to a support ticket.",
+				)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&bundlePath, "bundle", "", "Also write a redacted diagnostics bundle (.tar.gz) to this path")
+	output.AddOutputFlag(cmd)
+	return cmd
+}
+
+// runChecks runs every check in order and collects the report.
+func runChecks(cmd *cobra.Command) output.DoctorReport {
+	report := output.DoctorReport{
+		TypeMeta: output.NewTypeMeta("DoctorReport"),
+		Version:  componentversion.Get().GitVersion,
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		Checks:   make([]output.DoctorCheck, 0, len(checks)),
+	}
+	s := &state{}
+	for _, c := range checks {
+		res := c.run(cmd.Context(), s)
+		report.Checks = append(report.Checks, output.DoctorCheck{
+			Name:    c.name,
+			Status:  res.status,
+			Message: res.message,
+			Hint:    res.hint,
+		})
+	}
+	return report
+}
+
+var statusMarks = map[string]string{
+	statusPass: "✓",
+	statusWarn: "!",
+	statusFail: "✗",
+	statusSkip: "-",
+}
+
+func printReport(w io.Writer, report output.DoctorReport) {
+	fmt.Fprintf(w, "datumctl %s (%s)\n\n", report.Version, report.Platform)
+
+	width := 0
+	for _, c := range report.Checks {
+		width = max(width, len(c.Name))
+	}
+	for _, c := range report.Checks {
+		fmt.Fprintf(w, "%s %-*s  %s\n", statusMarks[c.Status], width, c.Name, c.Message)
+		if c.Hint != "" {
+			fmt.Fprintf(w, "  %s  %s\n", strings.Repeat(" ", width), c.Hint)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed, %d skipped\n",
+		count(report, statusPass), count(report, statusWarn), count(report, statusFail), count(report, statusSkip))
+}
+
+func count(report output.DoctorReport, status string) int {
+	n := 0
+	for _, c := range report.Checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,136 @@
+// Package errors provides the "datumctl errors" command, which documents the
+// registered error codes that datumctl emits with --error-format json and the
+// process exit code each one maps to.
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/output"
+)
+
+// Command returns the "errors" command tree.
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "errors",
+		Short: "Look up datumctl error codes and exit codes",
+		Long: templates.LongDesc(`
+			Look up the stable error codes datumctl reports when a command fails.
+
+			Each registered code appears in the "code" field of --error-format json
+			output and maps to a distinct process exit code, so scripts and CI can
+			branch on the kind of failure without parsing messages. Failures without
+			a registered code exit with status 1.`),
+		Example: templates.Examples(`
+			# List every error code and its exit code
+			datumctl errors list
+
+			# Show remediation steps for an error code
+			datumctl errors explain AUTH_EXPIRED`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(listCmd(), explainCmd())
+	return cmd
+}
+
+func listCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List registered error codes and their exit codes",
+		Long: templates.LongDesc(`
+			List every registered error code with the process exit code it maps to
+			and a one-line summary. Use -o json or -o yaml for a machine-readable
+			ErrorCodeList document.`),
+		Example: templates.Examples(`
+			# List error codes
+			datumctl errors list
+
+			# List error codes as JSON
+			datumctl errors list -o json`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output.OutputFormat(cmd)
+			if err != nil {
+				return err
+			}
+
+			codes := customerrors.Codes()
+			if format != "" {
+				doc := output.ErrorCodeList{
+					TypeMeta: output.NewTypeMeta("ErrorCodeList"),
+					Codes:    make([]output.ErrorCodeSummary, 0, len(codes)),
+				}
+				for _, info := range codes {
+					doc.Codes = append(doc.Codes, output.ErrorCodeSummary{
+						Code:        info.Code,
+						ExitCode:    info.ExitCode,
+						Summary:     info.Summary,
+						Retryable:   info.Retryable,
+						Remediation: info.Remediation,
+					})
+				}
+				return output.PrintStructured(cmd.OutOrStdout(), format, doc)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "CODE\tEXIT\tRETRYABLE\tSUMMARY")
+			for _, info := range codes {
+				fmt.Fprintf(w, "%s\t%d\t%t\t%s\n", info.Code, info.ExitCode, info.Retryable, info.Summary)
+			}
+			return w.Flush()
+		},
+	}
+	output.AddOutputFlag(cmd)
+	return cmd
+}
+
+func explainCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain <code>",
+		Short: "Explain an error code and how to resolve it",
+		Long: templates.LongDesc(`
+			Print the long-form description and remediation steps for a registered
+			error code. Codes are matched case-insensitively.`),
+		Example: templates.Examples(`
+			# Explain an expired-session failure
+			datumctl errors explain AUTH_EXPIRED`),
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			var names []string
+			for _, info := range customerrors.Codes() {
+				names = append(names, info.Code+"\t"+info.Summary)
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, ok := customerrors.LookupCode(strings.ToUpper(args[0]))
+			if !ok {
+				return customerrors.NewUserErrorWithHint(
+					fmt.Sprintf("unknown error code %q", args[0]),
+					"Run 'datumctl errors list' to see registered codes.",
+				)
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "%s (exit code %d)\n\n", info.Code, info.ExitCode)
+			fmt.Fprintf(out, "%s\n\n", info.Summary)
+			fmt.Fprintln(out, info.Remediation)
+			if info.Retryable {
+				fmt.Fprintln(out, "\nThis failure is usually transient; retrying may succeed.")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,225 @@
+// Package mcp defines the `datumctl mcp` cobra commands.
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	componentversion "k8s.io/component-base/version"
+	"k8s.io/kubectl/pkg/util/templates"
+
+	datumai "go.datum.net/datumctl/internal/ai"
+	"go.datum.net/datumctl/internal/client"
+	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/mcp"
+)
+
+// shutdownGrace bounds how long in-flight HTTP requests get to finish after
+// an interrupt.
+const shutdownGrace = 5 * time.Second
+
+// Command returns the cobra.Command for `datumctl mcp`.
+func Command(factory *client.DatumCloudFactory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve datumctl's AI tools to MCP clients",
+		Long: templates.LongDesc(`
+			Serve the tools behind 'datumctl ai' over the Model Context Protocol
+			(MCP), so editors and agent runtimes can list, inspect, and change
+			Datum Cloud resources with your datumctl login.`),
+	}
+	cmd.AddCommand(serveCommand(factory))
+	return cmd
+}
+
+type serveOptions struct {
+	transport      string
+	port           int
+	allowMutations bool
+}
+
+func serveCommand(factory *client.DatumCloudFactory) *cobra.Command {
+	var opts serveOptions
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run an MCP server backed by your datumctl session",
+		Long: templates.LongDesc(`
+			Run a Model Context Protocol server that exposes the same tools as
+			'datumctl ai': listing resource types, reading schemas, listing and
+			getting resources, validating, applying, and deleting manifests.
+
+			The server acts as you, with your current datumctl login, and on the
+			organization or project resolved the usual way: --organization or
+			--project, then the environment, then the active context ('datumctl
+			ctx use'). That scope is fixed while the server runs, so the
+			change_context tool is not offered. Tokens are refreshed as needed
+			and never leave datumctl.
+
+			Tools that change resources (apply_manifest, delete_resource) need
+			confirmation. The confirmation policy in the 'datumctl ai' config file
+			decides first; its deny rules hold in every mode. Otherwise the server
+			asks through MCP elicitation, showing the same preview 'datumctl ai'
+			does; clients that cannot ask are refused the change. Pass
+			--allow-mutations to let changes run without asking, e.g. for an
+			unattended agent you trust.
+
+			With --transport stdio (the default) the server speaks over stdin and
+			stdout, as MCP clients expect of a command they launch. With
+			--transport http it serves Streamable HTTP on 127.0.0.1 and runs until
+			interrupted. It prints the URL as the first line on stdout and a
+			random bearer token as the second; clients must send the token as
+			'Authorization: Bearer <token>' on every request. Either way, logs
+			go to stderr.`),
+		Example: templates.Examples(`
+			# Register with an MCP client that launches servers over stdio
+			# (the client's config names the command and its arguments)
+			datumctl mcp serve --project my-project
+
+			# Serve over HTTP on a fixed port for clients that connect by URL
+			datumctl mcp serve --transport http --port 8765
+
+			# Let an unattended agent apply changes without confirmation
+			datumctl mcp serve --organization my-org --allow-mutations`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(cmd, factory, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.transport, "transport", "stdio", "How clients connect: stdio or http")
+	cmd.Flags().IntVar(&opts.port, "port", 0, "Port to listen on with --transport http (0 picks a free port)")
+	cmd.Flags().BoolVar(&opts.allowMutations, "allow-mutations", false, "Run tools that change resources without asking for confirmation")
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, factory *client.DatumCloudFactory, opts serveOptions) error {
+	switch opts.transport {
+	case "stdio", "http":
+	default:
+		return customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("Unknown transport %q.", opts.transport),
+			"Pass --transport stdio or --transport http.",
+		)
+	}
+	if opts.port != 0 && opts.transport != "http" {
+		return customerrors.NewUserError("--port only applies with --transport http.")
+	}
+
+	project, org, platformWide, err := factory.ConfigFlags.ResolvedScope()
+	if err != nil {
+		return err
+	}
+	if !platformWide && org == "" && project == "" {
+		return customerrors.NewUserErrorWithHint(
+			"No organization or project to serve.",
+			"Pass --organization or --project, or pick a context with 'datumctl ctx use'.",
+		)
+	}
+	namespace := *factory.ConfigFlags.Namespace
+	if namespace == "" {
+		namespace = "default"
+		*factory.ConfigFlags.Namespace = namespace
+	}
+	// Surface a missing or expired login now rather than on the first call.
+	if _, err := factory.ConfigFlags.ToRESTConfig(); err != nil {
+		return customerrors.WrapUserErrorWithHint("Could not connect to Datum Cloud.", "Run 'datumctl login' to authenticate.", err)
+	}
+
+	// The confirmation policy is shared with 'datumctl ai'.
+	aiCfg, err := datumai.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	// The scope is pinned for the server's lifetime. Calls run concurrently
+	// and share the factory, so change_context is not offered: switching
+	// mid-call could run a change, checked against one project's policy, in
+	// another, and would leave the instructions naming the wrong context.
+	errOut := cmd.ErrOrStderr()
+	server := mcp.NewServer(mcp.Options{
+		Registry:       datumai.NewRegistry(factory, datumai.UserPluginsDir()).Without("change_context"),
+		AllowMutations: opts.allowMutations,
+		Policy:         aiCfg.Policy,
+		Project:        func() string { return project },
+		Version:        componentversion.Get().GitVersion,
+		Instructions:   instructions(project, org, namespace, platformWide),
+		Log:            errOut,
+	})
+	fmt.Fprintf(errOut, "[mcp] serving %s over %s\n", scopeLabel(project, org, platformWide), opts.transport)
+	if opts.allowMutations {
+		fmt.Fprintln(errOut, "[mcp] --allow-mutations: changes run without confirmation")
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if opts.transport == "stdio" {
+		return server.ServeStdio(ctx, cmd.InOrStdin(), cmd.OutOrStdout())
+	}
+	return serveHTTP(ctx, cmd, server, opts.port)
+}
+
+func serveHTTP(ctx context.Context, cmd *cobra.Command, server *mcp.Server, port int) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return customerrors.WrapUserErrorWithHint(
+			fmt.Sprintf("Could not listen on 127.0.0.1:%d.", port),
+			"The port may already be in use — pass a different --port, or omit --port to pick a free one.",
+			err,
+		)
+	}
+	token, err := mcp.NewToken()
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	url := "http://" + listener.Addr().String() + "/mcp"
+	httpServer := &http.Server{Handler: server.Handler(token), ReadHeaderTimeout: 10 * time.Second}
+
+	errOut := cmd.ErrOrStderr()
+	fmt.Fprintf(errOut, "[mcp] listening on %s (Ctrl+C to stop)\n", url)
+	fmt.Fprintf(errOut, "[mcp] token %s (send as 'Authorization: Bearer <token>'; valid until the server stops)\n", token)
+	// As with 'datumctl api proxy --require-token': the URL is the first
+	// stdout line and the token the second.
+	fmt.Fprintln(cmd.OutOrStdout(), url)
+	fmt.Fprintln(cmd.OutOrStdout(), token)
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- httpServer.Serve(listener) }()
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancel()
+	_ = httpServer.Shutdown(shutdownCtx)
+	return nil
+}
+
+func scopeLabel(project, org string, platformWide bool) string {
+	switch {
+	case platformWide:
+		return "the whole platform"
+	case project != "":
+		return "project " + project
+	}
+	return "organization " + org
+}
+
+// instructions tells the client's model which context the tools act on.
+func instructions(project, org, namespace string, platformWide bool) string {
+	return fmt.Sprintf("These tools act on Datum Cloud %s as the signed-in datumctl user; the default namespace is %q. "+
+		"Call list_resource_types to discover what exists and get_resource_schema before writing a manifest. "+
+		"apply_manifest and delete_resource change resources and may ask the user to confirm.",
+		scopeLabel(project, org, platformWide), namespace)
+}
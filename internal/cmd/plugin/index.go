@@ -14,6 +14,7 @@ import (
 	"k8s.io/kubectl/pkg/util/templates"
 
 	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/output"
 	"go.datum.net/datumctl/internal/pluginstore"
 )
 
@@ -177,17 +178,25 @@ func indexAddCmd() *cobra.Command {
 }
 
 func indexListCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List registered plugin catalogs",
 		Long: templates.LongDesc(`
 			List every registered plugin catalog with its type, plugin count, trust
-			badge, and description. The official datum catalog is always shown first.`),
+			badge, and description. The official datum catalog is always shown first.
+			Use -o json or -o yaml for a machine-readable CatalogList document.`),
 		Example: templates.Examples(`
 			# List registered catalogs
-			datumctl plugin index list`),
+			datumctl plugin index list
+
+			# List registered catalogs as YAML
+			datumctl plugin index list -o yaml`),
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output.OutputFormat(cmd)
+			if err != nil {
+				return err
+			}
 			pluginsDir, err := resolvePluginsDir(cmd)
 			if err != nil {
 				return err
@@ -230,22 +239,45 @@ func indexListCmd() *cobra.Command {
 			}
 			wg.Wait()
 
-			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
-			fmt.Fprintln(w, "NAME\tTYPE\tPLUGINS\tTRUST\tDESCRIPTION")
+			doc := output.CatalogList{
+				TypeMeta: output.NewTypeMeta("CatalogList"),
+				Catalogs: make([]output.CatalogSummary, 0, len(reg.Catalogs)),
+			}
 			for i := range reg.Catalogs {
 				cat := &reg.Catalogs[i]
-				desc := catalogDescription(cat)
-				count := "—"
-				if idx := indexes[i]; idx != nil && !idx.RefreshedAt.IsZero() {
-					count = fmt.Sprintf("%d", len(idx.Plugins))
-					if desc == "" && idx.Header.Description != "" {
-						desc = idx.Header.Description
+				summary := output.CatalogSummary{
+					Name:           cat.Name,
+					Type:           cat.Type,
+					Trust:          cat.Trust(),
+					Description:    catalogDescription(cat),
+					Disabled:       cat.Disabled,
+					DisabledReason: cat.DisabledReason,
+				}
+				if idx := indexes[i]; idx != nil && !idx.RefreshedAt.IsZero() && !cat.Disabled {
+					count := len(idx.Plugins)
+					summary.PluginCount = &count
+					if summary.Description == "" && idx.Header.Description != "" {
+						summary.Description = idx.Header.Description
 					}
 				}
+				doc.Catalogs = append(doc.Catalogs, summary)
+			}
+
+			if format != "" {
+				return output.PrintStructured(cmd.OutOrStdout(), format, doc)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tTYPE\tPLUGINS\tTRUST\tDESCRIPTION")
+			for _, cat := range doc.Catalogs {
+				desc := cat.Description
+				count := "—"
+				if cat.PluginCount != nil {
+					count = fmt.Sprintf("%d", *cat.PluginCount)
+				}
 				if cat.Disabled {
 					// Mark a disabled catalog inline so the user understands why it no
 					// longer participates in search/install rather than seeing it vanish.
-					count = "—"
 					marker := "(disabled: not in allow-list)"
 					if desc != "" {
 						desc = marker + " " + desc
@@ -253,11 +285,13 @@ func indexListCmd() *cobra.Command {
 						desc = marker
 					}
 				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", cat.Name, cat.Type, count, cat.Trust(), desc)
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", cat.Name, cat.Type, count, cat.Trust, desc)
 			}
 			return w.Flush()
 		},
 	}
+	output.AddOutputFlag(cmd)
+	return cmd
 }
 
 func indexRemoveCmd() *cobra.Command {
@@ -3,12 +3,14 @@ package plugin
 import (
 	"fmt"
 	"os"
+	"sort"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 	"k8s.io/kubectl/pkg/util/templates"
 
+	"go.datum.net/datumctl/internal/output"
 	"go.datum.net/datumctl/internal/plugindispatch"
 	"go.datum.net/datumctl/internal/pluginstore"
 )
@@ -27,11 +29,19 @@ func listCmd() *cobra.Command {
 			  ?       Version info unavailable.
 
 			Run 'datumctl plugin search' to refresh available plugins from your
-			catalogs.`),
+			catalogs. Use -o json or -o yaml for a machine-readable PluginList
+			document.`),
 		Example: templates.Examples(`
 			# List all installed plugins
-			datumctl plugin list`),
+			datumctl plugin list
+
+			# List installed plugins as JSON
+			datumctl plugin list -o json`),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output.OutputFormat(cmd)
+			if err != nil {
+				return err
+			}
 			pluginsDir, err := resolvePluginsDir(cmd)
 			if err != nil {
 				return err
@@ -42,7 +52,7 @@ func listCmd() *cobra.Command {
 				return fmt.Errorf("load plugins manifest: %w", err)
 			}
 
-			if len(manifest.Plugins) == 0 {
+			if len(manifest.Plugins) == 0 && format == "" {
 				fmt.Fprintln(cmd.OutOrStdout(), "No managed plugins installed.")
 				return nil
 			}
@@ -59,32 +69,51 @@ func listCmd() *cobra.Command {
 				return idx
 			}
 
-			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
-			fmt.Fprintln(w, "NAME\tINDEX\tVERSION\tTRUST\tDESCRIPTION\tSTATUS")
-			var anyUpdates bool
+			doc := output.PluginList{
+				TypeMeta: output.NewTypeMeta("PluginList"),
+				Plugins:  make([]output.InstalledPluginSummary, 0, len(manifest.Plugins)),
+			}
 			for name, entry := range manifest.Plugins {
-				description := ""
-				status := "?"
+				summary := output.InstalledPluginSummary{
+					Name:    name,
+					Version: entry.Version,
+					Status:  "unknown",
+				}
 				if entry.Manifest != nil {
-					description = entry.Manifest.Description
+					summary.Description = entry.Manifest.Description
 					if entry.Manifest.APIVersion == plugindispatch.PluginAPIVersion {
-						status = "ok"
+						summary.Status = "ok"
 					} else {
-						status = "!"
+						summary.Status = "incompatible"
 					}
 				}
-				indexLabel, trust := installedCatalogLabel(entry)
+				summary.Catalog, summary.Trust = installedCatalogLabel(entry)
 				// Update detection only applies to catalog-sourced plugins (the
 				// "(direct)" label marks a direct GitHub install with no catalog).
-				if status == "ok" && indexLabel != "(direct)" {
-					if indexEntry := pluginstore.FindInIndex(catalogIndex(indexLabel), name); indexEntry != nil {
+				if summary.Status == "ok" && summary.Catalog != "(direct)" {
+					if indexEntry := pluginstore.FindInIndex(catalogIndex(summary.Catalog), name); indexEntry != nil {
 						if isUpdateAvailable(entry.Version, indexEntry.Spec.Version) {
-							status = "update"
-							anyUpdates = true
+							summary.Status = "update-available"
+							summary.LatestVersion = indexEntry.Spec.Version
 						}
 					}
 				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", name, indexLabel, entry.Version, trust, description, status)
+				doc.Plugins = append(doc.Plugins, summary)
+			}
+			sort.Slice(doc.Plugins, func(i, j int) bool { return doc.Plugins[i].Name < doc.Plugins[j].Name })
+
+			if format != "" {
+				return output.PrintStructured(cmd.OutOrStdout(), format, doc)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tINDEX\tVERSION\tTRUST\tDESCRIPTION\tSTATUS")
+			var anyUpdates bool
+			for _, p := range doc.Plugins {
+				if p.Status == "update-available" {
+					anyUpdates = true
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.Name, p.Catalog, p.Version, p.Trust, p.Description, listStatusIndicator(p.Status))
 			}
 			if err := w.Flush(); err != nil {
 				return err
@@ -96,5 +125,21 @@ func listCmd() *cobra.Command {
 			return nil
 		},
 	}
+	output.AddOutputFlag(cmd)
 	return cmd
 }
+
+// listStatusIndicator maps a structured plugin status to the compact marker
+// shown in the STATUS column.
+func listStatusIndicator(status string) string {
+	switch status {
+	case "ok":
+		return "ok"
+	case "update-available":
+		return "update"
+	case "incompatible":
+		return "!"
+	default:
+		return "?"
+	}
+}
@@ -2,17 +2,19 @@ package plugin
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
 
+	"go.datum.net/datumctl/internal/output"
 	"go.datum.net/datumctl/internal/plugindispatch"
 	"go.datum.net/datumctl/internal/pluginstore"
 )
 
 // executeListCmd runs the list subcommand with the given plugins.json content
 // and returns the captured stdout.
-func executeListCmd(t *testing.T, manifest *pluginstore.Manifest) string {
+func executeListCmd(t *testing.T, manifest *pluginstore.Manifest, extraArgs ...string) string {
 	t.Helper()
 
 	dir := t.TempDir()
@@ -26,7 +28,7 @@ func executeListCmd(t *testing.T, manifest *pluginstore.Manifest) string {
 	var out bytes.Buffer
 	cmd.SetOut(&out)
 	cmd.SetErr(&out)
-	cmd.SetArgs([]string{"list"})
+	cmd.SetArgs(append([]string{"list"}, extraArgs...))
 
 	if err := cmd.Execute(); err != nil {
 		t.Fatalf("Execute list: %v", err)
@@ -108,3 +110,55 @@ func TestListCmd_showsAPIVersionMismatch(t *testing.T) {
 		t.Errorf("list output %q does not contain '!' for API version mismatch", output)
 	}
 }
+
+// TestListCmd_jsonOutput verifies that -o json emits a versioned PluginList
+// document with named statuses instead of the table's compact markers.
+func TestListCmd_jsonOutput(t *testing.T) {
+	t.Parallel()
+
+	manifest := &pluginstore.Manifest{
+		Plugins: map[string]*pluginstore.InstalledPlugin{
+			"dns": {
+				Source:      "github.com/datum-cloud/datumctl-dns",
+				Version:     "v0.1.0",
+				InstalledAt: time.Now().UTC(),
+				Manifest: &pluginstore.PluginManifest{
+					Name:        "datumctl-dns",
+					Description: "Manage DNS zones on Datum Cloud",
+					APIVersion:  plugindispatch.PluginAPIVersion + 999,
+				},
+			},
+		},
+	}
+
+	out := executeListCmd(t, manifest, "-o", "json")
+
+	var doc output.PluginList
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("unmarshal list output %q: %v", out, err)
+	}
+	if doc.APIVersion != output.SchemaAPIVersion || doc.Kind != "PluginList" {
+		t.Errorf("TypeMeta = %+v, want %s PluginList", doc.TypeMeta, output.SchemaAPIVersion)
+	}
+	if len(doc.Plugins) != 1 {
+		t.Fatalf("got %d plugins, want 1", len(doc.Plugins))
+	}
+	if got := doc.Plugins[0]; got.Name != "dns" || got.Status != "incompatible" || got.Description != "Manage DNS zones on Datum Cloud" {
+		t.Errorf("plugin = %+v, want dns/incompatible with description", got)
+	}
+}
+
+// TestListCmd_jsonOutputEmpty verifies that an empty install set still emits a
+// document with an empty plugins array rather than the human message.
+func TestListCmd_jsonOutputEmpty(t *testing.T) {
+	t.Parallel()
+
+	out := executeListCmd(t, &pluginstore.Manifest{}, "-o", "json")
+
+	if strings.Contains(out, "No managed plugins installed") {
+		t.Errorf("json output %q contains the human empty-state message", out)
+	}
+	if !strings.Contains(out, `"plugins": []`) {
+		t.Errorf("json output %q does not contain an empty plugins array", out)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"k8s.io/kubectl/pkg/util/templates"
 
 	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/output"
 	"go.datum.net/datumctl/internal/pluginstore"
 )
 
@@ -35,9 +36,16 @@ func searchCmd() *cobra.Command {
 			datumctl plugin search dns
 
 			# Scope the search to one catalog
-			datumctl plugin search dns --index acme`),
+			datumctl plugin search dns --index acme
+
+			# Emit results as JSON for scripting
+			datumctl plugin search dns -o json`),
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := output.OutputFormat(cmd)
+			if err != nil {
+				return err
+			}
 			pluginsDir, err := resolvePluginsDir(cmd)
 			if err != nil {
 				return err
@@ -65,14 +73,22 @@ func searchCmd() *cobra.Command {
 				query = strings.ToLower(args[0])
 			}
 
-			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
-			fmt.Fprintln(w, "NAME\tINDEX\tVERSION\tTRUST\tDESCRIPTION")
-			var rows int
+			doc := output.PluginSearchResult{
+				TypeMeta: output.NewTypeMeta("PluginSearchResult"),
+				Query:    query,
+				Plugins:  []output.AvailablePluginSummary{},
+			}
 			for i := range catalogs {
 				cat := catalogs[i]
 				idx, idxErr := loadOrRefreshCatalog(cmd, pluginsDir, cat)
 				if idxErr != nil {
-					fmt.Fprintf(cmd.ErrOrStderr(), "warning: skipping catalog %q: %v\n", cat.Name, idxErr)
+					if format == "" {
+						fmt.Fprintf(cmd.ErrOrStderr(), "warning: skipping catalog %q: %v\n", cat.Name, idxErr)
+					}
+					if doc.SkippedCatalogs == nil {
+						doc.SkippedCatalogs = map[string]string{}
+					}
+					doc.SkippedCatalogs[cat.Name] = idxErr.Error()
 					continue
 				}
 				for j := range idx.Plugins {
@@ -81,20 +97,35 @@ func searchCmd() *cobra.Command {
 						!strings.Contains(strings.ToLower(p.Spec.ShortDescription), query) {
 						continue
 					}
-					fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-						p.Name, cat.Name, p.Spec.Version, cat.Trust(), p.Spec.ShortDescription)
-					rows++
+					doc.Plugins = append(doc.Plugins, output.AvailablePluginSummary{
+						Name:        p.Name,
+						Catalog:     cat.Name,
+						Version:     p.Spec.Version,
+						Trust:       cat.Trust(),
+						Description: p.Spec.ShortDescription,
+					})
 				}
 			}
+
+			if format != "" {
+				return output.PrintStructured(cmd.OutOrStdout(), format, doc)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tINDEX\tVERSION\tTRUST\tDESCRIPTION")
+			for _, p := range doc.Plugins {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Name, p.Catalog, p.Version, p.Trust, p.Description)
+			}
 			if err := w.Flush(); err != nil {
 				return err
 			}
-			if rows == 0 {
+			if len(doc.Plugins) == 0 {
 				fmt.Fprintln(cmd.OutOrStdout(), "No matching plugins found.")
 			}
 			return nil
 		},
 	}
 	cmd.Flags().StringVar(&indexName, "index", "", "Scope the search to a single catalog")
+	output.AddOutputFlag(cmd)
 	return cmd
 }
@@ -37,14 +37,18 @@ import (
 	"go.datum.net/datumctl/internal/cmd/create"
 	datumctx "go.datum.net/datumctl/internal/cmd/ctx"
 	"go.datum.net/datumctl/internal/cmd/docs"
+	doctorcmd "go.datum.net/datumctl/internal/cmd/doctor"
+	errorscmd "go.datum.net/datumctl/internal/cmd/errors"
 	"go.datum.net/datumctl/internal/cmd/login"
 	"go.datum.net/datumctl/internal/cmd/logout"
+	mcpcmd "go.datum.net/datumctl/internal/cmd/mcp"
 	plugincmd "go.datum.net/datumctl/internal/cmd/plugin"
 	"go.datum.net/datumctl/internal/cmd/whoami"
 	"go.datum.net/datumctl/internal/datumconfig"
 	customerrors "go.datum.net/datumctl/internal/errors"
 	"go.datum.net/datumctl/internal/plugindispatch"
 	"go.datum.net/datumctl/internal/pluginstore"
+	"go.datum.net/datumctl/internal/telemetry"
 	"go.datum.net/datumctl/internal/updatecheck"
 )
 
@@ -113,6 +117,7 @@ Get started:
 			// as client-go already does for kubectl-backed commands. No-op
 			// below -v 6.
 			http.DefaultTransport = transport.DebugWrappers(http.DefaultTransport)
+			telemetry.SetCommand(cmd.CommandPath())
 
 			format, _ := cmd.Flags().GetString("error-format")
 			switch format {
@@ -171,7 +176,7 @@ Get started:
 						"'%s' is an unmanaged plugin that has not been trusted.\n"+
 							"  To allow it: datumctl plugin trust %s\n"+
 							"  To install as a managed plugin: datumctl plugin install %s",
-						filepath.Base(binaryPath), name, name))
+						filepath.Base(binaryPath), name, name)).WithCode(customerrors.CodePluginUntrusted)
 				}
 			}
 
@@ -180,7 +185,7 @@ Get started:
 				// Integrity: re-verify SHA256 in case the binary changed since
 				// ForwardPlugin ran (or ForwardPlugin was skipped due to an error).
 				if integrityErr := plugindispatch.VerifyManagedPluginIntegrity(earlyPluginsDir, name, binaryPath); integrityErr != nil {
-					return customerrors.NewUserError(integrityErr.Error())
+					return customerrors.NewUserError(integrityErr.Error()).WithCode(customerrors.CodeIntegrityMismatch)
 				}
 
 				manifest, loadErr := pluginstore.Load(pluginsDir)
@@ -658,6 +663,10 @@ the server.`
 	aiCmd.GroupID = "other"
 	rootCmd.AddCommand(aiCmd)
 
+	mcpCmd := mcpcmd.Command(factory)
+	mcpCmd.GroupID = "other"
+	rootCmd.AddCommand(mcpCmd)
+
 	activityCmd := activity.NewActivityCommand(activity.ActivityCommandOptions{
 		Factory:   factory,
 		IOStreams: ioStreams,
@@ -747,6 +756,14 @@ Specify the resource type and name to view its history.`
 	docsCmd.GroupID = "other"
 	rootCmd.AddCommand(docsCmd)
 
+	errorsCmd := errorscmd.Command()
+	errorsCmd.GroupID = "other"
+	rootCmd.AddCommand(errorsCmd)
+
+	doctorCmd := doctorcmd.Command()
+	doctorCmd.GroupID = "other"
+	rootCmd.AddCommand(doctorCmd)
+
 	consoleCmd := console.Command(factory)
 	consoleCmd.GroupID = "other"
 	rootCmd.AddCommand(consoleCmd)
@@ -10,19 +10,27 @@ import (
 	"go.datum.net/datumctl/internal/authutil"
 	"go.datum.net/datumctl/internal/datumconfig"
 	"go.datum.net/datumctl/internal/onboarding"
+	"go.datum.net/datumctl/internal/output"
 )
 
 // Command returns the top-level "whoami" command.
 func Command() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "whoami",
 		Short: "Show the current user and context",
 		Args:  cobra.NoArgs,
 		RunE:  runWhoami,
 	}
+	output.AddOutputFlag(cmd)
+	return cmd
 }
 
 func runWhoami(cmd *cobra.Command, _ []string) error {
+	format, err := output.OutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
 	cfg, err := datumconfig.LoadAuto()
 	if err != nil {
 		return err
@@ -51,6 +59,11 @@ func runWhoami(cmd *cobra.Command, _ []string) error {
 		userEmail = session.UserEmail
 	}
 
+	if format != "" {
+		doc := whoamiDocument(cmd.Context(), cfg, session, userName, userEmail)
+		return output.PrintStructured(cmd.OutOrStdout(), format, doc)
+	}
+
 	fmt.Printf("User:         %s (%s)\n", userName, userEmail)
 
 	printOnboardingStatus(cmd.Context(), cfg, session)
@@ -87,26 +100,87 @@ func runWhoami(cmd *cobra.Command, _ []string) error {
 	return nil
 }
 
-func printOnboardingStatus(ctx context.Context, cfg *datumconfig.ConfigV1Beta1, session *datumconfig.Session) {
+// whoamiDocument builds the structured WhoAmI document. Onboarding is omitted
+// when no organization is in effect or the session's credentials can't be
+// read, and reported as "unknown" when the check itself fails.
+func whoamiDocument(ctx context.Context, cfg *datumconfig.ConfigV1Beta1, session *datumconfig.Session, userName, userEmail string) output.WhoAmI {
+	doc := output.WhoAmI{
+		TypeMeta: output.NewTypeMeta("WhoAmI"),
+		User:     output.WhoAmIUser{Name: userName, Email: userEmail},
+		Session:  session.Name,
+		Endpoint: session.Endpoint.Server,
+	}
+	if ctxEntry := cfg.CurrentContextEntry(); ctxEntry != nil {
+		summary := output.NewContextSummary(cfg, ctxEntry)
+		doc.Context = &summary
+	}
+
+	if result, checked, err := checkOnboarding(ctx, cfg, session); checked {
+		status := &output.OnboardingStatus{State: "unknown"}
+		if err == nil {
+			status.State = onboardingState(result.State)
+			status.Reason = result.Reason
+			status.ActionURL = result.ActionURL
+		}
+		doc.Onboarding = status
+	}
+
+	for _, name := range []string{"DATUM_PROJECT", "DATUM_ORGANIZATION"} {
+		if v := os.Getenv(name); v != "" {
+			if doc.Overrides == nil {
+				doc.Overrides = map[string]string{}
+			}
+			doc.Overrides[name] = v
+		}
+	}
+	return doc
+}
+
+// onboardingState maps an onboarding.State to its structured output name.
+func onboardingState(state onboarding.State) string {
+	switch state {
+	case onboarding.Complete:
+		return "complete"
+	case onboarding.NeedsOnboarding:
+		return "needs-onboarding"
+	case onboarding.OrgIncomplete:
+		return "org-incomplete"
+	default:
+		return "unknown"
+	}
+}
+
+// checkOnboarding runs the onboarding check for the effective organization.
+// checked is false when there is nothing to check or the session's
+// credentials are unavailable; err reports a failure of the check itself.
+func checkOnboarding(ctx context.Context, cfg *datumconfig.ConfigV1Beta1, session *datumconfig.Session) (result onboarding.Result, checked bool, err error) {
 	orgID := onboarding.ResolveEffectiveOrgID(cfg, os.Getenv("DATUM_PROJECT"), os.Getenv("DATUM_ORGANIZATION"))
 	if orgID == "" {
-		return
+		return onboarding.Result{}, false, nil
 	}
 
 	tknSrc, err := authutil.GetTokenSourceForUser(ctx, session.UserKey)
 	if err != nil {
-		return
+		return onboarding.Result{}, false, nil
 	}
 	userID, err := authutil.GetUserIDFromTokenForUser(session.UserKey)
 	if err != nil {
-		return
+		return onboarding.Result{}, false, nil
 	}
 	apiHostname, err := authutil.GetAPIHostnameForUser(session.UserKey)
 	if err != nil {
-		return
+		return onboarding.Result{}, false, nil
 	}
 
-	result, err := onboarding.CheckOrg(ctx, apiHostname, tknSrc, userID, orgID, cfg.OrgDisplayName(session.Name, orgID))
+	result, err = onboarding.CheckOrg(ctx, apiHostname, tknSrc, userID, orgID, cfg.OrgDisplayName(session.Name, orgID))
+	return result, true, err
+}
+
+func printOnboardingStatus(ctx context.Context, cfg *datumconfig.ConfigV1Beta1, session *datumconfig.Session) {
+	result, checked, err := checkOnboarding(ctx, cfg, session)
+	if !checked {
+		return
+	}
 	if err != nil {
 		fmt.Println("Onboarding:   couldn't check")
 		return
@@ -1,6 +1,7 @@
 // Package chatstorage persists AI chat conversations to ~/.datumctl/conversations/.
 // Each conversation is a single JSON file; the store provides list, load, save,
-// and last-conversation helpers used by the console AppModel.
+// and last-conversation helpers used by the console AppModel and by
+// 'datumctl ai --resume' and 'datumctl ai history'.
 package chatstorage
 
 import (
@@ -11,33 +12,53 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"go.datum.net/datumctl/internal/ai/llm"
 )
 
+// Version is the conversation format written by this build.
+//
+//	1 (or absent): user and assistant text only.
+//	2: the full agent transcript, with tool calls and results, and the
+//	   namespace and platform-wide mode the conversation ran in.
+const Version = 2
+
 // Message is one turn in a conversation.
 type Message struct {
-	Role      string    `json:"role"`    // "user" or "assistant"
+	Role      string    `json:"role"` // "user", "assistant", or "tool_result"
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"at"`
+
+	// ToolCalls are the tools an assistant message asked to run.
+	ToolCalls []llm.ToolCall `json:"tool_calls,omitempty"`
+	// ToolResult is set on "tool_result" messages.
+	ToolResult *llm.ToolResult `json:"tool_result,omitempty"`
 }
 
 // Conversation is the full record for one chat session.
 type Conversation struct {
-	ID        string    `json:"id"`
-	StartedAt time.Time `json:"started_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	OrgID     string    `json:"org_id,omitempty"`
-	ProjectID string    `json:"project_id,omitempty"`
-	Messages  []Message `json:"messages"`
+	Version      int       `json:"version,omitempty"`
+	ID           string    `json:"id"`
+	StartedAt    time.Time `json:"started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	OrgID        string    `json:"org_id,omitempty"`
+	ProjectID    string    `json:"project_id,omitempty"`
+	Namespace    string    `json:"namespace,omitempty"`
+	PlatformWide bool      `json:"platform_wide,omitempty"`
+	Messages     []Message `json:"messages"`
 }
 
 // Meta is a lightweight index entry for the conversation list.
 type Meta struct {
-	ID        string
-	StartedAt time.Time
-	UpdatedAt time.Time
-	OrgID     string
-	ProjectID string
-	Preview   string // first user message, truncated
+	ID           string
+	StartedAt    time.Time
+	UpdatedAt    time.Time
+	OrgID        string
+	ProjectID    string
+	Namespace    string
+	PlatformWide bool
+	Questions    int    // number of user messages
+	Preview      string // first user message, truncated
 }
 
 // Store manages conversation files under Dir.
@@ -67,6 +88,7 @@ func NewConversation(orgID, projectID string) *Conversation {
 	now := time.Now().UTC()
 	id := now.Format("20060102-150405") + "-" + randomSuffix()
 	return &Conversation{
+		Version:   Version,
 		ID:        id,
 		StartedAt: now,
 		UpdatedAt: now,
@@ -85,6 +107,42 @@ func (c *Conversation) AddMessage(role, content string) {
 	c.UpdatedAt = time.Now().UTC()
 }
 
+// SetTranscript replaces the messages with an agent transcript, keeping the
+// timestamps of the messages already recorded.
+func (c *Conversation) SetTranscript(msgs []llm.Message) {
+	now := time.Now().UTC()
+	out := make([]Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = Message{Role: string(m.Role), Content: m.Content, Timestamp: now, ToolCalls: m.ToolCalls, ToolResult: m.ToolResult}
+		if i < len(c.Messages) && c.Messages[i].Role == out[i].Role && c.Messages[i].Content == out[i].Content {
+			out[i].Timestamp = c.Messages[i].Timestamp
+		}
+	}
+	c.Messages = out
+	c.Version = Version
+	c.UpdatedAt = now
+}
+
+// History returns the conversation as agent history, to resume it. Version 1
+// conversations hold only text, so the model sees the answers but not the
+// tool calls behind them.
+func (c *Conversation) History() []llm.Message {
+	history := make([]llm.Message, 0, len(c.Messages))
+	for _, m := range c.Messages {
+		switch llm.Role(m.Role) {
+		case llm.RoleUser:
+			history = append(history, llm.Message{Role: llm.RoleUser, Content: m.Content})
+		case llm.RoleAssistant:
+			history = append(history, llm.Message{Role: llm.RoleAssistant, Content: m.Content, ToolCalls: m.ToolCalls})
+		case llm.RoleToolResult:
+			if m.ToolResult != nil {
+				history = append(history, llm.Message{Role: llm.RoleToolResult, ToolResult: m.ToolResult})
+			}
+		}
+	}
+	return history
+}
+
 // Save writes the conversation to store as <ID>.json, overwriting any prior version.
 func (s *Store) Save(c *Conversation) error {
 	data, err := json.MarshalIndent(c, "", "  ")
@@ -100,6 +158,9 @@ func (s *Store) Save(c *Conversation) error {
 
 // Load reads a conversation by ID.
 func (s *Store) Load(id string) (*Conversation, error) {
+	if err := checkID(id); err != nil {
+		return nil, err
+	}
 	path := filepath.Join(s.Dir, id+".json")
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -109,9 +170,28 @@ func (s *Store) Load(id string) (*Conversation, error) {
 	if err := json.Unmarshal(data, &c); err != nil {
 		return nil, fmt.Errorf("parse conversation %s: %w", id, err)
 	}
+	if c.Version > Version {
+		return nil, fmt.Errorf("conversation %s was saved in format version %d by a newer datumctl; upgrade to open it", id, c.Version)
+	}
 	return &c, nil
 }
 
+// Resolve loads the conversation ref names: "last" for the most recently
+// updated one, or an ID.
+func (s *Store) Resolve(ref string) (*Conversation, error) {
+	if ref != "last" {
+		return s.Load(ref)
+	}
+	c, err := s.Last()
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("no saved conversations in %s", s.Dir)
+	}
+	return c, nil
+}
+
 // List returns metadata for all stored conversations, newest first.
 func (s *Store) List() ([]*Meta, error) {
 	entries, err := os.ReadDir(s.Dir)
@@ -133,20 +213,25 @@ func (s *Store) List() ([]*Meta, error) {
 			continue // skip malformed files silently
 		}
 		m := &Meta{
-			ID:        c.ID,
-			StartedAt: c.StartedAt,
-			UpdatedAt: c.UpdatedAt,
-			OrgID:     c.OrgID,
-			ProjectID: c.ProjectID,
+			ID:           c.ID,
+			StartedAt:    c.StartedAt,
+			UpdatedAt:    c.UpdatedAt,
+			OrgID:        c.OrgID,
+			ProjectID:    c.ProjectID,
+			Namespace:    c.Namespace,
+			PlatformWide: c.PlatformWide,
 		}
 		for _, msg := range c.Messages {
-			if msg.Role == "user" {
+			if msg.Role != "user" {
+				continue
+			}
+			m.Questions++
+			if m.Preview == "" {
 				preview := strings.ReplaceAll(msg.Content, "\n", " ")
 				if len(preview) > 60 {
 					preview = preview[:59] + "…"
 				}
 				m.Preview = preview
-				break
 			}
 		}
 		metas = append(metas, m)
@@ -160,6 +245,9 @@ func (s *Store) List() ([]*Meta, error) {
 
 // Delete removes a conversation by ID. Returns nil if the file does not exist.
 func (s *Store) Delete(id string) error {
+	if err := checkID(id); err != nil {
+		return err
+	}
 	path := filepath.Join(s.Dir, id+".json")
 	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("delete conversation %s: %w", id, err)
@@ -176,6 +264,14 @@ func (s *Store) Last() (*Conversation, error) {
 	return s.Load(metas[0].ID)
 }
 
+// checkID rejects IDs that would resolve outside the store directory.
+func checkID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid conversation ID %q", id)
+	}
+	return nil
+}
+
 // randomSuffix returns a short pseudo-random hex string for ID uniqueness.
 func randomSuffix() string {
 	f, err := os.Open("/dev/urandom")
@@ -0,0 +1,195 @@
+package chatstorage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSetTranscriptKeepsTimestamps(t *testing.T) {
+	c := NewConversation("org", "proj")
+	c.AddMessage("user", "list zones")
+	asked := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.Messages[0].Timestamp = asked
+
+	call := llm.ToolCall{ID: "call-1", ToolName: "list_resources", Arguments: map[string]any{"kind": "DNSZone"}}
+	result := &llm.ToolResult{CallID: "call-1", Content: `{"items":[]}`}
+	c.SetTranscript([]llm.Message{
+		{Role: llm.RoleUser, Content: "list zones"},
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{call}},
+		{Role: llm.RoleToolResult, ToolResult: result},
+		{Role: llm.RoleAssistant, Content: "No zones."},
+	})
+
+	if len(c.Messages) != 4 {
+		t.Fatalf("messages = %+v, want the four transcript messages", c.Messages)
+	}
+	if !c.Messages[0].Timestamp.Equal(asked) {
+		t.Errorf("recorded message timestamp = %v, want %v kept", c.Messages[0].Timestamp, asked)
+	}
+	if c.Messages[3].Timestamp.IsZero() || c.Messages[3].Timestamp.Equal(asked) {
+		t.Errorf("new message timestamp = %v, want the time of the update", c.Messages[3].Timestamp)
+	}
+	if !reflect.DeepEqual(c.Messages[1].ToolCalls, []llm.ToolCall{call}) || c.Messages[2].ToolResult != result {
+		t.Errorf("tool call and result not kept: %+v", c.Messages[1:3])
+	}
+	if c.Version != Version {
+		t.Errorf("version = %d, want %d", c.Version, Version)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	call := llm.ToolCall{ID: "call-1", ToolName: "list_resources"}
+	result := &llm.ToolResult{CallID: "call-1", Content: "[]"}
+	for _, tc := range []struct {
+		name     string
+		messages []Message
+		want     []llm.Message
+	}{
+		{
+			name: "version 1 with tool events",
+			messages: []Message{
+				{Role: "user", Content: "list zones"},
+				{Role: "tool", Content: "list_resources"},
+				{Role: "assistant", Content: "No zones."},
+			},
+			want: []llm.Message{
+				{Role: llm.RoleUser, Content: "list zones"},
+				{Role: llm.RoleAssistant, Content: "No zones."},
+			},
+		},
+		{
+			name: "version 2 with tool calls",
+			messages: []Message{
+				{Role: "user", Content: "list zones"},
+				{Role: "assistant", ToolCalls: []llm.ToolCall{call}},
+				{Role: "tool_result", ToolResult: result},
+				{Role: "assistant", Content: "No zones."},
+			},
+			want: []llm.Message{
+				{Role: llm.RoleUser, Content: "list zones"},
+				{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{call}},
+				{Role: llm.RoleToolResult, ToolResult: result},
+				{Role: llm.RoleAssistant, Content: "No zones."},
+			},
+		},
+		{
+			name: "tool result without a result",
+			messages: []Message{
+				{Role: "user", Content: "hi"},
+				{Role: "tool_result"},
+			},
+			want: []llm.Message{{Role: llm.RoleUser, Content: "hi"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Conversation{Messages: tc.messages}
+			if got := c.History(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("History() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	store := newTestStore(t)
+	if _, err := store.Resolve("last"); err == nil || !strings.Contains(err.Error(), "no saved conversations") {
+		t.Errorf("Resolve(last) in an empty store = %v, want no saved conversations", err)
+	}
+
+	older := NewConversation("org", "")
+	older.ID = "older"
+	older.UpdatedAt = time.Now().Add(-time.Hour)
+	newer := NewConversation("org", "")
+	newer.ID = "newer"
+	for _, c := range []*Conversation{older, newer} {
+		if err := store.Save(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for ref, want := range map[string]string{"last": "newer", "older": "older"} {
+		got, err := store.Resolve(ref)
+		if err != nil {
+			t.Fatalf("Resolve(%q): %v", ref, err)
+		}
+		if got.ID != want {
+			t.Errorf("Resolve(%q) = %s, want %s", ref, got.ID, want)
+		}
+	}
+}
+
+func TestInvalidIDs(t *testing.T) {
+	store := newTestStore(t)
+	// A file outside the store that a traversing ID would reach.
+	outside := filepath.Join(filepath.Dir(store.Dir), "outside.json")
+	if err := os.WriteFile(outside, []byte(`{"id":"outside"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"", ".", "..", "../outside", "a/b", `a\b`} {
+		if _, err := store.Load(id); err == nil || !strings.Contains(err.Error(), "invalid conversation ID") {
+			t.Errorf("Load(%q) = %v, want an invalid ID error", id, err)
+		}
+		if err := store.Delete(id); err == nil || !strings.Contains(err.Error(), "invalid conversation ID") {
+			t.Errorf("Delete(%q) = %v, want an invalid ID error", id, err)
+		}
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("file outside the store: %v, want it untouched", err)
+	}
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	store := newTestStore(t)
+	c := NewConversation("", "")
+	c.Version = Version + 1
+	if err := store.Save(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Load(c.ID); err == nil || !strings.Contains(err.Error(), "newer datumctl") {
+		t.Errorf("Load = %v, want a newer-version error", err)
+	}
+	if metas, err := store.List(); err != nil || len(metas) != 0 {
+		t.Errorf("List = %v, %v; want the unreadable conversation left out", metas, err)
+	}
+}
+
+func TestListMeta(t *testing.T) {
+	store := newTestStore(t)
+	c := NewConversation("org", "proj")
+	c.Namespace = "team"
+	c.AddMessage("user", "list\nzones")
+	c.AddMessage("assistant", "No zones.")
+	c.AddMessage("user", "and records?")
+	if err := store.Save(c); err != nil {
+		t.Fatal(err)
+	}
+
+	metas, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metas) != 1 {
+		t.Fatalf("List = %+v, want one conversation", metas)
+	}
+	m := metas[0]
+	if m.Questions != 2 || m.Preview != "list zones" || m.Namespace != "team" || m.ProjectID != "proj" {
+		t.Errorf("meta = %+v, want 2 questions, the first as preview, and the scope", m)
+	}
+}
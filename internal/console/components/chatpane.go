@@ -29,6 +29,7 @@ type ChatPaneModel struct {
 	agentErr       string
 	confirmPending bool
 	confirmCall    llm.ToolCall // non-zero when confirmPending
+	confirmPreview string       // dry-run diff for confirmCall, if any
 
 	streaming    bool // true while chunks are being appended to the streaming slot
 	streamingIdx int  // index into messages[] of the current assistant streaming slot
@@ -38,6 +39,7 @@ type ChatPaneModel struct {
 
 	orgName     string
 	projectName string
+	usage       string // token usage summary shown in the input separator
 
 	inputReady bool // true once textarea has been initialized via New()
 	input      textarea.Model
@@ -127,10 +129,15 @@ func (m *ChatPaneModel) SetConfirmPending(call llm.ToolCall) {
 	m.confirmCall = call
 }
 
+// SetConfirmPreview sets the diff shown for the pending confirmation in place
+// of the raw tool arguments. Call it after SetConfirmPending.
+func (m *ChatPaneModel) SetConfirmPreview(preview string) { m.confirmPreview = preview }
+
 // ClearConfirmPending clears any pending confirmation request.
 func (m *ChatPaneModel) ClearConfirmPending() {
 	m.confirmPending = false
 	m.confirmCall = llm.ToolCall{}
+	m.confirmPreview = ""
 }
 
 // ConfirmPending reports whether a tool-call confirmation is waiting for input.
@@ -145,6 +152,10 @@ func (m *ChatPaneModel) SetContext(org, project string) {
 	m.projectName = project
 }
 
+// SetUsage sets the token usage summary shown above the input, e.g. after
+// each turn. An empty summary hides it.
+func (m *ChatPaneModel) SetUsage(summary string) { m.usage = summary }
+
 // LastAssistantMessage returns the content of the most recent assistant message, or "".
 func (m ChatPaneModel) LastAssistantMessage() string {
 	for i := len(m.messages) - 1; i >= 0; i-- {
@@ -282,14 +293,23 @@ func (m ChatPaneModel) View() string {
 		title = title + "  " + m.sp.View()
 	}
 	rule := muted.Render(strings.Repeat("─", m.width))
-	sep := muted.Render(strings.Repeat("─", m.width))
+	sep := muted.Render(usageRule(m.usage, m.width))
+
+	// A delete's preview is the whole object; keep at least half the pane
+	// for the conversation.
+	maxPreview := max(3, m.height/2)
 
 	var inputRow string
 	switch {
+	case m.confirmPending && m.confirmPreview != "":
+		preview := clipLines(strings.TrimRight(m.confirmPreview, "\n"), maxPreview)
+		inputRow = lipgloss.NewStyle().Background(styles.Surface).Foreground(styles.Warning).
+			Render(fmt.Sprintf("  Confirm %s?  [y] approve  [n] cancel", m.confirmCall.ToolName)) +
+			"\n" + styles.ColorizeDiff(preview)
 	case m.confirmPending:
 		b, _ := json.MarshalIndent(m.confirmCall.Arguments, "", "  ")
 		inputRow = lipgloss.NewStyle().Background(styles.Surface).Foreground(styles.Warning).
-			Render(fmt.Sprintf("  Confirm %s?  [y] approve  [n] cancel\n%s", m.confirmCall.ToolName, string(b)))
+			Render(fmt.Sprintf("  Confirm %s?  [y] approve  [n] cancel\n%s", m.confirmCall.ToolName, clipLines(string(b), maxPreview)))
 	case m.agentErr != "":
 		inputRow = lipgloss.NewStyle().Background(styles.Surface).Foreground(styles.Error).Render("  " + m.agentErr)
 	default:
@@ -315,6 +335,26 @@ func (m ChatPaneModel) View() string {
 	return styles.PaneBorder(m.focused).Render(content)
 }
 
+// clipLines keeps the first n lines of s, ending with a note of how many
+// were left out.
+func clipLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[:n-1], "\n") + fmt.Sprintf("\n… %d more lines", len(lines)-n+1)
+}
+
+// usageRule returns a width-wide separator with the usage summary set into
+// it, or a plain one when there is no summary or it does not fit.
+func usageRule(usage string, width int) string {
+	label := "── " + usage + " "
+	if usage == "" || lipgloss.Width(label) >= width {
+		return strings.Repeat("─", width)
+	}
+	return label + strings.Repeat("─", width-lipgloss.Width(label))
+}
+
 // rebuildContent regenerates the viewport content from the current message list.
 func (m *ChatPaneModel) rebuildContent() {
 	var sb strings.Builder
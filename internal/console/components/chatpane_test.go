@@ -128,6 +128,41 @@ func TestChatPaneModel_ConfirmPending(t *testing.T) {
 	}
 }
 
+// TestChatPaneModel_ConfirmPreview verifies that a preview set for the pending
+// confirmation replaces the raw JSON arguments in View().
+func TestChatPaneModel_ConfirmPreview(t *testing.T) {
+	t.Parallel()
+	m := NewChatPaneModel(80, 24)
+	m.SetConfirmPending(llm.ToolCall{ToolName: "delete_resource", Arguments: map[string]any{"kind": "DNSZone"}})
+	m.SetConfirmPreview("--- live\n+++ (deleted)\n-  \"kind\": \"DNSZone\"\n")
+
+	plain := stripANSI(m.View())
+	if !strings.Contains(plain, "+++ (deleted)") {
+		t.Errorf("confirm preview: want the diff in View(), got %q", plain)
+	}
+	if strings.Contains(plain, "\"kind\": \"DNSZone\"\n}") {
+		t.Errorf("confirm preview: raw arguments still shown, got %q", plain)
+	}
+}
+
+// TestChatPaneModel_LongConfirmPreview verifies that a preview longer than the
+// pane is clipped, leaving the conversation on screen.
+func TestChatPaneModel_LongConfirmPreview(t *testing.T) {
+	t.Parallel()
+	m := NewChatPaneModel(80, 24)
+	m.AppendUserMessage("delete the zone")
+	m.SetConfirmPending(llm.ToolCall{ToolName: "delete_resource"})
+	m.SetConfirmPreview("--- live\n+++ (deleted)\n" + strings.Repeat("-  line\n", 100))
+
+	plain := stripANSI(m.View())
+	if !strings.Contains(plain, "more lines") {
+		t.Errorf("long preview: want a clipped-lines note, got %q", plain)
+	}
+	if !strings.Contains(plain, "delete the zone") {
+		t.Errorf("long preview: conversation pushed off screen, got %q", plain)
+	}
+}
+
 // TestChatPaneModel_InputCleared verifies that ClearInput resets the input to empty.
 func TestChatPaneModel_InputCleared(t *testing.T) {
 	t.Parallel()
@@ -232,3 +267,21 @@ func TestChatPaneModel_ConfirmPendingClearedAfterClear(t *testing.T) {
 		t.Errorf("after ClearConfirmPending: tool name must NOT appear in View(), got %q", plain)
 	}
 }
+
+// TestChatPaneModel_Usage verifies that the usage summary is shown in the
+// separator above the input when it fits, and left out when it does not.
+func TestChatPaneModel_Usage(t *testing.T) {
+	t.Parallel()
+	m := NewChatPaneModel(80, 24)
+	m.SetAgentReady()
+	m.SetUsage("session 51.0k tokens, ~$0.04")
+
+	if plain := stripANSI(m.View()); !strings.Contains(plain, "── session 51.0k tokens, ~$0.04 ──") {
+		t.Errorf("want the usage summary in View(), got %q", plain)
+	}
+
+	m.SetSize(20, 24)
+	if plain := stripANSI(m.View()); strings.Contains(plain, "session") {
+		t.Errorf("usage summary wider than the pane should be left out, got %q", plain)
+	}
+}
@@ -238,24 +238,39 @@ func (c *HistoryClient) ComputeDiff(
 		return rendered, false, true, nil
 	}
 
-	prevLines := manifestLines(prev)
-	currLines := manifestLines(curr)
-
 	// FromFile = predecessor REV label; ToFile = current REV label.
 	// Index k is 0-based: REV = k+1, predecessor REV = k.
+	body, err = UnifiedDiff(prev, curr, fmt.Sprintf("rev %d", k), fmt.Sprintf("rev %d", k+1))
+	if err != nil {
+		return "", false, false, err
+	}
+	return body, false, false, nil
+}
+
+// UnifiedDiff returns the raw (uncolorized) unified diff between two
+// manifests rendered as indented JSON. A nil manifest diffs as empty, so a
+// creation is all additions and a deletion all removals. The result is empty
+// when the manifests render identically.
+func UnifiedDiff(from, to map[string]any, fromLabel, toLabel string) (string, error) {
+	var a, b []string
+	if from != nil {
+		a = manifestLines(from)
+	}
+	if to != nil {
+		b = manifestLines(to)
+	}
 	var buf bytes.Buffer
 	ud := difflib.UnifiedDiff{
-		A:        prevLines,
-		B:        currLines,
-		FromFile: fmt.Sprintf("rev %d", k),
-		ToFile:   fmt.Sprintf("rev %d", k+1),
+		A:        a,
+		B:        b,
+		FromFile: fromLabel,
+		ToFile:   toLabel,
 		Context:  3,
 	}
-	if e := difflib.WriteUnifiedDiff(&buf, ud); e != nil {
-		return "", false, false, e
+	if err := difflib.WriteUnifiedDiff(&buf, ud); err != nil {
+		return "", err
 	}
-
-	return buf.String(), false, false, nil
+	return buf.String(), nil
 }
 
 // buildHistoryFilter returns a CEL filter expression for an AuditLogQuery.
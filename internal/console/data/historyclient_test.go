@@ -306,6 +306,39 @@ func TestComputeDiff_CurrNil_ReturnsUnparseableMsg(t *testing.T) {
 	}
 }
 
+// --- UnifiedDiff ---
+
+func TestUnifiedDiff_NilSidesAndIdentical(t *testing.T) {
+	t.Parallel()
+	obj := map[string]any{"kind": "DNSZone", "spec": map[string]any{"domainName": "example.com"}}
+
+	created, err := UnifiedDiff(nil, obj, "(does not exist)", "after apply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(created, "+++ after apply") || !strings.Contains(created, `+  "kind": "DNSZone",`) {
+		t.Errorf("creation diff:\n%s", created)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(created), "\n")[3:] {
+		if !strings.HasPrefix(line, "+") {
+			t.Errorf("creation diff has a non-addition line %q", line)
+		}
+	}
+
+	deleted, err := UnifiedDiff(obj, nil, "live", "(deleted)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(deleted, `-    "domainName": "example.com"`) {
+		t.Errorf("deletion diff:\n%s", deleted)
+	}
+
+	same, err := UnifiedDiff(obj, obj, "a", "b")
+	if err != nil || same != "" {
+		t.Errorf("identical manifests = %q, %v; want no diff", same, err)
+	}
+}
+
 // --- ForceRefresh / Invalidate ---
 
 func TestHistoryClient_ForceRefresh_DropsCacheEntry(t *testing.T) {
@@ -320,6 +320,9 @@ func exportChatCmd(conv *chatstorage.Conversation) tea.Cmd {
 				case "user":
 					sb.WriteString("**You:** " + msg.Content + "\n\n")
 				case "assistant":
+					if msg.Content == "" {
+						continue // tool calls only
+					}
 					sb.WriteString("**Assistant:** " + msg.Content + "\n\n")
 				}
 			}
@@ -348,25 +351,26 @@ func initChatAgentCmd(
 		}
 		aiCfg.ApplyEnvOverrides()
 
-		llmClient, err := llm.NewClient(llm.Config{
-			Provider:        aiCfg.Provider,
-			Model:           aiCfg.Model,
-			AnthropicAPIKey: aiCfg.AnthropicAPIKey,
-			OpenAIAPIKey:    aiCfg.OpenAIAPIKey,
-			GeminiAPIKey:    aiCfg.GeminiAPIKey,
-		})
+		llmClient, err := llm.NewClient(aiCfg.LLMConfig())
 		if err != nil {
 			return chatAgentInitMsg{err: fmt.Errorf("initialize LLM: %w\n\nRun 'datumctl ai config set anthropic_api_key <key>' to save your key", err)}
 		}
 
-		registry := datumai.NewRegistry(factory)
+		registry := datumai.NewRegistry(factory, datumai.UserPluginsDir())
 		agent := datumai.NewAgent(datumai.AgentOptions{
-			LLM:           llmClient,
-			Registry:      registry,
-			SystemPrompt:  datumai.BuildSystemPrompt(org, project, namespace, false, viewContext),
-			MaxIterations: 20,
-			Gate:          datumai.TUIGate{RequestCh: confirmCh, Ctx: turnCtx},
-			IsTerminal:    false,
+			LLM:              llmClient,
+			Registry:         registry,
+			SystemPrompt:     datumai.BuildSystemPrompt(org, project, namespace, false, viewContext),
+			MaxIterations:    20,
+			ContextTokens:    aiCfg.ContextTokens,
+			MaxSessionTokens: aiCfg.MaxTokensPerSession,
+			Gate:             datumai.TUIGate{RequestCh: confirmCh, Ctx: turnCtx},
+			IsTerminal:       false,
+			Policy:           aiCfg.Policy,
+			Project: func() string {
+				project, _, _, _ := factory.ConfigFlags.ResolvedScope()
+				return project
+			},
 		})
 		return chatAgentInitMsg{agent: agent}
 	}
@@ -381,21 +385,6 @@ func sendChatMessageCmd(ctx context.Context, agent *datumai.Agent, text string,
 	}
 }
 
-// chatConvToAgentHistory converts stored conversation messages to llm.Message
-// slice for seeding an Agent's history. Tool events (role "tool") are skipped.
-func chatConvToAgentHistory(msgs []chatstorage.Message) []llm.Message {
-	history := make([]llm.Message, 0, len(msgs))
-	for _, m := range msgs {
-		switch m.Role {
-		case "user":
-			history = append(history, llm.Message{Role: llm.RoleUser, Content: m.Content})
-		case "assistant":
-			history = append(history, llm.Message{Role: llm.RoleAssistant, Content: m.Content})
-		}
-	}
-	return history
-}
-
 // copyToClipboardCmd copies text to the system clipboard. Errors are silently dropped.
 func copyToClipboardCmd(text string) tea.Cmd {
 	return func() tea.Msg {
@@ -1012,7 +1001,7 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Seed the agent's conversation history from the restored session so
 			// the LLM has context from prior messages.
 			if m.chatConversation != nil {
-				m.chatAgent.SetHistory(chatConvToAgentHistory(m.chatConversation.Messages))
+				m.chatAgent.SetHistory(m.chatConversation.History())
 			}
 			m.chat.SetAgentReady()
 			cmds = append(cmds, listenForConfirmCmd(m.ctx, m.chatConfirmCh))
@@ -1050,13 +1039,12 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Finalize the streaming slot (triggers full markdown render).
 			m.chat.FinalizeStream()
 		}
-		// Save full response to disk using the complete text from the agent.
-		assistantContent := msg.response
-		if msg.err != nil {
-			assistantContent = "Error: " + msg.err.Error()
+		if m.chatAgent != nil {
+			m.chat.SetUsage(m.chatAgent.UsageSummary())
 		}
-		if m.chatStore != nil && m.chatConversation != nil && assistantContent != "" {
-			m.chatConversation.AddMessage("assistant", assistantContent)
+		// Save the agent's full transcript, tool calls included, to disk.
+		if m.chatStore != nil && m.chatConversation != nil && m.chatAgent != nil {
+			m.chatConversation.SetTranscript(m.chatAgent.Transcript())
 			cmds = append(cmds, saveChatConvCmd(m.chatStore, m.chatConversation))
 		}
 		cmds = append(cmds, listenForConfirmCmd(m.ctx, m.chatConfirmCh))
@@ -1064,6 +1052,7 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case chatConfirmReqMsg:
 		m.chatConfirmReply = msg.req.ReplyCh
 		m.chat.SetConfirmPending(msg.req.Call)
+		m.chat.SetConfirmPreview(msg.req.Preview)
 		m.statusBar.Pane = "CHAT_CONFIRM"
 
 	case contextCacheRefreshedMsg:
@@ -1086,7 +1075,9 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case "user":
 					m.chat.AppendUserMessage(mm.Content)
 				case "assistant":
-					m.chat.AppendAssistantMessage(mm.Content)
+					if mm.Content != "" {
+						m.chat.AppendAssistantMessage(mm.Content)
+					}
 				}
 			}
 			// Load history list for the sidebar.
@@ -1110,14 +1101,16 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.chatAgent != nil {
 			m.chat.SetAgentReady()
 			// Sync agent history to the newly loaded conversation.
-			m.chatAgent.SetHistory(chatConvToAgentHistory(msg.conv.Messages))
+			m.chatAgent.SetHistory(msg.conv.History())
 		}
 		for _, mm := range msg.conv.Messages {
 			switch mm.Role {
 			case "user":
 				m.chat.AppendUserMessage(mm.Content)
 			case "assistant":
-				m.chat.AppendAssistantMessage(mm.Content)
+				if mm.Content != "" {
+					m.chat.AppendAssistantMessage(mm.Content)
+				}
 			}
 		}
 		m.updatePaneFocus()
@@ -1733,6 +1726,18 @@ func (m AppModel) handleFilterKey(msg tea.KeyMsg, _ *[]tea.Cmd) (tea.Model, tea.
 	return m, cmd
 }
 
+// newChatConversation starts a conversation record in the active context.
+func (m *AppModel) newChatConversation() *chatstorage.Conversation {
+	var org, project string
+	if m.tuiCtx.ActiveCtx != nil {
+		org = m.tuiCtx.ActiveCtx.OrganizationID
+		project = m.tuiCtx.ActiveCtx.ProjectID
+	}
+	conv := chatstorage.NewConversation(org, project)
+	conv.Namespace = m.tuiCtx.Namespace
+	return conv
+}
+
 // handleChatKey routes keyboard events while the ChatPane is active.
 func (m AppModel) handleChatKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Clear transient hints on any keypress.
@@ -1824,12 +1829,7 @@ func (m AppModel) handleChatKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			// Create a new conversation record on first message of session.
 			if m.chatConversation == nil && m.chatStore != nil {
-				var org, project string
-				if m.tuiCtx.ActiveCtx != nil {
-					org = m.tuiCtx.ActiveCtx.OrganizationID
-					project = m.tuiCtx.ActiveCtx.ProjectID
-				}
-				m.chatConversation = chatstorage.NewConversation(org, project)
+				m.chatConversation = m.newChatConversation()
 			}
 			m.chat.AppendUserMessage(text)
 			m.chat.ClearInput()
@@ -1902,12 +1902,7 @@ func (m AppModel) handleChatKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.chatConversation != nil && m.chatStore != nil {
 			saveCmd = saveChatConvCmd(m.chatStore, m.chatConversation)
 		}
-		var org, project string
-		if m.tuiCtx.ActiveCtx != nil {
-			org = m.tuiCtx.ActiveCtx.OrganizationID
-			project = m.tuiCtx.ActiveCtx.ProjectID
-		}
-		m.chatConversation = chatstorage.NewConversation(org, project)
+		m.chatConversation = m.newChatConversation()
 		w, h := m.chat.Width(), m.chat.Height()
 		m.chat = components.NewChatPaneModel(w, h)
 		if m.chatAgent != nil {
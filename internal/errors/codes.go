@@ -0,0 +1,252 @@
+package errors
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Registered error codes. Each code maps to a distinct process exit code so
+// scripts can branch on the failure class without parsing messages. Codes are
+// part of datumctl's stable interface: never renumber or reuse one.
+const (
+	CodeAuthExpired          = "AUTH_EXPIRED"
+	CodeOnboardingIncomplete = "ONBOARDING_INCOMPLETE"
+	CodeForbidden            = "FORBIDDEN"
+	CodeNotFound             = "NOT_FOUND"
+	CodeConflict             = "CONFLICT"
+	CodePluginUntrusted      = "PLUGIN_UNTRUSTED"
+	CodeIntegrityMismatch    = "INTEGRITY_MISMATCH"
+	CodeNetwork              = "NETWORK"
+	CodeAlreadyExists        = "ALREADY_EXISTS"
+)
+
+// ExitCodeGeneric is the exit code for failures without a registered code.
+const ExitCodeGeneric = 1
+
+// CodeInfo describes a registered error code.
+type CodeInfo struct {
+	// Code is the machine-readable identifier emitted in --error-format json.
+	Code string
+
+	// ExitCode is the process exit code used when a command fails with Code.
+	ExitCode int
+
+	// Summary is a one-line description of the failure class.
+	Summary string
+
+	// Retryable is the default UserError.Retryable for errors with this code.
+	Retryable bool
+
+	// Remediation is the long-form guidance printed by 'datumctl errors explain'.
+	Remediation string
+}
+
+// catalog holds every registered code. Exit codes start at 10 to stay clear of
+// 1 (generic failure and 'datumctl diff' differences), 2 (usage errors) and
+// 126-130 (shell and signal conventions).
+var catalog = map[string]CodeInfo{
+	CodeAuthExpired: {
+		Code:     CodeAuthExpired,
+		ExitCode: 10,
+		Summary:  "The session's credentials are missing, expired, or revoked.",
+		Remediation: `datumctl could not obtain a valid access token for the active session. The
+refresh token may have expired, been revoked, or the session may have been
+removed from the keyring.
+
+Sign in again with 'datumctl login'. For a service account, re-run
+'datumctl login --credentials <key-file>'. Use 'datumctl auth list' to check
+which session is active.`,
+	},
+	CodeOnboardingIncomplete: {
+		Code:     CodeOnboardingIncomplete,
+		ExitCode: 11,
+		Summary:  "The organization has not finished setup in the cloud portal.",
+		Remediation: `The target organization is missing required setup, such as contact details,
+a billing account, or a payment method. Resource commands are blocked until
+setup is complete.
+
+Follow the portal link printed with the error, then retry. 'datumctl whoami'
+shows the current onboarding status.`,
+	},
+	CodeForbidden: {
+		Code:     CodeForbidden,
+		ExitCode: 12,
+		Summary:  "The active user is not allowed to perform the operation.",
+		Remediation: `The API understood the request but the active user lacks permission for it.
+
+Check that the right account is active ('datumctl whoami') and that you are
+targeting the intended organization or project. Ask an organization owner to
+grant the required role.`,
+	},
+	CodeNotFound: {
+		Code:     CodeNotFound,
+		ExitCode: 13,
+		Summary:  "The requested resource or resource type does not exist.",
+		Remediation: `The resource, or its resource type, could not be found in the targeted
+organization or project.
+
+Check the name and namespace, and confirm the context with 'datumctl whoami'.
+Use 'datumctl api-resources' to list the resource types available in the
+current context.`,
+	},
+	CodeConflict: {
+		Code:      CodeConflict,
+		ExitCode:  14,
+		Summary:   "The resource changed since it was last read.",
+		Retryable: true,
+		Remediation: `The resource was modified by someone else between reading it and writing it
+back, so the write was rejected to avoid overwriting their change.
+
+Fetch the latest version and retry. 'datumctl apply' re-reads the resource
+on each run, so re-running it is usually enough.`,
+	},
+	CodePluginUntrusted: {
+		Code:     CodePluginUntrusted,
+		ExitCode: 15,
+		Summary:  "A plugin on PATH has not been trusted.",
+		Remediation: `datumctl refuses to run unmanaged plugin binaries that have not been
+explicitly trusted, because plugins receive access to your credentials.
+
+Install the plugin as a managed plugin with 'datumctl plugin install <name>',
+or, if you built or audited the binary yourself, trust it with
+'datumctl plugin trust <name>'.`,
+	},
+	CodeIntegrityMismatch: {
+		Code:     CodeIntegrityMismatch,
+		ExitCode: 16,
+		Summary:  "A managed plugin binary does not match its recorded checksum.",
+		Remediation: `The plugin binary on disk differs from the one recorded at install time, or
+has no install record. It may have been modified or replaced.
+
+Reinstall it with 'datumctl plugin install <name>'. If you did not expect the
+binary to change, investigate before running it.`,
+	},
+	CodeNetwork: {
+		Code:      CodeNetwork,
+		ExitCode:  17,
+		Summary:   "The Datum Cloud API or auth server could not be reached.",
+		Retryable: true,
+		Remediation: `datumctl could not connect to a Datum Cloud endpoint. This is usually a
+transient network problem, a proxy or firewall, or DNS.
+
+Retry the command. If it keeps failing, check connectivity to the endpoint
+shown by 'datumctl auth list' and any HTTPS_PROXY settings.`,
+	},
+	CodeAlreadyExists: {
+		Code:     CodeAlreadyExists,
+		ExitCode: 18,
+		Summary:  "A resource with the same name already exists.",
+		Remediation: `The create was rejected because a resource with that name already exists in
+the targeted organization or project. Retrying will fail the same way.
+
+Pick another name, delete the existing resource first, or use
+'datumctl apply', which updates existing resources instead of failing.`,
+	},
+}
+
+// LookupCode returns the registration for code.
+func LookupCode(code string) (CodeInfo, bool) {
+	info, ok := catalog[code]
+	return info, ok
+}
+
+// Codes returns every registered code, ordered by exit code.
+func Codes() []CodeInfo {
+	out := make([]CodeInfo, 0, len(catalog))
+	for _, info := range catalog {
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ExitCode < out[j].ExitCode })
+	return out
+}
+
+// WithCode sets e's Code and, when code is registered, its default Retryable.
+// It returns e so it can be chained onto the constructors:
+//
+//	return NewUserErrorWithHint(msg, hint).WithCode(CodeAuthExpired)
+func (e *UserError) WithCode(code string) *UserError {
+	e.Code = code
+	if info, ok := catalog[code]; ok {
+		e.Retryable = info.Retryable
+	}
+	return e
+}
+
+// CodeOf returns the error code for err. A Code set on a UserError in the
+// chain wins; otherwise Kubernetes API status errors and network failures are
+// classified into the matching registered code. It returns "" when err has no
+// recognizable class.
+func CodeOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	if userErr, ok := IsUserError(err); ok && userErr.Code != "" {
+		return userErr.Code
+	}
+	switch {
+	case apierrors.IsUnauthorized(err):
+		return CodeAuthExpired
+	case apierrors.IsForbidden(err):
+		return CodeForbidden
+	case apierrors.IsNotFound(err):
+		return CodeNotFound
+	case apierrors.IsAlreadyExists(err):
+		return CodeAlreadyExists
+	case apierrors.IsConflict(err):
+		return CodeConflict
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return CodeNetwork
+	}
+	return ""
+}
+
+// kubectlReasonCodes maps the status reasons kubectl prints as
+// "Error from server (<Reason>): ..." to registered codes.
+var kubectlReasonCodes = map[string]string{
+	"Forbidden":     CodeForbidden,
+	"NotFound":      CodeNotFound,
+	"Conflict":      CodeConflict,
+	"AlreadyExists": CodeAlreadyExists,
+	"Unauthorized":  CodeAuthExpired,
+}
+
+// CodeOfKubectlMessage classifies a fatal error message produced by kubectl's
+// error handling, which reaches datumctl only as preformatted text. It
+// recognizes kubectl's standard API status and connection error forms and
+// returns "" for anything else.
+func CodeOfKubectlMessage(msg string) string {
+	msg = strings.TrimPrefix(msg, "error: ")
+	if rest, ok := strings.CutPrefix(msg, "Error from server ("); ok {
+		if reason, _, found := strings.Cut(rest, ")"); found {
+			return kubectlReasonCodes[reason]
+		}
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(msg, "You must be logged in to the server"):
+		return CodeAuthExpired
+	case strings.HasPrefix(msg, "Unable to connect to the server"),
+		strings.HasPrefix(msg, "The connection to the server"):
+		return CodeNetwork
+	}
+	return ""
+}
+
+// ExitCode returns the process exit code registered for err's code. ok is
+// false when err carries no registered code, leaving the caller to pick its
+// own default.
+func ExitCode(err error) (code int, ok bool) {
+	info, ok := catalog[CodeOf(err)]
+	if !ok {
+		return ExitCodeGeneric, false
+	}
+	return info.ExitCode, true
+}
@@ -0,0 +1,130 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TestCodes_distinctExitCodes verifies every registered code maps to its own
+// exit code and none collides with the generic failure status.
+func TestCodes_distinctExitCodes(t *testing.T) {
+	seen := map[int]string{}
+	for _, info := range Codes() {
+		if info.ExitCode == ExitCodeGeneric {
+			t.Errorf("%s uses the generic exit code %d", info.Code, ExitCodeGeneric)
+		}
+		if other, ok := seen[info.ExitCode]; ok {
+			t.Errorf("%s and %s share exit code %d", info.Code, other, info.ExitCode)
+		}
+		seen[info.ExitCode] = info.Code
+		if info.Summary == "" || info.Remediation == "" {
+			t.Errorf("%s is missing a summary or remediation", info.Code)
+		}
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	gr := schema.GroupResource{Group: "resourcemanager.miloapis.com", Resource: "projects"}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"plain", errors.New("boom"), ""},
+		{"user error with code", NewUserError("x").WithCode(CodePluginUntrusted), CodePluginUntrusted},
+		{"wrapped user error", fmt.Errorf("outer: %w", NewUserError("x").WithCode(CodeIntegrityMismatch)), CodeIntegrityMismatch},
+		{"user error code wins over cause", WrapUserError("x", apierrors.NewNotFound(gr, "p")).WithCode(CodeAuthExpired), CodeAuthExpired},
+		{"uncoded user error falls back to cause", WrapUserError("x", apierrors.NewNotFound(gr, "p")), CodeNotFound},
+		{"unauthorized", apierrors.NewUnauthorized("expired"), CodeAuthExpired},
+		{"forbidden", apierrors.NewForbidden(gr, "p", errors.New("denied")), CodeForbidden},
+		{"conflict", apierrors.NewConflict(gr, "p", errors.New("changed")), CodeConflict},
+		{"already exists", apierrors.NewAlreadyExists(gr, "p"), CodeAlreadyExists},
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, CodeNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCode_setsRetryableDefault(t *testing.T) {
+	if !NewUserError("x").WithCode(CodeNetwork).Retryable {
+		t.Error("NETWORK errors should default to retryable")
+	}
+	if NewUserError("x").WithCode(CodeForbidden).Retryable {
+		t.Error("FORBIDDEN errors should not default to retryable")
+	}
+}
+
+// TestFormat_classifiesUncodedErrors verifies the JSON envelope carries a
+// classified code for API errors that were never wrapped in a UserError.
+func TestFormat_classifiesUncodedErrors(t *testing.T) {
+	var buf bytes.Buffer
+	Format(&buf, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, FormatJSON, 0)
+
+	var env envelope
+	if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+		t.Fatalf("unmarshal %q: %v", buf.String(), err)
+	}
+	if env.Error.Code != CodeNetwork || !env.Error.Retryable {
+		t.Errorf("envelope = %+v, want code %s and retryable", env.Error, CodeNetwork)
+	}
+}
+
+// TestFormat_alreadyExistsIsNotRetryable verifies a create that lost to an
+// existing resource is not reported as retryable, unlike a 409 conflict on
+// a stale write.
+func TestFormat_alreadyExistsIsNotRetryable(t *testing.T) {
+	gr := schema.GroupResource{Group: "resourcemanager.miloapis.com", Resource: "projects"}
+	tests := []struct {
+		err       error
+		code      string
+		retryable bool
+	}{
+		{apierrors.NewAlreadyExists(gr, "p"), CodeAlreadyExists, false},
+		{apierrors.NewConflict(gr, "p", errors.New("the object has been modified")), CodeConflict, true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		Format(&buf, tt.err, FormatJSON, 0)
+
+		var env envelope
+		if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+			t.Fatalf("unmarshal %q: %v", buf.String(), err)
+		}
+		if env.Error.Code != tt.code || env.Error.Retryable != tt.retryable {
+			t.Errorf("envelope = %+v, want code %s and retryable %v", env.Error, tt.code, tt.retryable)
+		}
+	}
+}
+
+func TestCodeOfKubectlMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{`Error from server (Forbidden): projects.resourcemanager.miloapis.com "p" is forbidden`, CodeForbidden},
+		{`Error from server (NotFound): dnszones.networking.datumapis.com "z" not found`, CodeNotFound},
+		{`Error from server (AlreadyExists): error when creating "p.yaml": projects "p" already exists`, CodeAlreadyExists},
+		{"error: You must be logged in to the server (Unauthorized)", CodeAuthExpired},
+		{"Unable to connect to the server: dial tcp: lookup api.datum.net: no such host", CodeNetwork},
+		{`Error from server (BadRequest): invalid`, ""},
+		{"error: the server doesn't have a resource type \"widgets\"", ""},
+	}
+	for _, tt := range tests {
+		if got := CodeOfKubectlMessage(tt.msg); got != tt.want {
+			t.Errorf("CodeOfKubectlMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
@@ -122,7 +122,9 @@ type envelopeError struct {
 // "error: <message>\n<hint>" form, with the wrapped technical error appended
 // when verbosity is at least 4. For "json" and "yaml", a structured envelope
 // is emitted using the UserError fields when available, falling back to
-// err.Error() for non-UserError values.
+// err.Error() for non-UserError values. The envelope's code comes from
+// CodeOf, so API and network failures are classified even without a
+// UserError.
 func Format(w io.Writer, err error, format string, verbosity int) {
 	if err == nil {
 		return
@@ -133,11 +135,19 @@ func Format(w io.Writer, err error, format string, verbosity int) {
 	switch format {
 	case FormatJSON, FormatYAML:
 		env := envelope{Error: envelopeError{Message: err.Error()}}
+		if info, ok := LookupCode(CodeOf(err)); ok {
+			env.Error.Code = info.Code
+			env.Error.Retryable = info.Retryable
+		}
 		if isUser {
-			env.Error.Code = userErr.Code
+			if userErr.Code != "" {
+				env.Error.Code = userErr.Code
+				env.Error.Retryable = userErr.Retryable
+			} else if userErr.Retryable {
+				env.Error.Retryable = true
+			}
 			env.Error.Message = userErr.Message
 			env.Error.Hint = userErr.Hint
-			env.Error.Retryable = userErr.Retryable
 			if userErr.Err != nil {
 				env.Error.Details = userErr.Err.Error()
 			}
@@ -0,0 +1,236 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// SessionHeader carries the session ID of the Streamable HTTP transport.
+const SessionHeader = "Mcp-Session-Id"
+
+// maxMessageBytes bounds one POSTed message; manifests are the largest
+// arguments, and nowhere near this.
+const maxMessageBytes = 10 << 20
+
+// Handler serves the Streamable HTTP transport at any path it is mounted on.
+// Each POST carries one JSON-RPC message. A request is answered with a JSON
+// body, or — when the server needs to ask the user to confirm a change — an
+// event stream carrying the elicitation request and then the response; the
+// client POSTs its answer to the elicitation separately. There is no
+// server-initiated GET stream.
+//
+// The handler only answers local clients: like 'datumctl api proxy', it
+// rejects a non-local Host or Origin, so a web page cannot reach it through
+// DNS rebinding or a cross-origin request. Every request must also carry
+// token as "Authorization: Bearer <token>", so other local users and
+// processes cannot act as the signed-in user; an empty token admits no one.
+func (s *Server) Handler(token string) http.Handler {
+	return &httpTransport{server: s, token: token, sessions: map[string]*session{}}
+}
+
+// NewToken returns a random bearer token for Handler.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate MCP token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type httpTransport struct {
+	server *Server
+	token  string
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+func (t *httpTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !localHost(r.Host) || !localOrigin(r.Header.Get("Origin")) {
+		http.Error(w, "the datumctl MCP server only serves local clients", http.StatusForbidden)
+		return
+	}
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || t.token == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(t.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="datumctl mcp"`)
+		http.Error(w, "missing or invalid token; send the token printed when the server started as 'Authorization: Bearer <token>'", http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		t.post(w, r)
+	case http.MethodDelete:
+		id := r.Header.Get(SessionHeader)
+		t.mu.Lock()
+		_, ok := t.sessions[id]
+		delete(t.sessions, id)
+		t.mu.Unlock()
+		if !ok {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "use POST to send messages; this server opens no GET stream", http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *httpTransport) post(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes+1))
+	if err != nil {
+		return
+	}
+	if len(data) > maxMessageBytes {
+		writeJSON(w, http.StatusRequestEntityTooLarge, errorMessage(nil, codeInvalidRequest, "message too large"))
+		return
+	}
+	msg, err := parseMessage(data)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorMessage(nil, codeParseError, "invalid JSON-RPC message: "+err.Error()))
+		return
+	}
+
+	var sess *session
+	if msg.Method == "initialize" {
+		id, err := newSessionID()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorMessage(msg.ID, codeInvalidRequest, err.Error()))
+			return
+		}
+		sess = newSession()
+		t.mu.Lock()
+		t.sessions[id] = sess
+		t.mu.Unlock()
+		w.Header().Set(SessionHeader, id)
+	} else {
+		id := r.Header.Get(SessionHeader)
+		if id == "" {
+			writeJSON(w, http.StatusBadRequest, errorMessage(msg.ID, codeInvalidRequest, "missing "+SessionHeader+" header; send initialize first"))
+			return
+		}
+		t.mu.Lock()
+		sess = t.sessions[id]
+		t.mu.Unlock()
+		if sess == nil {
+			writeJSON(w, http.StatusNotFound, errorMessage(msg.ID, codeInvalidRequest, "unknown or expired session; initialize again"))
+			return
+		}
+	}
+
+	switch {
+	case msg.isRequest():
+		stream := &httpStream{server: t.server, w: w}
+		stream.finish(t.server.serve(r.Context(), sess, msg, stream))
+	case msg.isNotification():
+		t.server.notify(sess, msg)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		t.server.deliver(msg)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// httpStream answers one POSTed request. It stays a plain JSON response
+// unless the server sends the client a request of its own, which turns it
+// into an event stream.
+type httpStream struct {
+	server *Server
+	w      http.ResponseWriter
+
+	mu        sync.Mutex
+	streaming bool
+}
+
+func (s *httpStream) request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	msg, wait, err := s.server.await(method, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.event(msg); err != nil {
+		return nil, err
+	}
+	return wait(ctx)
+}
+
+func (s *httpStream) event(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.streaming {
+		s.streaming = true
+		s.w.Header().Set("Content-Type", "text/event-stream")
+		s.w.Header().Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(http.StatusOK)
+	}
+	if _, err := fmt.Fprintf(s.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func (s *httpStream) finish(msg *message) {
+	s.mu.Lock()
+	streaming := s.streaming
+	s.mu.Unlock()
+	if streaming {
+		_ = s.event(msg)
+		return
+	}
+	writeJSON(s.w, http.StatusOK, msg)
+}
+
+func writeJSON(w http.ResponseWriter, code int, msg *message) {
+	data, _ := json.Marshal(msg)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate session ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// localHost reports whether hostport names this machine: localhost,
+// 127.0.0.1, or [::1], with or without a port.
+func localHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	switch strings.ToLower(host) {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// localOrigin reports whether a browser Origin header is absent or local.
+func localOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && localHost(u.Host)
+}
@@ -0,0 +1,396 @@
+// Package mcp serves datumctl's AI tools over the Model Context Protocol, so
+// editors and agent runtimes can use them with the user's existing datumctl
+// login. It speaks JSON-RPC 2.0 over stdio (ServeStdio) or Streamable HTTP
+// (Handler), and covers the tool side of the protocol: initialize, ping,
+// tools/list, tools/call, cancellation, and elicitation for confirmations.
+//
+// Like the api proxy engine, the server is self-contained: the tools, the
+// confirmation policy, and the log destination are injected.
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"go.datum.net/datumctl/internal/ai"
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+// protocolVersions are the MCP revisions the server speaks, newest first.
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Options configures a Server.
+type Options struct {
+	// Registry supplies the tools. Tools with RequiresConfirm change
+	// resources and are gated; the rest run as soon as they are called.
+	Registry *ai.Registry
+
+	// AllowMutations runs gated tools without asking. Otherwise the server
+	// asks the user through MCP elicitation, and refuses gated tools for
+	// clients that do not support it.
+	AllowMutations bool
+
+	// Policy decides gated tools before AllowMutations or the user is
+	// consulted; its denials hold even with AllowMutations. Project reports
+	// the session's project for it. Both may be nil.
+	Policy  *ai.Policy
+	Project func() string
+
+	// Version is reported as the server version during initialize.
+	Version string
+
+	// Instructions, when set, is offered to the client as guidance for the
+	// model, e.g. which context the tools act on.
+	Instructions string
+
+	// Log receives one line per tool call and protocol problem. Nil
+	// discards them. It must never be stdout for the stdio transport.
+	Log io.Writer
+}
+
+// Server answers MCP requests. One Server can serve several sessions.
+type Server struct {
+	opts Options
+
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	pending map[string]chan *message
+}
+
+// NewServer returns a Server for opts.
+func NewServer(opts Options) *Server {
+	if opts.Registry == nil {
+		opts.Registry = ai.NewEmptyRegistry()
+	}
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+	return &Server{opts: opts, pending: map[string]chan *message{}}
+}
+
+// message is any JSON-RPC 2.0 message: a request (Method and ID), a
+// notification (Method, no ID), or a response (ID and Result or Error).
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+func (m *message) isRequest() bool      { return m.Method != "" && m.ID != nil }
+func (m *message) isNotification() bool { return m.Method != "" && m.ID == nil }
+
+func parseMessage(data []byte) (*message, error) {
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	if msg.JSONRPC != "2.0" {
+		return nil, fmt.Errorf("not a JSON-RPC 2.0 message")
+	}
+	return &msg, nil
+}
+
+func resultMessage(id json.RawMessage, result any) *message {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errorMessage(id, codeInvalidRequest, err.Error())
+	}
+	return &message{JSONRPC: "2.0", ID: id, Result: data}
+}
+
+func errorMessage(id json.RawMessage, code int, text string) *message {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &message{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: text}}
+}
+
+// session is what the server remembers about one client connection.
+type session struct {
+	mu        sync.Mutex
+	canElicit bool
+	inflight  map[string]context.CancelFunc
+}
+
+func newSession() *session { return &session{inflight: map[string]context.CancelFunc{}} }
+
+// peer sends server-initiated requests to the client, over whatever channel
+// the transport has open for the request being served.
+type peer interface {
+	request(ctx context.Context, method string, params any) (json.RawMessage, error)
+}
+
+// serve handles one request and returns its response. It can be cancelled
+// by a notifications/cancelled naming the request.
+func (s *Server) serve(ctx context.Context, sess *session, msg *message, p peer) *message {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	key := string(msg.ID)
+	sess.mu.Lock()
+	sess.inflight[key] = cancel
+	sess.mu.Unlock()
+	defer func() {
+		sess.mu.Lock()
+		delete(sess.inflight, key)
+		sess.mu.Unlock()
+	}()
+
+	switch msg.Method {
+	case "initialize":
+		return s.initialize(sess, msg)
+	case "ping":
+		return resultMessage(msg.ID, struct{}{})
+	case "tools/list":
+		return resultMessage(msg.ID, map[string]any{"tools": s.toolList()})
+	case "tools/call":
+		return s.callTool(ctx, sess, msg, p)
+	}
+	return errorMessage(msg.ID, codeMethodNotFound, fmt.Sprintf("method %q is not supported", msg.Method))
+}
+
+// notify handles a notification from the client.
+func (s *Server) notify(sess *session, msg *message) {
+	if msg.Method != "notifications/cancelled" {
+		return // notifications/initialized and the rest need no action
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if json.Unmarshal(msg.Params, &params) != nil {
+		return
+	}
+	sess.mu.Lock()
+	cancel := sess.inflight[string(params.RequestID)]
+	sess.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// deliver routes the client's response to a server-initiated request.
+func (s *Server) deliver(msg *message) {
+	s.mu.Lock()
+	ch := s.pending[string(msg.ID)]
+	delete(s.pending, string(msg.ID))
+	s.mu.Unlock()
+	if ch != nil {
+		ch <- msg
+	}
+}
+
+// await registers a server-initiated request and returns its message and a
+// function that waits for the client's answer.
+func (s *Server) await(method string, params any) (*message, func(context.Context) (json.RawMessage, error), error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	id := json.RawMessage(strconv.Quote("datumctl-" + strconv.FormatInt(s.nextID.Add(1), 10)))
+	ch := make(chan *message, 1)
+	s.mu.Lock()
+	s.pending[string(id)] = ch
+	s.mu.Unlock()
+	wait := func(ctx context.Context) (json.RawMessage, error) {
+		select {
+		case reply := <-ch:
+			if reply.Error != nil {
+				return nil, reply.Error
+			}
+			return reply.Result, nil
+		case <-ctx.Done():
+			s.mu.Lock()
+			delete(s.pending, string(id))
+			s.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	return &message{JSONRPC: "2.0", ID: id, Method: method, Params: data}, wait, nil
+}
+
+func (s *Server) initialize(sess *session, msg *message) *message {
+	var params struct {
+		ProtocolVersion string                     `json:"protocolVersion"`
+		Capabilities    map[string]json.RawMessage `json:"capabilities"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return errorMessage(msg.ID, codeInvalidParams, "invalid initialize params: "+err.Error())
+	}
+	_, canElicit := params.Capabilities["elicitation"]
+	sess.mu.Lock()
+	sess.canElicit = canElicit
+	sess.mu.Unlock()
+
+	version := protocolVersions[0]
+	if slices.Contains(protocolVersions, params.ProtocolVersion) {
+		version = params.ProtocolVersion
+	}
+	result := map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{"tools": map[string]any{"listChanged": false}},
+		"serverInfo":      map[string]any{"name": "datumctl", "version": s.opts.Version},
+	}
+	if s.opts.Instructions != "" {
+		result["instructions"] = s.opts.Instructions
+	}
+	return resultMessage(msg.ID, result)
+}
+
+func (s *Server) toolList() []map[string]any {
+	defs := s.opts.Registry.Defs()
+	tools := make([]map[string]any, 0, len(defs))
+	for _, def := range defs {
+		tool, _ := s.opts.Registry.Find(def.Name)
+		tools = append(tools, map[string]any{
+			"name":        def.Name,
+			"description": def.Description,
+			"inputSchema": def.InputSchema,
+			"annotations": map[string]any{
+				"readOnlyHint":    !tool.RequiresConfirm,
+				"destructiveHint": tool.RequiresConfirm,
+			},
+		})
+	}
+	return tools
+}
+
+// declinedResult is what a declined mutation returns, as in 'datumctl ai'.
+const declinedResult = `{"skipped":true,"reason":"user declined"}`
+
+func (s *Server) callTool(ctx context.Context, sess *session, msg *message, p peer) *message {
+	var params struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return errorMessage(msg.ID, codeInvalidParams, "invalid tools/call params: "+err.Error())
+	}
+	tool, ok := s.opts.Registry.Find(params.Name)
+	if !ok {
+		return errorMessage(msg.ID, codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name))
+	}
+
+	decision := ai.Decision{Verdict: ai.VerdictAsk}
+	if tool.RequiresConfirm {
+		project := ""
+		if s.opts.Project != nil {
+			project = s.opts.Project()
+		}
+		decision = s.opts.Policy.Evaluate(llm.ToolCall{ToolName: params.Name, Arguments: params.Arguments}, project)
+		switch decision.Verdict {
+		case ai.VerdictDeny:
+			fmt.Fprintf(s.opts.Log, "[mcp] %s denied by policy: %s\n", params.Name, decision.Reason)
+			return toolResult(msg.ID, ai.DeniedResult(decision.Reason), false)
+		case ai.VerdictApprove:
+			fmt.Fprintf(s.opts.Log, "[mcp] %s approved by policy: %s\n", params.Name, decision.Reason)
+		}
+	}
+
+	if tool.RequiresConfirm && decision.Verdict == ai.VerdictAsk && !s.opts.AllowMutations {
+		sess.mu.Lock()
+		canElicit := sess.canElicit
+		sess.mu.Unlock()
+		if !canElicit {
+			fmt.Fprintf(s.opts.Log, "[mcp] %s refused: the client cannot ask for confirmation\n", params.Name)
+			return toolResult(msg.ID, fmt.Sprintf(
+				"%s changes Datum Cloud resources and needs the user's confirmation, but this MCP client does not support elicitation. "+
+					"To allow changes without confirmation, restart the server with 'datumctl mcp serve --allow-mutations'.", params.Name), true)
+		}
+		preview := ""
+		if tool.Preview != nil {
+			var err error
+			if preview, err = tool.Preview(ctx, params.Arguments); err != nil {
+				fmt.Fprintf(s.opts.Log, "[mcp] could not preview %s: %v\n", params.Name, err)
+			}
+		}
+		gate := elicitationGate{ctx: ctx, peer: p, log: s.opts.Log}
+		if !gate.Confirm(llm.ToolCall{ID: string(msg.ID), ToolName: params.Name, Arguments: params.Arguments}, preview) {
+			fmt.Fprintf(s.opts.Log, "[mcp] %s declined\n", params.Name)
+			return toolResult(msg.ID, declinedResult, false)
+		}
+	}
+
+	result, err := tool.Execute(ctx, params.Arguments)
+	if err != nil {
+		fmt.Fprintf(s.opts.Log, "[mcp] tool %s error: %v\n", params.Name, err)
+		return toolResult(msg.ID, err.Error(), true)
+	}
+	fmt.Fprintf(s.opts.Log, "[mcp] tool %s ok\n", params.Name)
+	return toolResult(msg.ID, result, false)
+}
+
+func toolResult(id json.RawMessage, text string, isError bool) *message {
+	return resultMessage(id, map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	})
+}
+
+// elicitationGate is the MCP confirmation gate: it shows the proposed action
+// to the user through the client's elicitation UI and approves only an
+// explicit accept with "confirm" checked. Any failure declines.
+type elicitationGate struct {
+	ctx  context.Context
+	peer peer
+	log  io.Writer
+}
+
+func (g elicitationGate) Confirm(call llm.ToolCall, preview string) bool {
+	var message bytes.Buffer
+	ai.PrintPreview(&message, call, preview)
+	result, err := g.peer.request(g.ctx, "elicitation/create", map[string]any{
+		"message": "datumctl wants to change Datum Cloud resources." + message.String(),
+		"requestedSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"confirm": map[string]any{
+					"type":        "boolean",
+					"title":       "Apply changes",
+					"description": "Check to let " + call.ToolName + " run.",
+				},
+			},
+			"required": []string{"confirm"},
+		},
+	})
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			fmt.Fprintf(g.log, "[mcp] confirmation for %s failed: %v\n", call.ToolName, err)
+		}
+		return false
+	}
+	var answer struct {
+		Action  string `json:"action"`
+		Content struct {
+			Confirm bool `json:"confirm"`
+		} `json:"content"`
+	}
+	if json.Unmarshal(result, &answer) != nil {
+		return false
+	}
+	return answer.Action == "accept" && answer.Content.Confirm
+}
@@ -0,0 +1,424 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.datum.net/datumctl/internal/ai"
+	"go.datum.net/datumctl/internal/ai/llm"
+)
+
+// testRegistry has one read-only tool and one that needs confirmation; the
+// channel reports each executed call.
+func testRegistry(ran chan<- string) *ai.Registry {
+	schema := map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"type": "string"}}}
+	return ai.NewRegistryOf(
+		ai.Tool{
+			Def: llm.ToolDef{Name: "list_things", Description: "List things.", InputSchema: schema},
+			Execute: func(ctx context.Context, args map[string]any) (string, error) {
+				ran <- "list_things"
+				return `{"items":["a","b"]}`, nil
+			},
+		},
+		ai.Tool{
+			Def:             llm.ToolDef{Name: "delete_resource", Description: "Delete a thing.", InputSchema: schema},
+			RequiresConfirm: true,
+			Execute: func(ctx context.Context, args map[string]any) (string, error) {
+				ran <- "delete_resource " + args["name"].(string)
+				return `{"deleted":true}`, nil
+			},
+		},
+	)
+}
+
+// stdioClient drives ServeStdio through pipes, one JSON line at a time.
+type stdioClient struct {
+	t     *testing.T
+	in    *io.PipeWriter
+	lines *bufio.Scanner
+	done  chan error
+}
+
+func startStdio(t *testing.T, opts Options) *stdioClient {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &stdioClient{t: t, in: inW, lines: bufio.NewScanner(outR), done: make(chan error, 1)}
+	go func() {
+		c.done <- NewServer(opts).ServeStdio(context.Background(), inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() { inW.Close() })
+	return c
+}
+
+func (c *stdioClient) send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, line+"\n"); err != nil {
+		c.t.Fatalf("write: %v", err)
+	}
+}
+
+func (c *stdioClient) recv() map[string]any {
+	c.t.Helper()
+	if !c.lines.Scan() {
+		c.t.Fatalf("server closed its output: %v", c.lines.Err())
+	}
+	var msg map[string]any
+	if err := json.Unmarshal(c.lines.Bytes(), &msg); err != nil {
+		c.t.Fatalf("server sent invalid JSON %q: %v", c.lines.Text(), err)
+	}
+	return msg
+}
+
+func (c *stdioClient) initialize(capabilities string) {
+	c.t.Helper()
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":` + capabilities + `,"clientInfo":{"name":"test"}}}`)
+	result := c.recv()["result"].(map[string]any)
+	if result["protocolVersion"] != "2025-03-26" {
+		c.t.Errorf("protocolVersion = %v, want the client's", result["protocolVersion"])
+	}
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+}
+
+func toolText(t *testing.T, msg map[string]any) (string, bool) {
+	t.Helper()
+	result, ok := msg["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("not a tool result: %v", msg)
+	}
+	content := result["content"].([]any)[0].(map[string]any)
+	return content["text"].(string), result["isError"].(bool)
+}
+
+func TestStdioListAndCall(t *testing.T) {
+	ran := make(chan string, 4)
+	c := startStdio(t, Options{Registry: testRegistry(ran)})
+	c.initialize(`{}`)
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	tools := c.recv()["result"].(map[string]any)["tools"].([]any)
+	if len(tools) != 2 {
+		t.Fatalf("listed %d tools, want 2", len(tools))
+	}
+	deleteTool := tools[1].(map[string]any)
+	if hints := deleteTool["annotations"].(map[string]any); hints["destructiveHint"] != true || hints["readOnlyHint"] != false {
+		t.Errorf("delete_resource annotations = %v, want destructive", hints)
+	}
+
+	c.send(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"list_things","arguments":{}}}`)
+	if text, isError := toolText(t, c.recv()); isError || text != `{"items":["a","b"]}` {
+		t.Errorf("list_things = %q (isError %v)", text, isError)
+	}
+	if got := <-ran; got != "list_things" {
+		t.Errorf("ran %q", got)
+	}
+
+	c.send(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"nope"}}`)
+	if msg := c.recv(); msg["error"] == nil {
+		t.Errorf("unknown tool answered %v, want a JSON-RPC error", msg)
+	}
+	c.send(`{"jsonrpc":"2.0","id":5,"method":"resources/list"}`)
+	if code := c.recv()["error"].(map[string]any)["code"]; code != float64(codeMethodNotFound) {
+		t.Errorf("unsupported method code = %v", code)
+	}
+
+	c.in.Close()
+	if err := <-c.done; err != nil {
+		t.Errorf("ServeStdio = %v, want nil at EOF", err)
+	}
+}
+
+func TestMutationWithoutElicitationIsRefused(t *testing.T) {
+	ran := make(chan string, 1)
+	c := startStdio(t, Options{Registry: testRegistry(ran)})
+	c.initialize(`{}`)
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"delete_resource","arguments":{"name":"x"}}}`)
+	text, isError := toolText(t, c.recv())
+	if !isError || !strings.Contains(text, "--allow-mutations") {
+		t.Errorf("result = %q (isError %v), want a refusal pointing at --allow-mutations", text, isError)
+	}
+	if len(ran) != 0 {
+		t.Error("the tool ran without confirmation")
+	}
+}
+
+func TestMutationAllowed(t *testing.T) {
+	ran := make(chan string, 1)
+	c := startStdio(t, Options{Registry: testRegistry(ran), AllowMutations: true})
+	c.initialize(`{}`)
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"delete_resource","arguments":{"name":"x"}}}`)
+	if _, isError := toolText(t, c.recv()); isError || <-ran != "delete_resource x" {
+		t.Error("--allow-mutations did not run the tool")
+	}
+}
+
+func TestPolicyDecidesBeforeAllowMutations(t *testing.T) {
+	ran := make(chan string, 1)
+	policy := &ai.Policy{Rules: []ai.PolicyRule{{Action: ai.PolicyDeny, Kinds: []string{"Project"}, Reason: "protected"}}}
+	c := startStdio(t, Options{Registry: testRegistry(ran), AllowMutations: true, Policy: policy})
+	c.initialize(`{}`)
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"delete_resource","arguments":{"name":"x","kind":"Project"}}}`)
+	text, isError := toolText(t, c.recv())
+	if isError || !strings.Contains(text, "denied by policy: protected") {
+		t.Errorf("result = %q (isError %v), want a policy denial", text, isError)
+	}
+	if len(ran) != 0 {
+		t.Error("a denied tool ran")
+	}
+}
+
+func TestMutationConfirmedByElicitation(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		answer  string
+		wantRun bool
+	}{
+		{"accept", `{"action":"accept","content":{"confirm":true}}`, true},
+		{"unchecked", `{"action":"accept","content":{"confirm":false}}`, false},
+		{"decline", `{"action":"decline"}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ran := make(chan string, 1)
+			c := startStdio(t, Options{Registry: testRegistry(ran)})
+			c.initialize(`{"elicitation":{}}`)
+
+			c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"delete_resource","arguments":{"name":"x"}}}`)
+			ask := c.recv()
+			if ask["method"] != "elicitation/create" {
+				t.Fatalf("server sent %v, want an elicitation", ask)
+			}
+			if text := ask["params"].(map[string]any)["message"].(string); !strings.Contains(text, "delete_resource") {
+				t.Errorf("elicitation message lacks the preview:\n%s", text)
+			}
+			id, _ := json.Marshal(ask["id"])
+			c.send(`{"jsonrpc":"2.0","id":` + string(id) + `,"result":` + tc.answer + `}`)
+
+			text, isError := toolText(t, c.recv())
+			if isError {
+				t.Fatalf("result is an error: %s", text)
+			}
+			if tc.wantRun {
+				if got := <-ran; got != "delete_resource x" {
+					t.Errorf("ran %q", got)
+				}
+				return
+			}
+			if text != declinedResult || len(ran) != 0 {
+				t.Errorf("result = %q with %d runs, want the declined result and none", text, len(ran))
+			}
+		})
+	}
+}
+
+// TestConcurrentCalls runs many calls at once, as MCP clients may, and is
+// meant for -race: every call, gated or not, is served on its own goroutine
+// against the same registry, policy, and session.
+func TestConcurrentCalls(t *testing.T) {
+	const calls = 32
+	ran := make(chan string, calls)
+	policy := &ai.Policy{MutationProjects: []string{"proj"}}
+	c := startStdio(t, Options{
+		Registry:       testRegistry(ran),
+		AllowMutations: true,
+		Policy:         policy,
+		Project:        func() string { return "proj" },
+	})
+	c.initialize(`{}`)
+
+	for i := range calls {
+		call := `{"name":"list_things","arguments":{}}`
+		if i%2 == 1 {
+			call = `{"name":"delete_resource","arguments":{"name":"x"}}`
+		}
+		c.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":%s}`, i+2, call))
+	}
+	seen := map[float64]bool{}
+	for range calls {
+		msg := c.recv()
+		if text, isError := toolText(t, msg); isError {
+			t.Errorf("call %v failed: %s", msg["id"], text)
+		}
+		seen[msg["id"].(float64)] = true
+	}
+	if len(seen) != calls || len(ran) != calls {
+		t.Errorf("answered %d calls and ran %d, want %d of each", len(seen), len(ran), calls)
+	}
+}
+
+func TestStdioEOFFailsPendingConfirmation(t *testing.T) {
+	ran := make(chan string, 1)
+	c := startStdio(t, Options{Registry: testRegistry(ran)})
+	c.initialize(`{"elicitation":{}}`)
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"delete_resource","arguments":{"name":"x"}}}`)
+	c.recv() // the elicitation
+	go func() {
+		for c.lines.Scan() { // drain the final response
+		}
+	}()
+	c.in.Close()
+
+	select {
+	case err := <-c.done:
+		if err != nil {
+			t.Errorf("ServeStdio = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeStdio did not return after EOF with a confirmation pending")
+	}
+	if len(ran) != 0 {
+		t.Error("the tool ran although nobody confirmed")
+	}
+}
+
+// testToken is the bearer token the HTTP tests serve with and send.
+const testToken = "test-token"
+
+func postJSON(t *testing.T, url, session, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if session != "" {
+		req.Header.Set(SessionHeader, session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestHTTPSessionAndElicitation(t *testing.T) {
+	ran := make(chan string, 1)
+	srv := httptest.NewServer(NewServer(Options{Registry: testRegistry(ran)}).Handler(testToken))
+	defer srv.Close()
+
+	resp := postJSON(t, srv.URL, "", `{"jsonrpc":"2.0","method":"tools/list","id":1}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("request without a session = %d, want 400", resp.StatusCode)
+	}
+
+	resp = postJSON(t, srv.URL, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{"elicitation":{}}}}`)
+	resp.Body.Close()
+	session := resp.Header.Get(SessionHeader)
+	if resp.StatusCode != http.StatusOK || session == "" {
+		t.Fatalf("initialize = %d with session %q", resp.StatusCode, session)
+	}
+	if resp := postJSON(t, srv.URL, session, `{"jsonrpc":"2.0","method":"notifications/initialized"}`); resp.StatusCode != http.StatusAccepted {
+		t.Errorf("notification = %d, want 202", resp.StatusCode)
+	}
+
+	// The mutation's response becomes an event stream: first the
+	// elicitation, then — once the answer is POSTed — the result.
+	resp = postJSON(t, srv.URL, session, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"delete_resource","arguments":{"name":"x"}}}`)
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want an event stream", ct)
+	}
+	events := bufio.NewScanner(resp.Body)
+	nextEvent := func() map[string]any {
+		for events.Scan() {
+			if data, ok := strings.CutPrefix(events.Text(), "data: "); ok {
+				var msg map[string]any
+				if err := json.Unmarshal([]byte(data), &msg); err != nil {
+					t.Fatal(err)
+				}
+				return msg
+			}
+		}
+		t.Fatalf("stream ended: %v", events.Err())
+		return nil
+	}
+	ask := nextEvent()
+	if ask["method"] != "elicitation/create" {
+		t.Fatalf("first event = %v, want an elicitation", ask)
+	}
+	id, _ := json.Marshal(ask["id"])
+	answer := postJSON(t, srv.URL, session, `{"jsonrpc":"2.0","id":`+string(id)+`,"result":{"action":"accept","content":{"confirm":true}}}`)
+	answer.Body.Close()
+	if answer.StatusCode != http.StatusAccepted {
+		t.Errorf("answer = %d, want 202", answer.StatusCode)
+	}
+	if text, isError := toolText(t, nextEvent()); isError || text != `{"deleted":true}` {
+		t.Errorf("result = %q (isError %v)", text, isError)
+	}
+	if got := <-ran; got != "delete_resource x" {
+		t.Errorf("ran %q", got)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL, nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set(SessionHeader, session)
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE = %v, %v", resp, err)
+	}
+	resp = postJSON(t, srv.URL, session, `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("request on an ended session = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHTTPRejectsNonLocalClients(t *testing.T) {
+	handler := NewServer(Options{}).Handler(testToken)
+	for _, tc := range []struct{ host, origin string }{
+		{"attacker.example:8765", ""},
+		{"127.0.0.1:8765", "https://attacker.example"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewBufferString(`{}`))
+		req.Host = tc.host
+		if tc.origin != "" {
+			req.Header.Set("Origin", tc.origin)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("host %q origin %q = %d, want 403", tc.host, tc.origin, rec.Code)
+		}
+	}
+}
+
+func TestHTTPRequiresToken(t *testing.T) {
+	ran := make(chan string, 1)
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{}}}`
+	for _, tc := range []struct {
+		name, serverToken, auth string
+	}{
+		{"no header", testToken, ""},
+		{"wrong token", testToken, "Bearer other"},
+		{"not bearer", testToken, "Basic " + testToken},
+		{"server without a token", "", "Bearer "},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewServer(Options{Registry: testRegistry(ran), AllowMutations: true}).Handler(tc.serverToken)
+			req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(initialize))
+			req.Host = "127.0.0.1:8765"
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized || rec.Header().Get(SessionHeader) != "" {
+				t.Errorf("status = %d with session %q, want 401 and no session", rec.Code, rec.Header().Get(SessionHeader))
+			}
+		})
+	}
+	if len(ran) != 0 {
+		t.Error("a tool ran for an unauthenticated client")
+	}
+}
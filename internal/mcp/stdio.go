@@ -0,0 +1,117 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ServeStdio serves one client over newline-delimited JSON-RPC on in and
+// out, as MCP clients expect of a server they launch. When in reaches EOF it
+// lets running requests finish — failing any confirmation they still wait
+// for, since no answer can arrive — and returns. When ctx is done, running
+// requests are cancelled instead.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	conn := &stdioConn{server: s, out: out, closed: make(chan struct{})}
+	sess := newSession()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(conn.closed)
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		var line []byte
+		select {
+		case line = <-lines:
+		case err := <-readErr:
+			return err // the deferred close fails pending confirmations
+		case <-ctx.Done():
+			return nil
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		msg, err := parseMessage(line)
+		switch {
+		case err != nil:
+			conn.send(errorMessage(nil, codeParseError, "invalid JSON-RPC message: "+err.Error()))
+		case msg.isRequest():
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				conn.send(s.serve(ctx, sess, msg, conn))
+			}()
+		case msg.isNotification():
+			s.notify(sess, msg)
+		default:
+			s.deliver(msg)
+		}
+	}
+}
+
+// stdioConn writes whole messages to the client, one per line, and is the
+// peer for server-initiated requests.
+type stdioConn struct {
+	server *Server
+	// closed is closed when the client's input ends.
+	closed chan struct{}
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (c *stdioConn) send(msg *message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		fmt.Fprintf(c.server.opts.Log, "[mcp] encode message: %v\n", err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = c.out.Write(append(data, '\n'))
+}
+
+func (c *stdioConn) request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	msg, wait, err := c.server.await(method, params)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	c.send(msg)
+	return wait(ctx)
+}
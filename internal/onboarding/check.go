@@ -150,17 +150,17 @@ func UserError(result Result) error {
 		return customerrors.NewUserErrorWithHint(
 			"You're signed in, but you don't have an organization yet.",
 			hint,
-		)
+		).WithCode(customerrors.CodeOnboardingIncomplete)
 	case OrgIncomplete:
 		return customerrors.NewUserErrorWithHint(
 			orgIncompleteMessage(result.OrgDisplayName, result.Reason, result.Message),
 			hint,
-		)
+		).WithCode(customerrors.CodeOnboardingIncomplete)
 	default:
 		return customerrors.NewUserErrorWithHint(
 			"Your account still needs a little setup in the portal.",
 			hint,
-		)
+		).WithCode(customerrors.CodeOnboardingIncomplete)
 	}
 }
 
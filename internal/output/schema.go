@@ -0,0 +1,281 @@
+package output
+
+// This file defines the structured documents emitted by datumctl-native
+// commands with -o json|yaml. Every document embeds TypeMeta; see
+// docs/output.md for the user-facing reference.
+
+import (
+	"time"
+
+	"go.datum.net/datumctl/internal/datumconfig"
+)
+
+// SessionSummary describes one locally stored login session.
+type SessionSummary struct {
+	Name      string `json:"name"`
+	UserEmail string `json:"userEmail"`
+	UserName  string `json:"userName,omitempty"`
+	Endpoint  string `json:"endpoint"`
+	Active    bool   `json:"active"`
+}
+
+// NewSessionSummary converts a stored session into its structured form.
+func NewSessionSummary(s *datumconfig.Session, activeSession string) SessionSummary {
+	return SessionSummary{
+		Name:      s.Name,
+		UserEmail: s.UserEmail,
+		UserName:  s.UserName,
+		Endpoint:  s.Endpoint.Server,
+		Active:    s.Name == activeSession,
+	}
+}
+
+// ContextSummary describes one discovered organization or project context.
+type ContextSummary struct {
+	// Name is the session-qualified unique key ("session/ref").
+	Name string `json:"name"`
+	// Ref is the session-relative value passed to 'datumctl ctx use'.
+	Ref                     string `json:"ref"`
+	Session                 string `json:"session"`
+	Type                    string `json:"type"`
+	OrganizationID          string `json:"organizationID"`
+	OrganizationDisplayName string `json:"organizationDisplayName,omitempty"`
+	ProjectID               string `json:"projectID,omitempty"`
+	ProjectDisplayName      string `json:"projectDisplayName,omitempty"`
+	Namespace               string `json:"namespace,omitempty"`
+	Current                 bool   `json:"current"`
+}
+
+// NewContextSummary converts a discovered context into its structured form,
+// resolving display names within the context's own session.
+func NewContextSummary(cfg *datumconfig.ConfigV1Beta1, c *datumconfig.DiscoveredContext) ContextSummary {
+	summary := ContextSummary{
+		Name:                    c.Name,
+		Ref:                     c.Ref(),
+		Session:                 c.Session,
+		Type:                    "organization",
+		OrganizationID:          c.OrganizationID,
+		OrganizationDisplayName: cfg.OrgDisplayName(c.Session, c.OrganizationID),
+		Namespace:               c.Namespace,
+		Current:                 cfg.CurrentContext == c.Name,
+	}
+	if c.ProjectID != "" {
+		summary.Type = "project"
+		summary.ProjectID = c.ProjectID
+		summary.ProjectDisplayName = cfg.ProjectDisplayName(c.Session, c.ProjectID)
+	}
+	return summary
+}
+
+// ContextList is emitted by 'datumctl ctx' and 'datumctl ctx list'.
+type ContextList struct {
+	TypeMeta
+	CurrentContext string           `json:"currentContext,omitempty"`
+	ActiveSession  string           `json:"activeSession,omitempty"`
+	Sessions       []SessionSummary `json:"sessions"`
+	Contexts       []ContextSummary `json:"contexts"`
+}
+
+// SessionList is emitted by 'datumctl auth list'.
+type SessionList struct {
+	TypeMeta
+	Sessions []SessionSummary `json:"sessions"`
+}
+
+// OnboardingStatus reports whether the effective organization has finished
+// cloud-portal setup.
+type OnboardingStatus struct {
+	// State is one of: complete, needs-onboarding, org-incomplete, unknown.
+	State     string `json:"state"`
+	Reason    string `json:"reason,omitempty"`
+	ActionURL string `json:"actionURL,omitempty"`
+}
+
+// WhoAmI is emitted by 'datumctl whoami'.
+type WhoAmI struct {
+	TypeMeta
+	User       WhoAmIUser        `json:"user"`
+	Session    string            `json:"session"`
+	Endpoint   string            `json:"endpoint"`
+	Context    *ContextSummary   `json:"context,omitempty"`
+	Onboarding *OnboardingStatus `json:"onboarding,omitempty"`
+	// Overrides lists DATUM_* environment variables that take precedence over
+	// the active context, keyed by variable name.
+	Overrides map[string]string `json:"overrides,omitempty"`
+}
+
+// WhoAmIUser identifies the signed-in user.
+type WhoAmIUser struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+// InstalledPluginSummary describes one managed plugin.
+type InstalledPluginSummary struct {
+	Name        string `json:"name"`
+	Catalog     string `json:"catalog"`
+	Version     string `json:"version"`
+	Trust       string `json:"trust"`
+	Description string `json:"description,omitempty"`
+	// Status is one of: ok, update-available, incompatible, unknown.
+	Status        string `json:"status"`
+	LatestVersion string `json:"latestVersion,omitempty"`
+}
+
+// PluginList is emitted by 'datumctl plugin list'.
+type PluginList struct {
+	TypeMeta
+	Plugins []InstalledPluginSummary `json:"plugins"`
+}
+
+// AvailablePluginSummary describes one plugin offered by a catalog.
+type AvailablePluginSummary struct {
+	Name        string `json:"name"`
+	Catalog     string `json:"catalog"`
+	Version     string `json:"version"`
+	Trust       string `json:"trust"`
+	Description string `json:"description,omitempty"`
+}
+
+// PluginSearchResult is emitted by 'datumctl plugin search'.
+type PluginSearchResult struct {
+	TypeMeta
+	Query   string                   `json:"query,omitempty"`
+	Plugins []AvailablePluginSummary `json:"plugins"`
+	// SkippedCatalogs lists catalogs that could not be read, with the reason.
+	SkippedCatalogs map[string]string `json:"skippedCatalogs,omitempty"`
+}
+
+// CatalogSummary describes one registered plugin catalog.
+type CatalogSummary struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Trust       string `json:"trust"`
+	Description string `json:"description,omitempty"`
+	// PluginCount is omitted when the catalog index is unavailable.
+	PluginCount *int `json:"pluginCount,omitempty"`
+	Disabled    bool `json:"disabled"`
+	// DisabledReason explains why a disabled catalog is excluded.
+	DisabledReason string `json:"disabledReason,omitempty"`
+}
+
+// CatalogList is emitted by 'datumctl plugin index list'.
+type CatalogList struct {
+	TypeMeta
+	Catalogs []CatalogSummary `json:"catalogs"`
+}
+
+// ErrorCodeSummary describes one registered error code.
+type ErrorCodeSummary struct {
+	Code        string `json:"code"`
+	ExitCode    int    `json:"exitCode"`
+	Summary     string `json:"summary"`
+	Retryable   bool   `json:"retryable"`
+	Remediation string `json:"remediation"`
+}
+
+// ErrorCodeList is emitted by 'datumctl errors list'.
+type ErrorCodeList struct {
+	TypeMeta
+	Codes []ErrorCodeSummary `json:"codes"`
+}
+
+// DoctorCheck is the outcome of one 'datumctl doctor' check.
+type DoctorCheck struct {
+	Name string `json:"name"`
+	// Status is one of: pass, warn, fail, skip.
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// DoctorReport is emitted by 'datumctl doctor'.
+type DoctorReport struct {
+	TypeMeta
+	Version  string        `json:"version"`
+	Platform string        `json:"platform"`
+	Checks   []DoctorCheck `json:"checks"`
+}
+
+// ProxySummary describes one background API proxy started with
+// 'datumctl api proxy start'.
+type ProxySummary struct {
+	Name string `json:"name"`
+	// Status is one of: running, unresponsive, stopped.
+	Status        string    `json:"status"`
+	PID           int       `json:"pid"`
+	URL           string    `json:"url"`
+	Session       string    `json:"session,omitempty"`
+	Scope         string    `json:"scope,omitempty"`
+	TokenRequired bool      `json:"tokenRequired"`
+	LogFile       string    `json:"logFile"`
+	StartedAt     time.Time `json:"startedAt"`
+}
+
+// ProxyStatus is emitted by 'datumctl api proxy status'.
+type ProxyStatus struct {
+	TypeMeta
+	ProxySummary
+}
+
+// ProxyList is emitted by 'datumctl api proxy list'.
+type ProxyList struct {
+	TypeMeta
+	Proxies []ProxySummary `json:"proxies"`
+}
+
+// ConversationSummary describes one saved 'datumctl ai' conversation.
+type ConversationSummary struct {
+	ID             string    `json:"id"`
+	StartedAt      time.Time `json:"startedAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	OrganizationID string    `json:"organizationID,omitempty"`
+	ProjectID      string    `json:"projectID,omitempty"`
+	Namespace      string    `json:"namespace,omitempty"`
+	PlatformWide   bool      `json:"platformWide,omitempty"`
+	Questions      int       `json:"questions"`
+	Preview        string    `json:"preview,omitempty"`
+}
+
+// ConversationList is emitted by 'datumctl ai history list'.
+type ConversationList struct {
+	TypeMeta
+	Conversations []ConversationSummary `json:"conversations"`
+}
+
+// AIToolCall is one tool call made while answering a 'datumctl ai' question.
+// It has exactly one of Result, Error, and Skipped.
+type AIToolCall struct {
+	ID        string         `json:"id"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+	Result    string         `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	// Skipped says why a change was not made: "not confirmed", or
+	// "denied by policy: ...".
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// AIUsage is the tokens a 'datumctl ai' turn used. InputTokens excludes the
+// cached input tokens, which are counted separately.
+type AIUsage struct {
+	InputTokens      int `json:"inputTokens"`
+	OutputTokens     int `json:"outputTokens"`
+	CacheReadTokens  int `json:"cacheReadTokens,omitempty"`
+	CacheWriteTokens int `json:"cacheWriteTokens,omitempty"`
+	// EstimatedCostUSD is the list-price cost, when the model's price is known.
+	EstimatedCostUSD *float64 `json:"estimatedCostUSD,omitempty"`
+}
+
+// AITurn is emitted by 'datumctl ai --output json', and as the last line of
+// --output ndjson.
+type AITurn struct {
+	TypeMeta
+	Provider   string       `json:"provider"`
+	Model      string       `json:"model"`
+	Answer     string       `json:"answer"`
+	ToolCalls  []AIToolCall `json:"toolCalls"`
+	Iterations int          `json:"iterations"`
+	Usage      *AIUsage     `json:"usage,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
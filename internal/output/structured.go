@@ -0,0 +1,85 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	customerrors "go.datum.net/datumctl/internal/errors"
+)
+
+// SchemaAPIVersion identifies the version of the structured documents that
+// datumctl-native commands emit with -o json|yaml. Fields may be added within a
+// version; removing or renaming a field requires a new version.
+const SchemaAPIVersion = "datumctl.output.datum.net/v1alpha1"
+
+// Structured output formats accepted by --output on datumctl-native commands.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// TypeMeta identifies the schema of a structured output document. It is
+// embedded in every top-level document so consumers can check apiVersion and
+// kind before decoding the rest.
+type TypeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// NewTypeMeta returns the TypeMeta for a document of the given kind at the
+// current SchemaAPIVersion.
+func NewTypeMeta(kind string) TypeMeta {
+	return TypeMeta{APIVersion: SchemaAPIVersion, Kind: kind}
+}
+
+// AddOutputFlag registers the -o/--output flag used by datumctl-native
+// commands. An empty value keeps the human-readable output.
+func AddOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "", "Output format. One of: json, yaml. Defaults to human-readable text.")
+}
+
+// OutputFormat returns the validated --output value for cmd: "" for human
+// output, or FormatJSON / FormatYAML. Commands without the flag report "".
+func OutputFormat(cmd *cobra.Command) (string, error) {
+	f := cmd.Flags().Lookup("output")
+	if f == nil {
+		return "", nil
+	}
+	switch f.Value.String() {
+	case "":
+		return "", nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatYAML:
+		return FormatYAML, nil
+	default:
+		return "", customerrors.NewUserErrorWithHint(
+			fmt.Sprintf("invalid value %q for --output", f.Value.String()),
+			"Allowed values: json, yaml.",
+		)
+	}
+}
+
+// PrintStructured writes doc to w as indented JSON or YAML. YAML is produced
+// from the JSON encoding, so both formats share the json struct tags.
+func PrintStructured(w io.Writer, format string, doc any) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case FormatYAML:
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
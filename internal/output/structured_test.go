@@ -0,0 +1,93 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestPrintStructured(t *testing.T) {
+	doc := SessionList{
+		TypeMeta: NewTypeMeta("SessionList"),
+		Sessions: []SessionSummary{{Name: "s1", UserEmail: "a@example.com", Endpoint: "https://api.example.com", Active: true}},
+	}
+
+	tests := []struct {
+		name       string
+		format     string
+		wantErr    bool
+		wantOutput []string
+	}{
+		{
+			name:   "JSON",
+			format: FormatJSON,
+			wantOutput: []string{
+				`"apiVersion": "` + SchemaAPIVersion + `"`,
+				`"kind": "SessionList"`,
+				`"userEmail": "a@example.com"`,
+				`"active": true`,
+			},
+		},
+		{
+			name:   "YAML",
+			format: FormatYAML,
+			wantOutput: []string{
+				"apiVersion: " + SchemaAPIVersion,
+				"kind: SessionList",
+				"userEmail: a@example.com",
+			},
+		},
+		{
+			name:    "Unsupported",
+			format:  "table",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := PrintStructured(&buf, tt.format, doc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PrintStructured() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("PrintStructured() output = \n%s, want to contain \n%s", buf.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: ""},
+		{value: "json", want: FormatJSON},
+		{value: "yaml", want: FormatYAML},
+		{value: "wide", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "test"}
+			AddOutputFlag(cmd)
+			if err := cmd.Flags().Set("output", tt.value); err != nil {
+				t.Fatalf("set --output: %v", err)
+			}
+			got, err := OutputFormat(cmd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OutputFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("OutputFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@
 package plugindispatch
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -13,12 +14,14 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/mod/semver"
 
 	"go.datum.net/datumctl/internal/authutil"
 	"go.datum.net/datumctl/internal/client"
 	"go.datum.net/datumctl/internal/datumconfig"
 	"go.datum.net/datumctl/internal/pluginstore"
+	"go.datum.net/datumctl/internal/telemetry"
 )
 
 // osExit is a package-level indirection over os.Exit so tests can capture the
@@ -36,6 +39,14 @@ func Exec(binaryPath string, args []string, factory *client.DatumCloudFactory) e
 	if err != nil {
 		return fmt.Errorf("build plugin environment: %w", err)
 	}
+	// The plugin continues the trace under a span of its own. Everything is
+	// flushed first: on Unix the exec below replaces this process.
+	ctx, span := telemetry.StartSpan(context.Background(), "plugin.exec",
+		attribute.String("datumctl.plugin", filepath.Base(binaryPath)))
+	env = telemetry.InjectEnv(ctx, env)
+	span.End()
+	telemetry.EndCommand(nil)
+
 	merged := overlayEnv(os.Environ(), env)
 	if execErr := execPlatform(binaryPath, args, merged); execErr != nil {
 		// On Windows, kubectl's DefaultPluginHandler returns the child's
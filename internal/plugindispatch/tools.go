@@ -0,0 +1,99 @@
+package plugindispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"go.datum.net/datumctl/internal/client"
+	"go.datum.net/datumctl/internal/telemetry"
+)
+
+const (
+	// toolCallTimeout bounds one plugin tool call.
+	toolCallTimeout = 2 * time.Minute
+
+	// maxToolOutputBytes caps what a plugin tool call may return.
+	maxToolOutputBytes = 1 << 20
+)
+
+// RunTool calls an AI tool declared in a plugin's manifest: it runs
+// binaryPath with --plugin-tool <tool>, writes args as a JSON object to its
+// stdin, and returns its stdout. The plugin gets the same DATUM_* environment
+// as when run as a command. A non-zero exit is an error carrying the plugin's
+// stderr.
+//
+// Callers must have verified the binary (see VerifyManagedPluginIntegrity);
+// RunTool executes it as given.
+func RunTool(ctx context.Context, binaryPath, tool string, args map[string]any, factory *client.DatumCloudFactory) (string, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	input, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("encode tool arguments: %w", err)
+	}
+	env, err := BuildEnv(factory)
+	if err != nil {
+		return "", fmt.Errorf("build plugin environment: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, toolCallTimeout)
+	defer cancel()
+	ctx, span := telemetry.StartSpan(ctx, "plugin.tool",
+		attribute.String("datumctl.plugin", filepath.Base(binaryPath)),
+		attribute.String("datumctl.plugin.tool", tool))
+	defer span.End()
+
+	stdout := &limitedWriter{n: maxToolOutputBytes}
+	stderr := &limitedWriter{n: maxToolOutputBytes}
+	cmd := exec.CommandContext(ctx, binaryPath, "--plugin-tool", tool)
+	cmd.Env = overlayEnv(os.Environ(), telemetry.InjectEnv(ctx, env))
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("plugin tool %s timed out after %s", tool, toolCallTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("plugin tool %s failed: %s", tool, msg)
+		}
+		return "", fmt.Errorf("plugin tool %s failed: %w", tool, err)
+	}
+	return stdout.String(), nil
+}
+
+// limitedWriter keeps at most n bytes and counts the rest, so a runaway
+// plugin cannot exhaust memory.
+type limitedWriter struct {
+	buf     bytes.Buffer
+	n       int
+	dropped int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	kept := min(len(p), max(0, l.n-l.buf.Len()))
+	l.buf.Write(p[:kept])
+	l.dropped += len(p) - kept
+	return len(p), nil
+}
+
+// String returns the output kept, ending with a note of how much was dropped
+// so a cut-off result is never mistaken for a whole one.
+func (l *limitedWriter) String() string {
+	if l.dropped == 0 {
+		return l.buf.String()
+	}
+	return fmt.Sprintf("%s\n[... %d more bytes dropped: plugin tool output is limited to %d bytes]", l.buf.String(), l.dropped, l.n)
+}
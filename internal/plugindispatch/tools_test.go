@@ -0,0 +1,80 @@
+package plugindispatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeToolScript writes an executable shell script at dir/name.
+func writeToolScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin tool scripts need a POSIX shell")
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatalf("write tool script: %v", err)
+	}
+	return path
+}
+
+// TestRunTool_passesArgumentsOnStdin verifies the tool name is passed after
+// --plugin-tool, the arguments arrive as JSON on stdin, and the DATUM_*
+// environment is set.
+func TestRunTool_passesArgumentsOnStdin(t *testing.T) {
+	// Not parallel — uses t.Setenv via buildMinimalFactory.
+	factory := buildMinimalFactory(t)
+	bin := writeToolScript(t, t.TempDir(), "dns",
+		`echo "$1 $2 api=$DATUM_PLUGIN_API_VERSION"; cat`+"\n")
+
+	out, err := RunTool(context.Background(), bin, "list_zones", map[string]any{"zone": "example.com"}, factory)
+	if err != nil {
+		t.Fatalf("RunTool: %v", err)
+	}
+	want := "--plugin-tool list_zones api=1\n" + `{"zone":"example.com"}`
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+// TestLimitedWriter verifies output past the limit is dropped with a note
+// saying how much.
+func TestLimitedWriter(t *testing.T) {
+	t.Parallel()
+	w := &limitedWriter{n: 8}
+	for _, chunk := range []string{"{\"items\"", ":[1,2,3]}"} {
+		if n, err := w.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	want := "{\"items\"\n[... 9 more bytes dropped: plugin tool output is limited to 8 bytes]"
+	if got := w.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	whole := &limitedWriter{n: 8}
+	whole.Write([]byte("{}"))
+	if got := whole.String(); got != "{}" {
+		t.Errorf("String() under the limit = %q, want the output as is", got)
+	}
+}
+
+// TestRunTool_failureReportsStderr verifies a non-zero exit becomes an error
+// carrying what the plugin wrote to stderr.
+func TestRunTool_failureReportsStderr(t *testing.T) {
+	// Not parallel — uses t.Setenv via buildMinimalFactory.
+	factory := buildMinimalFactory(t)
+	bin := writeToolScript(t, t.TempDir(), "dns", "echo 'zone not found' >&2\nexit 3\n")
+
+	_, err := RunTool(context.Background(), bin, "get_zone", nil, factory)
+	if err == nil {
+		t.Fatal("RunTool: expected an error for a non-zero exit")
+	}
+	if !strings.Contains(err.Error(), "zone not found") {
+		t.Errorf("error %q should carry the plugin's stderr", err.Error())
+	}
+}
@@ -29,6 +29,19 @@ type PluginManifest struct {
 	MinDatumctlVersion string `json:"min_datumctl_version,omitempty"`
 	APIVersion         int    `json:"api_version"`
 	MinAPIVersion      int    `json:"min_api_version,omitempty"`
+	// Tools are the AI agent tools the plugin contributes; see PluginTool.
+	Tools []PluginTool `json:"tools,omitempty"`
+}
+
+// PluginTool is an AI agent tool declared in a plugin's manifest. The agent
+// calls it by running the plugin with --plugin-tool <name> and the call's
+// arguments as a JSON object on stdin; the plugin's stdout is the result.
+type PluginTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema,omitempty"`
+	// Mutating tools change state and are confirmed before each call.
+	Mutating bool `json:"mutating,omitempty"`
 }
 
 // TrustedEntry records a trusted PATH-plugin binary path.
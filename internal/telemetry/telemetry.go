@@ -0,0 +1,285 @@
+// Package telemetry implements opt-in OpenTelemetry tracing of datumctl
+// itself: one span for the command, child spans for the phases of building a
+// client (config load, keyring reads, token refresh, the onboarding check,
+// discovery) and one per HTTP request.
+//
+// Tracing is off unless DATUMCTL_OTEL_EXPORTER is set. When it is off, the
+// global no-op tracer provider stays installed and every helper here costs a
+// context lookup at most.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	componentversion "k8s.io/component-base/version"
+)
+
+// Environment variables that configure tracing.
+const (
+	// EnvExporter selects the exporter: "otlp" or "file". Unset or empty
+	// disables tracing.
+	EnvExporter = "DATUMCTL_OTEL_EXPORTER"
+
+	// EnvFile is the file the "file" exporter appends to.
+	EnvFile = "DATUMCTL_OTEL_FILE"
+)
+
+// Exporter names accepted in DATUMCTL_OTEL_EXPORTER.
+const (
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+// DefaultFile is the "file" exporter's destination when DATUMCTL_OTEL_FILE is
+// unset, relative to the working directory.
+const DefaultFile = "datumctl-trace.jsonl"
+
+const (
+	tracerName = "go.datum.net/datumctl"
+
+	// shutdownTimeout bounds the final flush, so an unreachable collector
+	// delays exit by at most this long.
+	shutdownTimeout = 5 * time.Second
+)
+
+var (
+	mu       sync.Mutex
+	provider *sdktrace.TracerProvider
+	closer   io.Closer
+	// rootCtx carries the command span. Work that reaches us on a context
+	// without a span — most of kubectl runs on context.TODO() — is parented
+	// here instead of starting disconnected traces.
+	rootCtx     = context.Background()
+	commandSpan trace.Span
+	endOnce     *sync.Once
+)
+
+// propagator is the W3C trace-context + baggage propagator used for HTTP
+// headers and the plugin environment.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Enabled reports whether tracing was configured by StartCommand.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return provider != nil
+}
+
+// StartCommand configures tracing from the environment and opens the command
+// span, named after argv until SetCommand refines it. A TRACEPARENT in the
+// environment — set by a parent datumctl for plugins, or by a traced CI job —
+// makes the command span its child. Configuration problems are returned for
+// the caller to report; the command should still run, untraced.
+func StartCommand(ctx context.Context) (context.Context, error) {
+	exporter := strings.TrimSpace(os.Getenv(EnvExporter))
+	if exporter == "" {
+		return ctx, nil
+	}
+	tp, c, err := newProvider(ctx, exporter)
+	if err != nil {
+		return ctx, err
+	}
+	install(tp, c)
+
+	ctx = propagator.Extract(ctx, envCarrier(os.Environ()))
+	ctx, span := Tracer().Start(ctx, "datumctl", trace.WithAttributes(
+		attribute.String("datumctl.version", componentversion.Get().GitVersion),
+	))
+	mu.Lock()
+	rootCtx, commandSpan, endOnce = ctx, span, &sync.Once{}
+	mu.Unlock()
+	return ctx, nil
+}
+
+// SetCommand names the command span after the resolved Cobra command path,
+// e.g. "datumctl get". Arguments are deliberately not recorded: they may
+// carry secrets.
+func SetCommand(commandPath string) {
+	mu.Lock()
+	span := commandSpan
+	mu.Unlock()
+	if span == nil {
+		return
+	}
+	span.SetName(commandPath)
+	span.SetAttributes(attribute.String("datumctl.command", commandPath))
+}
+
+// EndCommand ends the command span, recording err, and flushes every pending
+// span. It must run before the process exits or execs a plugin; later calls
+// are no-ops.
+func EndCommand(err error) {
+	mu.Lock()
+	span, once := commandSpan, endOnce
+	mu.Unlock()
+	if once == nil {
+		return
+	}
+	once.Do(func() {
+		End(span, err)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		shutdown(ctx)
+	})
+}
+
+// Tracer returns datumctl's tracer.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// StartSpan starts a span under the span in ctx or, when ctx has none, under
+// the command span.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(parentContext(ctx), name, trace.WithAttributes(attrs...))
+}
+
+// End records err, if any, on span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// InjectEnv appends TRACEPARENT (and TRACESTATE / BAGGAGE when present) for
+// the active span to env, following the OpenTelemetry convention for
+// propagating context to child processes. It returns env unchanged when
+// tracing is off.
+func InjectEnv(ctx context.Context, env []string) []string {
+	if !Enabled() {
+		return env
+	}
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(parentContext(ctx), carrier)
+	for key, value := range carrier {
+		env = append(env, strings.ToUpper(key)+"="+value)
+	}
+	return env
+}
+
+func parentContext(ctx context.Context) context.Context {
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		mu.Lock()
+		defer mu.Unlock()
+		return rootCtx
+	}
+	return ctx
+}
+
+func newProvider(ctx context.Context, exporter string) (*sdktrace.TracerProvider, io.Closer, error) {
+	var (
+		spanExporter sdktrace.SpanExporter
+		c            io.Closer
+		err          error
+	)
+	switch exporter {
+	case ExporterOTLP:
+		// Endpoint, headers, and TLS come from the standard
+		// OTEL_EXPORTER_OTLP_* variables.
+		spanExporter, err = otlptracehttp.New(ctx)
+	case ExporterFile:
+		path := os.Getenv(EnvFile)
+		if path == "" {
+			path = DefaultFile
+		}
+		f, openErr := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if openErr != nil {
+			return nil, nil, fmt.Errorf("open trace file: %w", openErr)
+		}
+		c = f
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, nil, fmt.Errorf("unsupported %s %q (want %s or %s)", EnvExporter, exporter, ExporterOTLP, ExporterFile)
+	}
+	if err != nil {
+		if c != nil {
+			c.Close()
+		}
+		return nil, nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithAttributes(
+			attribute.String("service.name", "datumctl"),
+			attribute.String("service.version", componentversion.Get().GitVersion),
+		),
+	)
+	if err != nil {
+		res = resource.Default()
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	return tp, c, nil
+}
+
+func install(tp *sdktrace.TracerProvider, c io.Closer) {
+	mu.Lock()
+	provider, closer = tp, c
+	mu.Unlock()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		fmt.Fprintf(os.Stderr, "warning: tracing: %v\n", err)
+	}))
+}
+
+func shutdown(ctx context.Context) {
+	mu.Lock()
+	tp, c := provider, closer
+	mu.Unlock()
+	if tp == nil {
+		return
+	}
+	if err := tp.Shutdown(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: tracing: %v\n", err)
+	}
+	if c != nil {
+		c.Close()
+	}
+}
+
+// envCarrier exposes TRACEPARENT-style environment variables to the
+// propagator, which asks for lower-case header names.
+type envCarrier []string
+
+func (e envCarrier) Get(key string) string {
+	prefix := strings.ToUpper(key) + "="
+	for _, kv := range e {
+		if value, ok := strings.CutPrefix(kv, prefix); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func (e envCarrier) Set(string, string) {}
+
+func (e envCarrier) Keys() []string {
+	keys := make([]string, 0, len(e))
+	for _, kv := range e {
+		if key, _, ok := strings.Cut(kv, "="); ok {
+			keys = append(keys, strings.ToLower(key))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,187 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// resetGlobals restores the disabled state after a test.
+func resetGlobals(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		mu.Lock()
+		provider, closer, commandSpan, endOnce = nil, nil, nil, nil
+		rootCtx = context.Background()
+		mu.Unlock()
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+}
+
+// useRecorder installs an in-memory exporter and opens a command span.
+func useRecorder(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	resetGlobals(t)
+	exp := tracetest.NewInMemoryExporter()
+	install(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)), nil)
+	ctx, span := Tracer().Start(context.Background(), "datumctl")
+	mu.Lock()
+	rootCtx, commandSpan, endOnce = ctx, span, &sync.Once{}
+	mu.Unlock()
+	return exp
+}
+
+func spanNamed(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, s := range spans {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+func TestDisabledByDefault(t *testing.T) {
+	resetGlobals(t)
+	t.Setenv(EnvExporter, "")
+
+	if _, err := StartCommand(context.Background()); err != nil {
+		t.Fatalf("StartCommand: %v", err)
+	}
+	if Enabled() {
+		t.Fatal("tracing enabled without DATUMCTL_OTEL_EXPORTER")
+	}
+	rt := http.DefaultTransport
+	if WrapTransport(rt) != rt {
+		t.Error("WrapTransport should return the transport unchanged when disabled")
+	}
+	if env := InjectEnv(context.Background(), []string{"A=1"}); len(env) != 1 {
+		t.Errorf("InjectEnv added variables while disabled: %v", env)
+	}
+	EndCommand(nil) // must not panic
+}
+
+func TestStartCommand_unsupportedExporter(t *testing.T) {
+	resetGlobals(t)
+	t.Setenv(EnvExporter, "jaeger")
+
+	if _, err := StartCommand(context.Background()); err == nil || !strings.Contains(err.Error(), "jaeger") {
+		t.Fatalf("err = %v, want unsupported exporter error", err)
+	}
+	if Enabled() {
+		t.Error("tracing enabled after a configuration error")
+	}
+}
+
+func TestStartSpan_fallsBackToCommandSpan(t *testing.T) {
+	exp := useRecorder(t)
+
+	_, span := StartSpan(context.TODO(), "config.load")
+	span.End()
+	SetCommand("datumctl get")
+	// Not EndCommand: shutting down the in-memory exporter discards spans.
+	commandSpan.End()
+
+	spans := exp.GetSpans()
+	root, ok := spanNamed(spans, "datumctl get")
+	if !ok {
+		t.Fatalf("command span not renamed; got %v", spans)
+	}
+	child, ok := spanNamed(spans, "config.load")
+	if !ok {
+		t.Fatal("config.load span missing")
+	}
+	if child.Parent.SpanID() != root.SpanContext.SpanID() {
+		t.Error("span started on a bare context should be a child of the command span")
+	}
+}
+
+func TestWrapTransport(t *testing.T) {
+	exp := useRecorder(t)
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: WrapTransport(http.DefaultTransport)}
+	resp, err := client.Get(srv.URL + "/apis?labelSelector=secret")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	resp.Body.Close()
+
+	span, ok := spanNamed(exp.GetSpans(), "HTTP GET")
+	if !ok {
+		t.Fatal("no HTTP span recorded")
+	}
+	if traceparent == "" || !strings.Contains(traceparent, span.SpanContext.TraceID().String()) {
+		t.Errorf("traceparent = %q, want the span's trace ID", traceparent)
+	}
+	for _, attr := range span.Attributes {
+		if strings.Contains(attr.Value.Emit(), "secret") {
+			t.Errorf("query string leaked into attribute %s", attr.Key)
+		}
+	}
+	if span.Status.Description == "" {
+		t.Error("5xx response should mark the span as an error")
+	}
+}
+
+func TestInjectEnv_roundTrip(t *testing.T) {
+	useRecorder(t)
+	ctx, span := StartSpan(context.Background(), "plugin.exec")
+	defer span.End()
+
+	env := InjectEnv(ctx, nil)
+	var found bool
+	for _, kv := range env {
+		found = found || strings.HasPrefix(kv, "TRACEPARENT=")
+	}
+	if !found {
+		t.Fatalf("TRACEPARENT not injected: %v", env)
+	}
+
+	extracted := propagator.Extract(context.Background(), envCarrier(env))
+	got := trace.SpanContextFromContext(extracted)
+	if got.TraceID() != span.SpanContext().TraceID() || got.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("extracted %v, want %v", got, span.SpanContext())
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	resetGlobals(t)
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	t.Setenv(EnvExporter, ExporterFile)
+	t.Setenv(EnvFile, path)
+
+	ctx, err := StartCommand(context.Background())
+	if err != nil {
+		t.Fatalf("StartCommand: %v", err)
+	}
+	_, span := StartSpan(ctx, "onboarding.check")
+	span.End()
+	EndCommand(nil)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read trace file: %v", err)
+	}
+	for _, name := range []string{`"Name":"datumctl"`, `"Name":"onboarding.check"`} {
+		if !strings.Contains(string(data), name) {
+			t.Errorf("trace file missing %s:\n%s", name, data)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package telemetry
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapTransport returns rt wrapped to record a client span per HTTP request
+// and propagate trace context upstream in the traceparent header. With
+// tracing off it returns rt itself.
+//
+// Place it innermost, directly over the wire transport, so the span measures
+// the round trip alone and each retry shows up as its own span.
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if !Enabled() {
+		return rt
+	}
+	return &tracingTransport{base: rt}
+}
+
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Tracer().Start(parentContext(req.Context()), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			// Path only: query strings can carry selectors with user data.
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+
+	// Clone before touching headers: a RoundTripper must not modify the
+	// caller's request.
+	out := req.Clone(ctx)
+	propagator.Inject(ctx, propagation.HeaderCarrier(out.Header))
+
+	resp, err := t.base.RoundTrip(out)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	// The span covers the time to response headers. Streaming bodies
+	// (watches, logs -f) would otherwise hold it open indefinitely.
+	span.End()
+	return resp, nil
+}
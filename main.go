@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 	"go.datum.net/datumctl/internal/cmd"
 	customerrors "go.datum.net/datumctl/internal/errors"
+	"go.datum.net/datumctl/internal/telemetry"
 	"go.miloapis.com/service-catalog/pkg/activation"
 	"k8s.io/component-base/cli"
 	"k8s.io/component-base/logs"
@@ -22,7 +23,6 @@ import (
 
 func main() {
 	logs.GlogSetter(kubectlcmd.GetLogVerbosity(os.Args))
-	rootCmd := cmd.RootCmd()
 
 	// Wire SIGINT/SIGTERM to a cancellable context so long interactive waits
 	// (e.g. the login callback/device-code polling) can be interrupted with
@@ -30,6 +30,17 @@ func main() {
 	// set via SetContext, so the signal-derived context reaches cmd.Context().
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
+
+	// Opt-in tracing (DATUMCTL_OTEL_EXPORTER). The command span must be
+	// ended and flushed on every exit path below, since os.Exit skips
+	// deferred calls.
+	ctx, err := telemetry.StartCommand(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: tracing disabled: %v\n", err)
+	}
+
+	// RootCmd may exec a plugin before returning, so tracing is set up first.
+	rootCmd := cmd.RootCmd()
 	rootCmd.SetContext(ctx)
 
 	// Route kubectl's internal fatal errors (from util.CheckErr) through the
@@ -37,19 +48,30 @@ func main() {
 	// we print kubectl's preformatted string verbatim to match legacy output;
 	// in structured modes we strip the "error: " prefix kubectl may add and
 	// re-encode the message inside the JSON/YAML envelope.
+	//
+	// kubectl's generic failure status is replaced by the registered exit code
+	// when the message matches one of kubectl's API status or connection error
+	// forms, so resource commands exit like datumctl-native ones.
 	util.BehaviorOnFatal(func(msg string, code int) {
 		msg = strings.TrimSuffix(msg, "\n")
+		errCode := customerrors.CodeOfKubectlMessage(msg)
+		if info, ok := customerrors.LookupCode(errCode); ok && code == util.DefaultErrorExitCode {
+			code = info.ExitCode
+		}
+		telemetry.EndCommand(errors.New(msg))
 		format := formatFor(rootCmd)
 		if format == customerrors.FormatHuman {
 			fmt.Fprintln(os.Stderr, msg)
 			os.Exit(code)
 		}
 		clean := strings.TrimPrefix(msg, "error: ")
-		customerrors.Format(os.Stderr, errors.New(clean), format, verbosity())
+		customerrors.Format(os.Stderr, customerrors.NewUserError(clean).WithCode(errCode), format, verbosity())
 		os.Exit(code)
 	})
 
-	if err := cli.RunNoErrOutput(rootCmd); err != nil {
+	err = cli.RunNoErrOutput(rootCmd)
+	telemetry.EndCommand(err)
+	if err != nil {
 		code, interrupted := exitCodeForError(err)
 		if interrupted {
 			// The user interrupted (^C / SIGTERM). Exit quietly instead of
@@ -65,12 +87,18 @@ func main() {
 // exitCodeForError maps a command error to a process exit code. interrupted is
 // true when the error was a user interrupt (^C / SIGTERM), which surfaces as a
 // canceled context and should be reported quietly with the conventional
-// 128+SIGINT exit code rather than as an error.
+// 128+SIGINT exit code rather than as an error. Service activation failures
+// keep their dedicated exit codes; otherwise errors carrying a registered code
+// (see 'datumctl errors list') exit with that code's exit status.
 func exitCodeForError(err error) (code int, interrupted bool) {
 	if errors.Is(err, context.Canceled) {
 		return 130, true
 	}
-	return activation.ExitCodeOf(err), false
+	if code := activation.ExitCodeOf(err); code != customerrors.ExitCodeGeneric {
+		return code, false
+	}
+	code, _ = customerrors.ExitCode(err)
+	return code, false
 }
 
 // formatFor reads --error-format off the parsed root command, falling back to
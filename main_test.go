@@ -5,6 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+
+	customerrors "go.datum.net/datumctl/internal/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // TestExitCodeForError_Interrupt verifies that a canceled context — how a
@@ -43,3 +47,33 @@ func TestExitCodeForError_NonInterrupt(t *testing.T) {
 		t.Fatal("expected a non-zero exit code for a failing command")
 	}
 }
+
+// TestExitCodeForError_RegisteredCodes verifies that errors carrying or
+// classified into a registered code exit with that code's distinct status.
+func TestExitCodeForError_RegisteredCodes(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"user error", customerrors.NewUserError("session expired").WithCode(customerrors.CodeAuthExpired), customerrors.CodeAuthExpired},
+		{"wrapped user error", fmt.Errorf("run: %w", customerrors.NewUserError("untrusted").WithCode(customerrors.CodePluginUntrusted)), customerrors.CodePluginUntrusted},
+		{"api forbidden", apierrors.NewForbidden(schema.GroupResource{Resource: "projects"}, "p", errors.New("denied")), customerrors.CodeForbidden},
+		{"api not found", apierrors.NewNotFound(schema.GroupResource{Resource: "projects"}, "p"), customerrors.CodeNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			info, ok := customerrors.LookupCode(tc.code)
+			if !ok {
+				t.Fatalf("code %s is not registered", tc.code)
+			}
+			code, interrupted := exitCodeForError(tc.err)
+			if interrupted {
+				t.Fatal("expected interrupted=false")
+			}
+			if code != info.ExitCode {
+				t.Fatalf("expected exit code %d, got %d", info.ExitCode, code)
+			}
+		})
+	}
+}
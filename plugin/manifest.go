@@ -15,6 +15,8 @@ type Manifest struct {
 	MinDatumctlVersion string `json:"min_datumctl_version,omitempty"`
 	APIVersion         int    `json:"api_version"`
 	MinAPIVersion      int    `json:"min_api_version,omitempty"`
+	// Tools are AI agent tools the plugin contributes. Serve them with ServeTools.
+	Tools []Tool `json:"tools,omitempty"`
 }
 
 // ServeManifest checks os.Args for --plugin-manifest. If found, it prints m as JSON
@@ -0,0 +1,78 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Tool declares an AI agent tool in the plugin manifest. datumctl's agent
+// offers it to the model as "<plugin>_<name>".
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// InputSchema is the JSON schema of the tool's arguments object.
+	InputSchema map[string]any `json:"input_schema,omitempty"`
+	// Mutating tools change state; datumctl asks the user to confirm each call.
+	Mutating bool `json:"mutating,omitempty"`
+}
+
+// ToolHandler runs one tool call. args is the JSON object the model passed;
+// the returned text is handed back to the model as the result.
+type ToolHandler func(ctx context.Context, args map[string]any) (string, error)
+
+// ServeTools checks os.Args for --plugin-tool <name>. If found, it reads the
+// call's arguments as a JSON object from stdin, runs the matching handler,
+// prints its result to stdout and exits 0; on error it prints the error to
+// stderr and exits 1. Like ServeManifest, call it before cobra.Execute().
+//
+// Context() and Token() work in tool calls as they do in commands.
+func ServeTools(handlers map[string]ToolHandler) {
+	if code, ok := serveTool(context.Background(), os.Args[1:], handlers, os.Stdin, os.Stdout, os.Stderr); ok {
+		os.Exit(code)
+	}
+}
+
+// serveTool implements ServeTools without exiting. It reports false when args
+// does not request a tool call.
+func serveTool(ctx context.Context, args []string, handlers map[string]ToolHandler, stdin io.Reader, stdout, stderr io.Writer) (int, bool) {
+	for i, arg := range args {
+		if arg != "--plugin-tool" {
+			continue
+		}
+		if i+1 >= len(args) {
+			fmt.Fprintln(stderr, "--plugin-tool requires a tool name")
+			return 1, true
+		}
+		name := args[i+1]
+		handler, ok := handlers[name]
+		if !ok {
+			fmt.Fprintf(stderr, "unknown tool %q\n", name)
+			return 1, true
+		}
+
+		var input map[string]any
+		data, err := io.ReadAll(stdin)
+		if err == nil && len(data) > 0 {
+			err = json.Unmarshal(data, &input)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "read tool arguments: %v\n", err)
+			return 1, true
+		}
+		if input == nil {
+			input = map[string]any{}
+		}
+
+		out, err := handler(ctx, input)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1, true
+		}
+		fmt.Fprint(stdout, out)
+		return 0, true
+	}
+	return 0, false
+}
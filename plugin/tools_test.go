@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestServeTool(t *testing.T) {
+	t.Parallel()
+
+	handlers := map[string]ToolHandler{
+		"echo_zone": func(ctx context.Context, args map[string]any) (string, error) {
+			zone, _ := args["zone"].(string)
+			return "zone=" + zone, nil
+		},
+		"fail": func(ctx context.Context, args map[string]any) (string, error) {
+			return "", errors.New("zone not found")
+		},
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantServed bool
+		wantCode   int
+		wantOut    string
+		wantErr    string
+	}{
+		{name: "no flag", args: []string{"zones", "list"}},
+		{name: "call", args: []string{"--plugin-tool", "echo_zone"}, stdin: `{"zone":"example.com"}`,
+			wantServed: true, wantOut: "zone=example.com"},
+		{name: "empty stdin", args: []string{"--plugin-tool", "echo_zone"}, wantServed: true, wantOut: "zone="},
+		{name: "handler error", args: []string{"--plugin-tool", "fail"}, wantServed: true, wantCode: 1, wantErr: "zone not found"},
+		{name: "unknown tool", args: []string{"--plugin-tool", "nope"}, wantServed: true, wantCode: 1, wantErr: "unknown tool"},
+		{name: "missing name", args: []string{"--plugin-tool"}, wantServed: true, wantCode: 1, wantErr: "requires a tool name"},
+		{name: "bad arguments", args: []string{"--plugin-tool", "echo_zone"}, stdin: "not json",
+			wantServed: true, wantCode: 1, wantErr: "read tool arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code, served := serveTool(context.Background(), tt.args, handlers, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if served != tt.wantServed || code != tt.wantCode {
+				t.Fatalf("serveTool = (%d, %v), want (%d, %v)", code, served, tt.wantCode, tt.wantServed)
+			}
+			if stdout.String() != tt.wantOut {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantOut)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantErr)
+			}
+		})
+	}
+}